@@ -0,0 +1,194 @@
+package fb2
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	etree "github.com/rupor-github/fb2converter/etree"
+)
+
+// ErrBrokenLink is returned by CheckLinks when internal link target
+// doesn't exist
+var ErrBrokenLink = errors.New("broken internal link")
+
+// SectionIDMode defines how section ids are generated
+type SectionIDMode int
+
+const (
+	// SectionIDNone leaves sections without ids
+	SectionIDNone SectionIDMode = iota
+	// SectionIDTitle makes ids from transliterated section titles
+	SectionIDTitle
+	// SectionIDSequential makes ids like section-1, section-2
+	SectionIDSequential
+)
+
+// SetSectionIDMode sets id generation for sections added later
+func (d *fb2) SetSectionIDMode(mode SectionIDMode) {
+	d.Lock()
+	defer d.Unlock()
+	d.sectionIDMode = mode
+}
+
+// uniqueID returns base or base with numeric suffix not present in ids
+// and marks it used
+func uniqueID(base string, ids map[string]bool) string {
+	id := base
+	for n := 2; ids[id]; n++ {
+		id = base + "-" + strconv.Itoa(n)
+	}
+	ids[id] = true
+	return id
+}
+
+// sequentialID returns first unused id prefix-N
+func sequentialID(prefix string, ids map[string]bool) string {
+	n := 1
+	for ids[prefix+"-"+strconv.Itoa(n)] {
+		n++
+	}
+	id := prefix + "-" + strconv.Itoa(n)
+	ids[id] = true
+	return id
+}
+
+// newID returns unique id for e. Sections get ids by mode,
+// other elements get sequential ids prefixed with tag.
+func newID(e *etree.Element, mode SectionIDMode, ids map[string]bool) string {
+	if e.Tag != "section" {
+		return sequentialID(e.Tag, ids)
+	}
+	if mode == SectionIDTitle {
+		if base := slugID(sectionTitle(e)); base != "" {
+			return uniqueID(base, ids)
+		}
+	}
+	return sequentialID("section", ids)
+}
+
+// assignSectionID sets id of new section according to id mode
+func (d *fb2) assignSectionID(section *etree.Element) {
+	if d.sectionIDMode == SectionIDNone || section.SelectAttrValue("id", "") != "" {
+		return
+	}
+	section.CreateAttr("id", newID(section, d.sectionIDMode, d.ids()))
+}
+
+// AssignSectionIDs sets ids of all main body sections without ids,
+// including nested ones. It returns the number of assigned ids.
+func (d *fb2) AssignSectionIDs(mode SectionIDMode) int {
+	d.Lock()
+	defer d.Unlock()
+	if mode == SectionIDNone {
+		return 0
+	}
+	ids := d.ids()
+	n := 0
+	for _, s := range d.body.FindElements("//section") {
+		if s.SelectAttrValue("id", "") == "" {
+			s.CreateAttr("id", newID(s, mode, ids))
+			n++
+		}
+	}
+	return n
+}
+
+// Anchor returns id of element e from the book, setting unique id
+// if e has none. The id is used as internal link target.
+func (d *fb2) Anchor(e *etree.Element) string {
+	d.Lock()
+	defer d.Unlock()
+	return d.anchor(e)
+}
+
+func (d *fb2) anchor(e *etree.Element) string {
+	if id := e.SelectAttrValue("id", ""); id != "" {
+		return id
+	}
+	mode := d.sectionIDMode
+	if mode == SectionIDNone {
+		mode = SectionIDTitle
+	}
+	id := newID(e, mode, d.ids())
+	e.CreateAttr("id", id)
+	return id
+}
+
+// SectionAnchor returns id of top level section i, setting it if needed
+func (d *fb2) SectionAnchor(i int) (string, error) {
+	d.Lock()
+	defer d.Unlock()
+	section, err := d.section(i)
+	if err != nil {
+		return "", err
+	}
+	return d.anchor(section), nil
+}
+
+// ParagraphAnchor returns id of paragraph p of top level section i,
+// setting it if needed. Paragraphs are counted from 0 among section
+// direct children, excluding title.
+func (d *fb2) ParagraphAnchor(i, p int) (string, error) {
+	d.Lock()
+	defer d.Unlock()
+	section, err := d.section(i)
+	if err != nil {
+		return "", err
+	}
+	ps := section.SelectElements("p")
+	if p < 0 || p >= len(ps) {
+		return "", fmt.Errorf("paragraph %d of section %d not found", p, i)
+	}
+	return d.anchor(ps[p]), nil
+}
+
+// InternalLink returns link to element with id inside the book
+func InternalLink(id string, c ...Inline) Inline {
+	return Link("#"+id, c...)
+}
+
+// AppendInline appends inline content to element, e.g. to paragraph
+// found in Section()
+func AppendInline(e *etree.Element, c ...Inline) {
+	appendInline(e, c...)
+}
+
+// CheckLinks verifies that internal links of all bodies point to
+// existing ids. Links are also checked on write.
+func (d *fb2) CheckLinks() error {
+	d.Lock()
+	defer d.Unlock()
+	root := etree.NewElement("FictionBook")
+	root.AddChild(d.body.Copy())
+	for _, b := range d.bodies {
+		root.AddChild(b.Copy())
+	}
+	return checkLinks(root)
+}
+
+// AllowBrokenLinks disables link check on write, so books with
+// links to missing anchors can be written
+func (d *fb2) AllowBrokenLinks(allow bool) {
+	d.Lock()
+	defer d.Unlock()
+	d.allowBrokenLinks = allow
+}
+
+// checkLinks verifies that internal links of document point to
+// existing ids
+func checkLinks(root *etree.Element) error {
+	ids := map[string]bool{}
+	collectIDs(root, ids)
+	for _, a := range root.FindElements("//a") {
+		href := a.SelectAttrValue("href", "")
+		if !strings.HasPrefix(href, "#") {
+			continue
+		}
+		if !ids[href[1:]] {
+			return fmt.Errorf("link %q error: %w", href, ErrBrokenLink)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package fb2
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func sectionIDs(d FB2) []string {
+	res := []string{}
+	for _, s := range d.Sections() {
+		res = append(res, s.ID)
+	}
+	return res
+}
+
+func Test_fb2_SectionIDMode(t *testing.T) {
+	tests := []struct {
+		name string
+		mode SectionIDMode
+		want []string
+	}{
+		{"none", SectionIDNone, []string{"", "", ""}},
+		{"title", SectionIDTitle, []string{"glava-1", "glava-1-2", "section-1"}},
+		{"sequential", SectionIDSequential, []string{"section-1", "section-2", "section-3"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewFB2("Test")
+			d.SetSectionIDMode(tt.mode)
+			d.AddSectionBlocks("Глава 1", P(Text("1")))
+			if err := d.AddSection("<p>2</p>", "Глава 1"); err != nil {
+				t.Fatalf("fb2.AddSection() error = %v", err)
+			}
+			if err := d.InsertSection(2, "* * *"); err != nil {
+				t.Fatalf("fb2.InsertSection() error = %v", err)
+			}
+			if got := sectionIDs(d); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("section ids = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_fb2_AssignSectionIDs(t *testing.T) {
+	src := `<?xml version="1.0" encoding="UTF-8"?>
+<FictionBook xmlns="http://www.gribuser.ru/xml/fictionbook/2.0" xmlns:l="http://www.w3.org/1999/xlink">
+  <description><title-info><book-title>Loaded</book-title></title-info></description>
+  <body>
+    <section><title><p>Часть 1</p></title>
+      <section id="chast-1"><title><p>Глава</p></title><p>Text</p></section>
+      <section><title><p>Глава</p></title><p>Text</p></section>
+    </section>
+  </body>
+</FictionBook>`
+	d, err := ReadFB2(strings.NewReader(src))
+	if err != nil {
+		t.Fatalf("ReadFB2() error = %v", err)
+	}
+	if got := d.AssignSectionIDs(SectionIDTitle); got != 2 {
+		t.Errorf("fb2.AssignSectionIDs() = %d, want 2", got)
+	}
+	got := []string{}
+	for _, s := range d.Body().FindElements("//section") {
+		got = append(got, s.SelectAttrValue("id", ""))
+	}
+	if want := []string{"chast-1-2", "chast-1", "glava"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("section ids = %v, want %v", got, want)
+	}
+}
+
+func Test_fb2_InternalLinks(t *testing.T) {
+	d := NewFB2("Test")
+	d.AddSectionBlocks("Введение", P(Text("Intro")), P(Text("Definition")))
+	d.AddSectionBlocks("Глава 1")
+	id, err := d.SectionAnchor(0)
+	if err != nil || id != "vvedenie" {
+		t.Fatalf("fb2.SectionAnchor() = %q, %v, want %q", id, err, "vvedenie")
+	}
+	pid, err := d.ParagraphAnchor(0, 1)
+	if err != nil || pid != "p-1" {
+		t.Fatalf("fb2.ParagraphAnchor() = %q, %v, want %q", pid, err, "p-1")
+	}
+	if again, _ := d.ParagraphAnchor(0, 1); again != pid {
+		t.Errorf("fb2.ParagraphAnchor() = %q, want stable %q", again, pid)
+	}
+	if _, err := d.ParagraphAnchor(0, 2); err == nil {
+		t.Errorf("fb2.ParagraphAnchor() expected error")
+	}
+	if err := d.ReplaceSectionContent(1,
+		P(Text("See "), InternalLink(id, Text("introduction")), Text(".")),
+	); err != nil {
+		t.Fatalf("fb2.ReplaceSectionContent() error = %v", err)
+	}
+	s, _ := d.Section(1)
+	AppendInline(s.SelectElement("p"), Text(" And "), InternalLink(pid, Text("definition")))
+	out, err := d.WriteToString()
+	if err != nil {
+		t.Fatalf("fb2.WriteToString() error = %v", err)
+	}
+	want := `<p>See <a l:href="#vvedenie">introduction</a>. And <a l:href="#p-1">definition</a></p>`
+	if !strings.Contains(out, want) {
+		t.Errorf("fb2.WriteToString() doesn't contain %s:\n%s", want, out)
+	}
+
+	if err := d.CheckLinks(); err != nil {
+		t.Errorf("fb2.CheckLinks() error = %v", err)
+	}
+	d.AddSectionBlocks("Broken", P(InternalLink("missing", Text("link"))))
+	if err := d.CheckLinks(); !errors.Is(err, ErrBrokenLink) {
+		t.Errorf("fb2.CheckLinks() error = %v, want %v", err, ErrBrokenLink)
+	}
+	if _, err := d.WriteToString(); !errors.Is(err, ErrBrokenLink) {
+		t.Errorf("fb2.WriteToString() error = %v, want %v", err, ErrBrokenLink)
+	}
+	d.AllowBrokenLinks(true)
+	if _, err := d.WriteToString(); err != nil {
+		t.Errorf("fb2.WriteToString() with broken links allowed error = %v", err)
+	}
+}
@@ -0,0 +1,103 @@
+package fb2
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	etree "github.com/rupor-github/fb2converter/etree"
+)
+
+// blankLineRe splits plain text into paragraphs
+var blankLineRe = regexp.MustCompile(`\n[ \t\r]*\n`)
+
+// textBlocks splits plain text on blank lines into paragraphs.
+// Single line breaks inside a paragraph are joined with spaces.
+func textBlocks(text string) []Block {
+	blocks := []Block{}
+	for _, p := range blankLineRe.Split(strings.ReplaceAll(text, "\r\n", "\n"), -1) {
+		p = strings.Join(strings.Fields(p), " ")
+		if p != "" {
+			blocks = append(blocks, P(Text(p)))
+		}
+	}
+	return blocks
+}
+
+// SetAnnotation sets book annotation from plain text.
+// Paragraphs are separated by blank lines.
+func (d *fb2) SetAnnotation(text string) {
+	d.Lock()
+	defer d.Unlock()
+	d.setAnnotationBlocks(textBlocks(text)...)
+}
+
+// SetAnnotationBlocks sets book annotation from paragraphs, poems,
+// cites, subtitles, tables and empty lines. No blocks removes annotation.
+func (d *fb2) SetAnnotationBlocks(blocks ...Block) error {
+	d.Lock()
+	defer d.Unlock()
+	return d.setAnnotationBlocks(blocks...)
+}
+
+func (d *fb2) setAnnotationBlocks(blocks ...Block) error {
+	if len(blocks) == 0 {
+		d.annotation = nil
+		return nil
+	}
+	a := etree.NewElement("annotation")
+	if err := AppendBlocks(a, blocks...); err != nil {
+		return fmt.Errorf("set annotation error: %w", err)
+	}
+	d.annotation = a
+	return nil
+}
+
+// SetAnnotationHTML sets book annotation converted from HTML fragment
+func (d *fb2) SetAnnotationHTML(s string) error {
+	d.Lock()
+	defer d.Unlock()
+	return d.setAnnotationHTML(s)
+}
+
+func (d *fb2) setAnnotationHTML(s string) error {
+	a := etree.NewElement("annotation")
+	if err := htmlToFB2(strings.NewReader(s), a); err != nil {
+		return err
+	}
+	if err := checkTables(a); err != nil {
+		return err
+	}
+	d.annotation = nil
+	if len(a.ChildElements()) != 0 {
+		d.annotation = a
+	}
+	return nil
+}
+
+// Annotation returns book annotation as plain text
+// with paragraphs separated by blank lines
+func (d *fb2) Annotation() string {
+	d.Lock()
+	defer d.Unlock()
+	if d.annotation == nil {
+		return ""
+	}
+	ps := []string{}
+	for _, e := range d.annotation.ChildElements() {
+		if t := plainText(e); t != "" {
+			ps = append(ps, t)
+		}
+	}
+	return strings.Join(ps, "\n\n")
+}
+
+// AnnotationElement returns a copy of annotation element or nil
+func (d *fb2) AnnotationElement() *etree.Element {
+	d.Lock()
+	defer d.Unlock()
+	if d.annotation == nil {
+		return nil
+	}
+	return d.annotation.Copy()
+}
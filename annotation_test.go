@@ -0,0 +1,158 @@
+package fb2
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	etree "github.com/rupor-github/fb2converter/etree"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+func Test_fb2_Annotation(t *testing.T) {
+	tests := []struct {
+		name     string
+		set      func(d FB2) error
+		wantText string
+		wantDesc string
+	}{
+		{
+			name: "plain text",
+			set: func(d FB2) error {
+				d.SetAnnotation("First line\ncontinued.\n\n  \nSecond paragraph.")
+				return nil
+			},
+			wantText: "First line continued.\n\nSecond paragraph.",
+			wantDesc: "<p>First line continued.</p>\n<p>Second paragraph.</p>",
+		},
+		{
+			name: "blocks",
+			set: func(d FB2) error {
+				d.SetAnnotationBlocks(
+					Subtitle(Text("About")),
+					P(Text("Book "), Strong(Text("about")), Text(" poems.")),
+					NewPoem("").AddStanza(NewStanza().AddVerse(Text("Verse one")).AddVerse(Text("Verse two"))),
+					NewCite("Quote").AddTextAuthor(Text("Author")),
+				)
+				return nil
+			},
+			wantText: "About\n\nBook about poems.\n\nVerse one\nVerse two\n\nQuote\nAuthor",
+		},
+		{
+			name: "html",
+			set: func(d FB2) error {
+				return d.SetAnnotationHTML(`<p>One <em>two</em></p><p>three</p>`)
+			},
+			wantText: "One two\n\nthree",
+			wantDesc: "<p>One <emphasis>two</emphasis></p>\n<p>three</p>",
+		},
+		{
+			name: "empty",
+			set: func(d FB2) error {
+				d.SetAnnotation("First")
+				d.SetAnnotation(" \n\n ")
+				return nil
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewFB2("Test")
+			if err := tt.set(d); err != nil {
+				t.Fatalf("set annotation error = %v", err)
+			}
+			if got := d.Annotation(); got != tt.wantText {
+				t.Errorf("fb2.Annotation() = %q, want %q", got, tt.wantText)
+			}
+			if tt.wantDesc != "" {
+				if got := d.Description(); got != tt.wantDesc {
+					t.Errorf("fb2.Description() = %q, want %q", got, tt.wantDesc)
+				}
+			}
+			out, err := d.WriteToString()
+			if err != nil {
+				t.Fatalf("fb2.WriteToString() error = %v", err)
+			}
+			hasAnnotation := strings.Contains(out, "<annotation>")
+			if hasAnnotation != (tt.wantText != "") {
+				t.Errorf("fb2.WriteToString() annotation present = %v, want %v", hasAnnotation, tt.wantText != "")
+			}
+			if (d.AnnotationElement() != nil) != (tt.wantText != "") {
+				t.Errorf("fb2.AnnotationElement() = %v", d.AnnotationElement())
+			}
+		})
+	}
+}
+
+func Test_fb2_AnnotationRoundTrip(t *testing.T) {
+	d := NewFB2("Test")
+	d.SetAnnotation("Para one.\n\nPara two.")
+	out, err := d.WriteToString()
+	if err != nil {
+		t.Fatalf("fb2.WriteToString() error = %v", err)
+	}
+	if i, j := strings.Index(out, "<annotation>"), strings.Index(out, "<lang"); i < 0 || j < i {
+		t.Errorf("annotation must precede lang in title-info:\n%s", out)
+	}
+	r, err := ReadFB2(strings.NewReader(out))
+	if err != nil {
+		t.Fatalf("ReadFB2() error = %v", err)
+	}
+	if got, want := r.Annotation(), "Para one.\n\nPara two."; got != want {
+		t.Errorf("fb2.Annotation() = %q, want %q", got, want)
+	}
+}
+
+func Test_fb2_AnnotationReadWrite(t *testing.T) {
+	d, err := OpenFB2("./testdata/test1.fb2")
+	if err != nil {
+		t.Fatalf("OpenFB2() error = %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		out, err := d.WriteToString()
+		if err != nil {
+			t.Fatalf("fb2.WriteToString() error = %v", err)
+		}
+		if n := strings.Count(out, "<annotation"); n != 1 {
+			t.Fatalf("written book has %d annotations, want 1", n)
+		}
+		if d, err = ReadFB2(strings.NewReader(out)); err != nil {
+			t.Fatalf("ReadFB2() error = %v", err)
+		}
+	}
+}
+
+func Test_fb2_SetAnnotationHTML_Checks(t *testing.T) {
+	d := NewFB2("Test")
+	d.SetAnnotation("Old")
+	err := d.SetAnnotationHTML(`<table><tr><td rowspan="3">1</td></tr></table>`)
+	if !errors.Is(err, ErrTableSpan) {
+		t.Errorf("fb2.SetAnnotationHTML() error = %v, want %v", err, ErrTableSpan)
+	}
+	if got := d.Annotation(); got != "Old" {
+		t.Errorf("fb2.Annotation() = %q, want old annotation", got)
+	}
+
+	// annotation images stay inline
+	nodes, err := html.ParseFragment(strings.NewReader(`<p><img src="a.png"></p>`),
+		&html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, blockImages := range []bool{false, true} {
+		c := &htmlConverter{image: func(string) string { return "a.png" }, blockImages: blockImages}
+		a := etree.NewElement("annotation")
+		for _, n := range nodes {
+			c.blockElement(n, a)
+		}
+		c.flush()
+		want := "p"
+		if blockImages {
+			want = "image"
+		}
+		if got := a.ChildElements(); len(got) != 1 || got[0].Tag != want {
+			t.Errorf("converted image (blockImages %v) = %s", blockImages, elementString(t, a))
+		}
+	}
+}
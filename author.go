@@ -0,0 +1,219 @@
+package fb2
+
+import "strings"
+
+// AuthorRole selects the author list of the book
+type AuthorRole int
+
+const (
+	// RoleAuthor is title-info author
+	RoleAuthor AuthorRole = iota
+	// RoleTranslator is title-info translator
+	RoleTranslator
+	// RoleDocumentAuthor is document-info author
+	RoleDocumentAuthor
+)
+
+// AuthorFormat is author name formatting style
+type AuthorFormat int
+
+const (
+	// AuthorFormatFull formats as "First Middle Last"
+	AuthorFormatFull AuthorFormat = iota
+	// AuthorFormatLastFirst formats as "Last First Middle"
+	AuthorFormatLastFirst
+	// AuthorFormatInitials formats as "F. M. Last"
+	AuthorFormatInitials
+	// AuthorFormatNickname formats as nickname if it's set,
+	// otherwise as AuthorFormatFull
+	AuthorFormatNickname
+)
+
+// NameOrder is the order of name parts in a parsed string
+type NameOrder int
+
+const (
+	// NameOrderAuto detects "Last, First" and "Last First Patronymic"
+	// forms, otherwise uses NameOrderFirstLast
+	NameOrderAuto NameOrder = iota
+	// NameOrderFirstLast is "First Middle Last", e.g. "J. R. R. Tolkien"
+	NameOrderFirstLast
+	// NameOrderLastFirst is "Last First Middle", e.g. "Толстой Лев Николаевич"
+	NameOrderLastFirst
+)
+
+// surnameParticles are lowercase prefixes that belong to the last name
+var surnameParticles = map[string]bool{
+	"van": true, "von": true, "der": true, "den": true, "de": true, "da": true,
+	"di": true, "du": true, "del": true, "la": true, "le": true, "ten": true,
+	"фон": true, "ван": true, "де": true, "да": true, "ди": true,
+}
+
+// patronymicSuffixes are Russian and Ukrainian patronymic endings
+var patronymicSuffixes = []string{
+	"вич", "вна", "ична", "инична", "ьич", "ич",
+}
+
+// Format returns author name in style. Authors without first and last
+// names are formatted as nickname in any style.
+func (a *AuthorType) Format(style AuthorFormat) string {
+	if a.FirstName == "" && a.MiddleName == "" && a.LastName == "" {
+		return a.Nickname
+	}
+	var parts []string
+	switch style {
+	case AuthorFormatNickname:
+		if a.Nickname != "" {
+			return a.Nickname
+		}
+		parts = []string{a.FirstName, a.MiddleName, a.LastName}
+	case AuthorFormatLastFirst:
+		parts = []string{a.LastName, a.FirstName, a.MiddleName}
+	case AuthorFormatInitials:
+		parts = []string{initials(a.FirstName), initials(a.MiddleName), a.LastName}
+	default:
+		parts = []string{a.FirstName, a.MiddleName, a.LastName}
+	}
+	res := parts[:0]
+	for _, p := range parts {
+		if p != "" {
+			res = append(res, p)
+		}
+	}
+	return strings.Join(res, " ")
+}
+
+// initials returns "J. R." for "John Ronald" or "J. R."
+func initials(name string) string {
+	words := splitName(name)
+	for i, w := range words {
+		for _, r := range w {
+			words[i] = string(r) + "."
+			break
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// splitName splits name on spaces and after initials dots,
+// so "J.R.R. Tolkien" gives "J.", "R.", "R.", "Tolkien"
+func splitName(name string) []string {
+	res := []string{}
+	for _, f := range strings.Fields(name) {
+		for f != "" {
+			i := strings.IndexRune(f, '.')
+			if i < 0 || i == len(f)-1 {
+				res = append(res, f)
+				break
+			}
+			res = append(res, f[:i+1])
+			f = f[i+1:]
+		}
+	}
+	return res
+}
+
+func isPatronymic(s string) bool {
+	s = strings.ToLower(s)
+	for _, suf := range patronymicSuffixes {
+		if strings.HasSuffix(s, suf) && len([]rune(s)) > len([]rune(suf))+2 {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseAuthor parses author name string into AuthorType.
+// A single word is treated as last name.
+func ParseAuthor(name string, order NameOrder) AuthorType {
+	name = strings.TrimSpace(name)
+	if order == NameOrderAuto {
+		order = NameOrderFirstLast
+		if i := strings.IndexRune(name, ','); i > 0 {
+			name = strings.TrimSpace(name[i+1:]) + " " + strings.TrimSpace(name[:i])
+		} else if w := strings.Fields(name); len(w) == 3 && isPatronymic(w[2]) && !isPatronymic(w[1]) {
+			order = NameOrderLastFirst
+		}
+	}
+	words := splitName(name)
+	a := AuthorType{}
+	switch {
+	case len(words) == 0:
+		return a
+	case len(words) == 1:
+		a.LastName = words[0]
+		return a
+	}
+	if order == NameOrderLastFirst {
+		a.LastName = words[0]
+		a.FirstName = words[1]
+		a.MiddleName = strings.Join(words[2:], " ")
+		return a
+	}
+	last := len(words) - 1
+	for last > 1 && surnameParticles[words[last-1]] {
+		last--
+	}
+	a.FirstName = words[0]
+	a.MiddleName = strings.Join(words[1:last], " ")
+	a.LastName = strings.Join(words[last:], " ")
+	return a
+}
+
+// authorList returns pointer to the author list for role
+func (d *fb2) authorList(role AuthorRole) *[]AuthorType {
+	switch role {
+	case RoleTranslator:
+		return &d.data.Description.TitleInfo.Translator
+	case RoleDocumentAuthor:
+		return &d.data.Description.DocumentInfo.Author
+	}
+	return &d.data.Description.TitleInfo.Author
+}
+
+// Authors returns authors with role
+func (d *fb2) Authors(role AuthorRole) []AuthorType {
+	d.Lock()
+	defer d.Unlock()
+	return append([]AuthorType{}, *d.authorList(role)...)
+}
+
+// AddAuthor appends author with role
+func (d *fb2) AddAuthor(role AuthorRole, author AuthorType) {
+	d.Lock()
+	defer d.Unlock()
+	l := d.authorList(role)
+	*l = append(*l, author)
+}
+
+// ReplaceAuthor replaces author with the same name as old.
+// It reports whether the author was found.
+func (d *fb2) ReplaceAuthor(role AuthorRole, old, author AuthorType) bool {
+	d.Lock()
+	defer d.Unlock()
+	l := *d.authorList(role)
+	for i := range l {
+		if sameAuthor(&l[i], &old) {
+			l[i] = author
+			return true
+		}
+	}
+	return false
+}
+
+// RemoveAuthor removes authors with the same name as author.
+// It reports whether any author was removed.
+func (d *fb2) RemoveAuthor(role AuthorRole, author AuthorType) bool {
+	d.Lock()
+	defer d.Unlock()
+	l := d.authorList(role)
+	res := []AuthorType{}
+	for i := range *l {
+		if !sameAuthor(&(*l)[i], &author) {
+			res = append(res, (*l)[i])
+		}
+	}
+	removed := len(res) != len(*l)
+	*l = res
+	return removed
+}
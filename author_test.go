@@ -0,0 +1,100 @@
+package fb2
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseAuthor(t *testing.T) {
+	tests := []struct {
+		name  string
+		order NameOrder
+		want  AuthorType
+	}{
+		{"Толстой Лев Николаевич", NameOrderAuto, AuthorType{FirstName: "Лев", MiddleName: "Николаевич", LastName: "Толстой"}},
+		{"Лев Николаевич Толстой", NameOrderAuto, AuthorType{FirstName: "Лев", MiddleName: "Николаевич", LastName: "Толстой"}},
+		{"Дмитрий Дмитриевич Шостакович", NameOrderAuto, AuthorType{FirstName: "Дмитрий", MiddleName: "Дмитриевич", LastName: "Шостакович"}},
+		{"J. R. R. Tolkien", NameOrderAuto, AuthorType{FirstName: "J.", MiddleName: "R. R.", LastName: "Tolkien"}},
+		{"J.R.R. Tolkien", NameOrderFirstLast, AuthorType{FirstName: "J.", MiddleName: "R. R.", LastName: "Tolkien"}},
+		{"Tolkien, J. R. R.", NameOrderAuto, AuthorType{FirstName: "J.", MiddleName: "R. R.", LastName: "Tolkien"}},
+		{"Ludwig van Beethoven", NameOrderAuto, AuthorType{FirstName: "Ludwig", LastName: "van Beethoven"}},
+		{"Стругацкий Аркадий", NameOrderLastFirst, AuthorType{FirstName: "Аркадий", LastName: "Стругацкий"}},
+		{"Гомер", NameOrderAuto, AuthorType{LastName: "Гомер"}},
+		{"  ", NameOrderAuto, AuthorType{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParseAuthor(tt.name, tt.order)
+			if !sameAuthor(&got, &tt.want) {
+				t.Errorf("ParseAuthor() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthorType_Format(t *testing.T) {
+	tolstoy := AuthorType{FirstName: "Лев", MiddleName: "Николаевич", LastName: "Толстой", Nickname: "Граф"}
+	nick := AuthorType{Nickname: "Lucky"}
+	tests := []struct {
+		name   string
+		author AuthorType
+		style  AuthorFormat
+		want   string
+	}{
+		{"Full", tolstoy, AuthorFormatFull, "Лев Николаевич Толстой"},
+		{"LastFirst", tolstoy, AuthorFormatLastFirst, "Толстой Лев Николаевич"},
+		{"Initials", tolstoy, AuthorFormatInitials, "Л. Н. Толстой"},
+		{"Nickname", tolstoy, AuthorFormatNickname, "Граф"},
+		{"Nickname fallback", nick, AuthorFormatFull, "Lucky"},
+		{"Initials without middle name", AuthorType{FirstName: "John", LastName: "Doe"}, AuthorFormatInitials, "J. Doe"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.author.Format(tt.style); got != tt.want {
+				t.Errorf("AuthorType.Format() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_fb2_Authors(t *testing.T) {
+	d := NewFB2("Test1Title")
+	tolkien := ParseAuthor("J. R. R. Tolkien", NameOrderAuto)
+	d.AddAuthor(RoleAuthor, tolkien)
+	d.AddAuthor(RoleAuthor, AuthorType{FirstName: "Christopher", LastName: "Tolkien"})
+	d.AddAuthor(RoleTranslator, ParseAuthor("Кистяковский Андрей", NameOrderLastFirst))
+	d.AddAuthor(RoleDocumentAuthor, AuthorType{Nickname: "scanner"})
+	if !d.RemoveAuthor(RoleAuthor, AuthorType{FirstName: "Christopher", LastName: "Tolkien"}) {
+		t.Errorf("fb2.RemoveAuthor() author not removed")
+	}
+	if !d.ReplaceAuthor(RoleDocumentAuthor, AuthorType{Nickname: "scanner"}, AuthorType{Nickname: "ocr"}) {
+		t.Errorf("fb2.ReplaceAuthor() author not replaced")
+	}
+	if d.ReplaceAuthor(RoleTranslator, AuthorType{Nickname: "scanner"}, AuthorType{}) {
+		t.Errorf("fb2.ReplaceAuthor() replaced missing author")
+	}
+	if got := d.Author(); got != "J. R. R. Tolkien" {
+		t.Errorf("fb2.Author() = %q", got)
+	}
+	d.SetDescription("Test")
+	out, err := d.WriteToString()
+	if err != nil {
+		t.Fatalf("fb2.WriteToString() error = %v", err)
+	}
+	if !strings.Contains(out, "<title>\n<p>J. R. R. Tolkien</p>") {
+		t.Errorf("fb2.WriteToString() no author in body title:\n%s", out)
+	}
+	if !strings.Contains(out, "<translator>") || strings.Contains(out, "<middle-name/>") {
+		t.Errorf("fb2.WriteToString() wrong authors:\n%s", out)
+	}
+	r, err := ReadFB2(strings.NewReader(out))
+	if err != nil {
+		t.Fatalf("ReadFB2() error = %v", err)
+	}
+	if got := r.Authors(RoleTranslator); len(got) != 1 || got[0].LastName != "Кистяковский" {
+		t.Errorf("fb2.Authors(RoleTranslator) = %+v", got)
+	}
+	if got := r.Authors(RoleDocumentAuthor); len(got) != 1 || got[0].Format(AuthorFormatFull) != "ocr" {
+		t.Errorf("fb2.Authors(RoleDocumentAuthor) = %+v", got)
+	}
+}
@@ -0,0 +1,214 @@
+package fb2
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image"
+	// image decoders for BinaryInfo dimensions
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	etree "github.com/rupor-github/fb2converter/etree"
+)
+
+// ErrBinaryNotFound is returned for binary ids missing in book
+var ErrBinaryNotFound = errors.New("binary not found")
+
+// binaryExts are file extensions by binary content type
+var binaryExts = map[string]string{
+	"image/jpeg":    ".jpg",
+	"image/jpg":     ".jpg",
+	"image/png":     ".png",
+	"image/gif":     ".gif",
+	"image/svg+xml": ".svg",
+	"image/webp":    ".webp",
+}
+
+// BinaryInfo describes book binary
+type BinaryInfo struct {
+	Id          string
+	ContentType string
+	// Size is decoded size in bytes, 0 for malformed base64
+	Size int
+	// Width and Height are image dimensions, 0 for binaries
+	// that aren't decodable images
+	Width  int
+	Height int
+	// Refs are locations of elements referring to binary,
+	// like "title-info/coverpage" or "body/section[2]/image"
+	Refs []string
+}
+
+// BinaryFileName returns safe file name of binary with extension
+// matching its content type
+func BinaryFileName(b FictionBookBinary) string {
+	name := filepath.Base(filepath.FromSlash(b.Id))
+	if name == "." || name == string(filepath.Separator) {
+		name = "image"
+	}
+	if ext, ok := binaryExts[strings.ToLower(b.ContentType)]; ok && !strings.EqualFold(filepath.Ext(name), ext) {
+		if e := strings.ToLower(filepath.Ext(name)); !(ext == ".jpg" && e == ".jpeg") {
+			name += ext
+		}
+	}
+	return name
+}
+
+// binaryRefs adds locations of image elements under e to refs
+// by binary id. Location of e is path.
+func binaryRefs(e *etree.Element, path string, refs map[string][]string) {
+	if e.Tag == "image" {
+		if href := e.SelectAttrValue("href", ""); strings.HasPrefix(href, "#") {
+			refs[href[1:]] = append(refs[href[1:]], path)
+		}
+	}
+	counts := map[string]int{}
+	for _, c := range e.ChildElements() {
+		counts[c.Tag]++
+	}
+	n := map[string]int{}
+	for _, c := range e.ChildElements() {
+		n[c.Tag]++
+		p := path + "/" + c.Tag
+		if counts[c.Tag] > 1 {
+			p += "[" + strconv.Itoa(n[c.Tag]) + "]"
+		}
+		binaryRefs(c, p, refs)
+	}
+}
+
+// refs returns locations of elements referring to binaries by id
+func (d *fb2) refs() map[string][]string {
+	refs := map[string][]string{}
+	desc := d.data.Description
+	for _, c := range desc.TitleInfo.Coverpage {
+		if c.Image != nil {
+			id := strings.TrimPrefix(c.Image.XlinkHref, "#")
+			refs[id] = append(refs[id], "title-info/coverpage")
+		}
+	}
+	if desc.SrcTitleInfo != nil {
+		for _, c := range desc.SrcTitleInfo.Coverpage {
+			if c.Image != nil {
+				id := strings.TrimPrefix(c.Image.XlinkHref, "#")
+				refs[id] = append(refs[id], "src-title-info/coverpage")
+			}
+		}
+	}
+	if d.annotation != nil {
+		binaryRefs(d.annotation, "title-info/annotation", refs)
+	}
+	path := "body"
+	if len(d.bodies) != 0 {
+		path = "body[1]"
+	}
+	binaryRefs(d.body, path, refs)
+	for i, b := range d.bodies {
+		binaryRefs(b, "body["+strconv.Itoa(i+2)+"]", refs)
+	}
+	return refs
+}
+
+// decodeBinary returns decoded content of binary
+func decodeBinary(b FictionBookBinary) ([]byte, error) {
+	data, err := base64.StdEncoding.DecodeString(stripSpaces(b.Text))
+	if err != nil {
+		return nil, fmt.Errorf("binary %q error: %w: %v", b.Id, ErrInvalidBinary, err)
+	}
+	return data, nil
+}
+
+// Binaries returns book binaries with their decoded size, image
+// dimensions and locations of elements referring to them
+func (d *fb2) Binaries() []BinaryInfo {
+	d.Lock()
+	defer d.Unlock()
+	refs := d.refs()
+	res := make([]BinaryInfo, 0, len(d.data.Binary))
+	for _, b := range d.data.Binary {
+		info := BinaryInfo{Id: b.Id, ContentType: b.ContentType, Refs: refs[b.Id]}
+		if data, err := decodeBinary(b); err == nil {
+			info.Size = len(data)
+			if cfg, _, err := image.DecodeConfig(bytes.NewReader(data)); err == nil {
+				info.Width, info.Height = cfg.Width, cfg.Height
+			}
+		}
+		res = append(res, info)
+	}
+	return res
+}
+
+// BinaryData returns decoded content of binary id
+func (d *fb2) BinaryData(id string) ([]byte, error) {
+	d.Lock()
+	defer d.Unlock()
+	b, ok := d.binary(id)
+	if !ok {
+		return nil, fmt.Errorf("binary %q error: %w", id, ErrBinaryNotFound)
+	}
+	return decodeBinary(b)
+}
+
+// ExtractBinaries saves book binaries to dir, creating it if needed.
+// File names get extensions matching content types, names clashing
+// with other binaries get numeric suffixes. It returns paths of saved
+// files by binary id.
+func (d *fb2) ExtractBinaries(dir string) (map[string]string, error) {
+	d.Lock()
+	defer d.Unlock()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("extract binaries error: %w", err)
+	}
+	paths := map[string]string{}
+	// names are compared ignoring case for case-insensitive file systems
+	used := map[string]bool{}
+	for _, b := range d.data.Binary {
+		data, err := decodeBinary(b)
+		if err != nil {
+			return paths, fmt.Errorf("extract binaries error: %w", err)
+		}
+		name := BinaryFileName(b)
+		ext := filepath.Ext(name)
+		base := strings.TrimSuffix(name, ext)
+		for n := 2; used[strings.ToLower(name)]; n++ {
+			name = base + "-" + strconv.Itoa(n) + ext
+		}
+		used[strings.ToLower(name)] = true
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, data, 0644); err != nil {
+			return paths, fmt.Errorf("extract binaries error: %w", err)
+		}
+		paths[b.Id] = path
+	}
+	return paths, nil
+}
+
+// ReplaceBinary replaces content of binary id keeping its id, so links
+// to it stay valid. Content type is detected from image data, content
+// type of other data is kept.
+func (d *fb2) ReplaceBinary(id string, data []byte) error {
+	d.Lock()
+	defer d.Unlock()
+	id = strings.TrimPrefix(id, "#")
+	for i := range d.data.Binary {
+		b := &d.data.Binary[i]
+		if b.Id != id {
+			continue
+		}
+		if contentType := http.DetectContentType(data); strings.HasPrefix(contentType, "image/") {
+			b.ContentType = contentType
+		}
+		b.Text = base64.StdEncoding.EncodeToString(data)
+		return nil
+	}
+	return fmt.Errorf("replace binary %q error: %w", id, ErrBinaryNotFound)
+}
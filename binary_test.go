@@ -0,0 +1,115 @@
+package fb2
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"image"
+	"image/png"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// pngImage returns PNG image of size w x h
+func pngImage(t *testing.T, w, h int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, w, h))); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestBinaries(t *testing.T) {
+	img := pngImage(t, 2, 3)
+	src := strings.ReplaceAll(splitSrc, "BBBB", base64.StdEncoding.EncodeToString(img))
+	book, err := ReadFB2(strings.NewReader(src))
+	if err != nil {
+		t.Fatalf("ReadFB2() error = %v", err)
+	}
+	want := []BinaryInfo{
+		{Id: "cover.jpg", ContentType: "image/jpeg", Size: 3, Refs: []string{"title-info/coverpage"}},
+		{Id: "map.png", ContentType: "image/png", Size: len(img), Width: 2, Height: 3, Refs: []string{"body[1]/section[2]/image"}},
+	}
+	if got := book.Binaries(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Binaries() = %+v, want %+v", got, want)
+	}
+
+	data, err := book.BinaryData("map.png")
+	if err != nil || !bytes.Equal(data, img) {
+		t.Errorf("BinaryData() = %v, %v", data, err)
+	}
+	if _, err := book.BinaryData("none"); !errors.Is(err, ErrBinaryNotFound) {
+		t.Errorf("BinaryData() error = %v, want %v", err, ErrBinaryNotFound)
+	}
+
+	dir := t.TempDir()
+	paths, err := book.ExtractBinaries(dir)
+	if err != nil {
+		t.Fatalf("ExtractBinaries() error = %v", err)
+	}
+	if wantPaths := map[string]string{"cover.jpg": filepath.Join(dir, "cover.jpg"), "map.png": filepath.Join(dir, "map.png")}; !reflect.DeepEqual(paths, wantPaths) {
+		t.Errorf("ExtractBinaries() = %v, want %v", paths, wantPaths)
+	}
+	if data, _ := ioutil.ReadFile(paths["map.png"]); !bytes.Equal(data, img) {
+		t.Errorf("ExtractBinaries() saved %v, want %v", data, img)
+	}
+
+	cover := pngImage(t, 4, 5)
+	if err := book.ReplaceBinary("#cover.jpg", cover); err != nil {
+		t.Fatalf("ReplaceBinary() error = %v", err)
+	}
+	if info := book.Binaries()[0]; info.Id != "cover.jpg" || info.ContentType != "image/png" || info.Width != 4 || info.Height != 5 {
+		t.Errorf("ReplaceBinary() binary = %+v", info)
+	}
+	if err := book.ReplaceBinary("none", cover); !errors.Is(err, ErrBinaryNotFound) {
+		t.Errorf("ReplaceBinary() error = %v, want %v", err, ErrBinaryNotFound)
+	}
+}
+
+func TestExtractBinaries_Clashes(t *testing.T) {
+	book := NewFB2("Clashes")
+	data := book.Data()
+	for _, id := range []string{"a/cover.jpg", "b/cover.jpg", "Cover.jpg", "x"} {
+		data.Binary = append(data.Binary, FictionBookBinary{Id: id, ContentType: "image/jpeg", Text: base64.StdEncoding.EncodeToString([]byte(id))})
+	}
+	dir := t.TempDir()
+	paths, err := book.ExtractBinaries(dir)
+	if err != nil {
+		t.Fatalf("ExtractBinaries() error = %v", err)
+	}
+	want := map[string]string{
+		"a/cover.jpg": filepath.Join(dir, "cover.jpg"),
+		"b/cover.jpg": filepath.Join(dir, "cover-2.jpg"),
+		"Cover.jpg":   filepath.Join(dir, "Cover-3.jpg"),
+		"x":           filepath.Join(dir, "x.jpg"),
+	}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("ExtractBinaries() = %v, want %v", paths, want)
+	}
+	for id, path := range paths {
+		if got, _ := ioutil.ReadFile(path); string(got) != id {
+			t.Errorf("ExtractBinaries() saved %q to %s, want %q", got, path, id)
+		}
+	}
+}
+
+func TestBinaryFileName(t *testing.T) {
+	tests := []struct {
+		id, contentType, want string
+	}{
+		{"cover.jpg", "image/jpeg", "cover.jpg"},
+		{"cover.jpeg", "image/jpeg", "cover.jpeg"},
+		{"img1", "image/png", "img1.png"},
+		{"../x.gif", "image/gif", "x.gif"},
+		{"data", "application/octet-stream", "data"},
+	}
+	for _, tt := range tests {
+		if got := BinaryFileName(FictionBookBinary{Id: tt.id, ContentType: tt.contentType}); got != tt.want {
+			t.Errorf("BinaryFileName(%q) = %q, want %q", tt.id, got, tt.want)
+		}
+	}
+}
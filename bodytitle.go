@@ -0,0 +1,116 @@
+package fb2
+
+import (
+	"strings"
+
+	etree "github.com/rupor-github/fb2converter/etree"
+)
+
+// BodyTitle configures body title block generated from book metadata
+// each time the book is written
+type BodyTitle struct {
+	// Authors adds title-info authors line
+	Authors bool
+	// AuthorFormat is the authors line style
+	AuthorFormat AuthorFormat
+	// Title adds book title line
+	Title bool
+	// Sequence adds title-info sequences line
+	Sequence bool
+	// Subtitles are extra lines added after the title
+	Subtitles []string
+	// Image is binary id of the image placed before body title
+	Image string
+	// Epigraph paragraphs placed after body title before
+	// epigraphs added with AddBodyEpigraph
+	Epigraph []string
+	// EpigraphAuthor is epigraph text-author
+	EpigraphAuthor string
+}
+
+// DefaultBodyTitle is used for books created with NewFB2
+var DefaultBodyTitle = BodyTitle{
+	Authors: true,
+	Title:   true,
+}
+
+// SetBodyTitle sets body title template. Nil template keeps body
+// title as it is, this is default for loaded books.
+func (d *fb2) SetBodyTitle(t *BodyTitle) {
+	d.Lock()
+	defer d.Unlock()
+	if t == nil {
+		d.bodyTitle = nil
+		return
+	}
+	bt := *t
+	bt.Subtitles = append([]string{}, t.Subtitles...)
+	bt.Epigraph = append([]string{}, t.Epigraph...)
+	d.bodyTitle = &bt
+}
+
+// bodyTitleLines returns body title paragraphs
+func (d *fb2) bodyTitleLines() []string {
+	t := d.bodyTitle
+	ti := &d.data.Description.TitleInfo
+	lines := []string{}
+	if t.Authors {
+		authors := []string{}
+		for i := range ti.Author {
+			authors = append(authors, ti.Author[i].Format(t.AuthorFormat))
+		}
+		lines = append(lines, strings.Join(authors, ", "))
+	}
+	if t.Title {
+		lines = append(lines, ti.BookTitle)
+	}
+	if t.Sequence {
+		seqs := []string{}
+		for i := range ti.Sequence {
+			seqs = append(seqs, ti.Sequence[i].String())
+		}
+		lines = append(lines, strings.Join(seqs, "; "))
+	}
+	lines = append(lines, t.Subtitles...)
+	res := lines[:0]
+	for _, l := range lines {
+		if strings.TrimSpace(l) != "" {
+			res = append(res, l)
+		}
+	}
+	return res
+}
+
+// writeBodyTitle replaces leading image and title of body
+// with generated ones and adds template epigraph
+func (d *fb2) writeBodyTitle(body *etree.Element) {
+	if d.bodyTitle == nil {
+		return
+	}
+	for _, e := range body.ChildElements() {
+		if e.Tag != "image" && e.Tag != "title" {
+			break
+		}
+		body.RemoveChild(e)
+	}
+	var first etree.Token
+	if elems := body.ChildElements(); len(elems) > 0 {
+		first = elems[0]
+	}
+	if d.bodyTitle.Image != "" {
+		img := etree.NewElement("image")
+		img.CreateAttr("l:href", "#"+strings.TrimPrefix(d.bodyTitle.Image, "#"))
+		img.SetTail("\n")
+		body.InsertChild(first, img)
+	}
+	if lines := d.bodyTitleLines(); len(lines) != 0 {
+		body.InsertChild(first, newTitle(lines...).SetTail("\n"))
+	}
+	if len(d.bodyTitle.Epigraph) != 0 {
+		ep := NewEpigraph(d.bodyTitle.Epigraph...)
+		if d.bodyTitle.EpigraphAuthor != "" {
+			ep.AddTextAuthor(Text(d.bodyTitle.EpigraphAuthor))
+		}
+		body.InsertChild(first, ep.Element().SetTail("\n"))
+	}
+}
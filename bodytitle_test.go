@@ -0,0 +1,95 @@
+package fb2
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_fb2_SetBodyTitle(t *testing.T) {
+	tests := []struct {
+		name  string
+		title *BodyTitle
+		want  string
+	}{
+		{
+			name:  "Default",
+			title: &DefaultBodyTitle,
+			want: `<body>
+<title>
+<p>Лев Николаевич Толстой</p>
+<p>Война и мир</p>
+</title>
+<section>`,
+		},
+		{
+			name: "Full template",
+			title: &BodyTitle{
+				Authors:        true,
+				AuthorFormat:   AuthorFormatInitials,
+				Title:          true,
+				Sequence:       true,
+				Subtitles:      []string{"Роман-эпопея"},
+				Image:          "cover",
+				Epigraph:       []string{"Все счастливые семьи похожи друг на друга."},
+				EpigraphAuthor: "Л. Толстой",
+			},
+			want: `<body>
+<image l:href="#cover"/>
+<title>
+<p>Л. Н. Толстой</p>
+<p>Война и мир</p>
+<p>Собрание: 5</p>
+<p>Роман-эпопея</p>
+</title>
+<epigraph>
+<p>Все счастливые семьи похожи друг на друга.</p>
+<text-author>Л. Толстой</text-author>
+</epigraph>
+<section>`,
+		},
+		{
+			name:  "No title",
+			title: nil,
+			want: `<body>
+<section>`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewFB2("Война и мир")
+			d.SetAuthor(ParseAuthor("Толстой Лев Николаевич", NameOrderAuto))
+			d.SetSequence("Собрание", 5)
+			d.SetDescription("Test")
+			d.AddSection("<p>Text</p>", "Chapter 1")
+			d.SetBodyTitle(tt.title)
+			// the second write must give the same output
+			for i := 0; i < 2; i++ {
+				out, err := d.WriteToString()
+				if err != nil {
+					t.Fatalf("fb2.WriteToString() error = %v", err)
+				}
+				if !strings.Contains(out, tt.want) {
+					t.Fatalf("fb2.WriteToString() want:\n%s\ngot:\n%s", tt.want, out)
+				}
+			}
+		})
+	}
+}
+
+func Test_fb2_SetBodyTitle_Loaded(t *testing.T) {
+	d, err := OpenFB2("./testdata/test1.fb2")
+	if err != nil {
+		t.Fatalf("OpenFB2() error = %v", err)
+	}
+	if _, err := d.WriteToString(); err != nil {
+		t.Errorf("fb2.WriteToString() error = %v", err)
+	}
+	d.SetBodyTitle(&DefaultBodyTitle)
+	out, err := d.WriteToString()
+	if err != nil {
+		t.Fatalf("fb2.WriteToString() error = %v", err)
+	}
+	if !strings.Contains(out, "<title>\n<p>fb2test data</p>\n<p>dsa</p>\n</title>") {
+		t.Errorf("fb2.WriteToString() body title not generated:\n%s", out)
+	}
+}
@@ -0,0 +1,82 @@
+package fb2
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// cp1251High are runes of windows-1251 bytes 0x80-0xBF.
+// Bytes 0xC0-0xFF are Cyrillic letters А-я.
+var cp1251High = [64]rune{
+	0x0402, 0x0403, 0x201a, 0x0453, 0x201e, 0x2026, 0x2020, 0x2021,
+	0x20ac, 0x2030, 0x0409, 0x2039, 0x040a, 0x040c, 0x040b, 0x040f,
+	0x0452, 0x2018, 0x2019, 0x201c, 0x201d, 0x2022, 0x2013, 0x2014,
+	0xfffd, 0x2122, 0x0459, 0x203a, 0x045a, 0x045c, 0x045b, 0x045f,
+	0x00a0, 0x040e, 0x045e, 0x0408, 0x00a4, 0x0490, 0x00a6, 0x00a7,
+	0x0401, 0x00a9, 0x0404, 0x00ab, 0x00ac, 0x00ad, 0x00ae, 0x0407,
+	0x00b0, 0x00b1, 0x0406, 0x0456, 0x0491, 0x00b5, 0x00b6, 0x00b7,
+	0x0451, 0x2116, 0x0454, 0x00bb, 0x0458, 0x0405, 0x0455, 0x0457,
+}
+
+// decodeCP1251 decodes windows-1251 text
+func decodeCP1251(b []byte) string {
+	var sb strings.Builder
+	sb.Grow(len(b) * 2)
+	for _, c := range b {
+		switch {
+		case c < 0x80:
+			sb.WriteByte(c)
+		case c < 0xc0:
+			sb.WriteRune(cp1251High[c-0x80])
+		default:
+			sb.WriteRune(rune(c-0xc0) + 0x0410)
+		}
+	}
+	return sb.String()
+}
+
+// decodeText returns UTF-8 text without byte order mark. Text that
+// isn't valid UTF-8 is decoded as windows-1251.
+func decodeText(b []byte) string {
+	b = bytes.TrimPrefix(b, []byte("\xef\xbb\xbf"))
+	if utf8.Valid(b) {
+		return string(b)
+	}
+	return decodeCP1251(b)
+}
+
+// cp1251Reader decodes windows-1251 stream into UTF-8
+type cp1251Reader struct {
+	r io.Reader
+	// buf is decoded text not read yet
+	buf []byte
+}
+
+func (c *cp1251Reader) Read(p []byte) (int, error) {
+	if len(c.buf) == 0 {
+		raw := make([]byte, 4096)
+		n, err := c.r.Read(raw)
+		if n == 0 {
+			return 0, err
+		}
+		c.buf = []byte(decodeCP1251(raw[:n]))
+	}
+	n := copy(p, c.buf)
+	c.buf = c.buf[n:]
+	return n, nil
+}
+
+// charsetReader returns reader decoding text in charset into UTF-8.
+// Only UTF-8 and windows-1251 are supported.
+func charsetReader(charset string, r io.Reader) (io.Reader, error) {
+	switch strings.ToLower(charset) {
+	case "", "utf-8", "utf8":
+		return r, nil
+	case "windows-1251", "cp1251":
+		return &cp1251Reader{r: r}, nil
+	}
+	return nil, fmt.Errorf("unsupported charset %q", charset)
+}
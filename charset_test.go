@@ -0,0 +1,22 @@
+package fb2
+
+import "testing"
+
+func Test_decodeText(t *testing.T) {
+	tests := []struct {
+		name string
+		src  []byte
+		want string
+	}{
+		{"utf-8", []byte("Привет"), "Привет"},
+		{"bom", []byte("\xef\xbb\xbfHello"), "Hello"},
+		{"windows-1251", []byte("\xcf\xf0\xe8\xe2\xe5\xf2 \xb8\xb9"), "Привет ё№"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := decodeText(tt.src); got != tt.want {
+				t.Errorf("decodeText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
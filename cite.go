@@ -0,0 +1,68 @@
+package fb2
+
+import (
+	etree "github.com/rupor-github/fb2converter/etree"
+)
+
+// CiteBuilder builds cite element
+type CiteBuilder struct {
+	id         string
+	lang       string
+	blocks     []Block
+	textAuthor [][]Inline
+}
+
+// NewCite returns cite builder. Lines are added as paragraphs.
+func NewCite(lines ...string) *CiteBuilder {
+	c := &CiteBuilder{}
+	for _, l := range lines {
+		c.blocks = append(c.blocks, P(Text(l)))
+	}
+	return c
+}
+
+// SetID sets cite id
+func (c *CiteBuilder) SetID(id string) *CiteBuilder {
+	c.id = id
+	return c
+}
+
+// SetLang sets cite language
+func (c *CiteBuilder) SetLang(lang string) *CiteBuilder {
+	c.lang = lang
+	return c
+}
+
+// AddParagraph appends paragraph
+func (c *CiteBuilder) AddParagraph(content ...Inline) *CiteBuilder {
+	c.blocks = append(c.blocks, P(content...))
+	return c
+}
+
+// AddBlock appends paragraph, poem, subtitle, table or empty line
+func (c *CiteBuilder) AddBlock(blocks ...Block) *CiteBuilder {
+	c.blocks = append(c.blocks, blocks...)
+	return c
+}
+
+// AddTextAuthor appends text-author line
+func (c *CiteBuilder) AddTextAuthor(content ...Inline) *CiteBuilder {
+	c.textAuthor = append(c.textAuthor, content)
+	return c
+}
+
+// Element returns cite element
+func (c *CiteBuilder) Element() *etree.Element {
+	e := etree.NewElement("cite")
+	if c.id != "" {
+		e.CreateAttr("id", c.id)
+	}
+	if c.lang != "" {
+		e.CreateAttr("xml:lang", c.lang)
+	}
+	appendBlocks(e, c.blocks...)
+	for _, ta := range c.textAuthor {
+		appendBlocks(e, newBlock("text-author", ta...))
+	}
+	return e
+}
@@ -0,0 +1,98 @@
+package fb2
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCiteBuilder(t *testing.T) {
+	cite := NewCite("Быть или не быть, вот в чём вопрос.").
+		SetID("c1").
+		AddBlock(EmptyLine()).
+		AddParagraph(Emphasis(Text("Гамлет"))).
+		AddTextAuthor(Text("У. Шекспир"))
+	want := `<cite id="c1">
+<p>Быть или не быть, вот в чём вопрос.</p>
+<empty-line/>
+<p><emphasis>Гамлет</emphasis></p>
+<text-author>У. Шекспир</text-author>
+</cite>`
+	if got := elementString(t, cite.Element()); got != want {
+		t.Errorf("CiteBuilder.Element() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func Test_fb2_Epigraphs(t *testing.T) {
+	d := NewFB2("Анна Каренина")
+	d.SetBodyTitle(&BodyTitle{Title: true, Epigraph: []string{"Мне отмщение, и Аз воздам"}})
+	d.AddBodyEpigraph(NewEpigraph("Все счастливые семьи похожи друг на друга").
+		AddTextAuthor(Text("Л. Толстой")))
+	d.AddSectionBlocks("Часть первая",
+		P(Text("Все смешалось в доме Облонских.")),
+		NewCite("Цитата").AddTextAuthor(Text("Автор")))
+	section := d.Body().SelectElement("section")
+	SetSectionAnnotation(section, P(Text("Old annotation")))
+	AddEpigraph(section, NewEpigraph("Section epigraph"))
+	SetSectionAnnotation(section, P(Text("Section annotation")))
+	d.SetDescription("Test")
+	out, err := d.WriteToString()
+	if err != nil {
+		t.Fatalf("fb2.WriteToString() error = %v", err)
+	}
+	want := `<body>
+<title>
+<p>Анна Каренина</p>
+</title>
+<epigraph>
+<p>Мне отмщение, и Аз воздам</p>
+</epigraph>
+<epigraph>
+<p>Все счастливые семьи похожи друг на друга</p>
+<text-author>Л. Толстой</text-author>
+</epigraph>
+<section>
+<title>
+<p>Часть первая</p>
+</title>
+<epigraph>
+<p>Section epigraph</p>
+</epigraph>
+<annotation>
+<p>Section annotation</p>
+</annotation>
+<p>Все смешалось в доме Облонских.</p>
+<cite>
+<p>Цитата</p>
+<text-author>Автор</text-author>
+</cite>
+</section>`
+	if !strings.Contains(out, want) {
+		t.Errorf("fb2.WriteToString() want:\n%s\ngot:\n%s", want, out)
+	}
+}
+
+func Test_fb2_EpigraphChecks(t *testing.T) {
+	d := NewFB2("Test")
+	d.AddSectionBlocks("One", NewCite("text").SetID("p1"))
+	section := d.Body().SelectElement("section")
+	badTable := NewCite().AddBlock(NewTable().AddRow(Cell(Text("a")).WithSpan(1, 2)))
+	if err := AddEpigraph(section, NewEpigraph("e").SetID("p1")); !errors.Is(err, ErrDuplicateID) {
+		t.Errorf("AddEpigraph() error = %v, want %v", err, ErrDuplicateID)
+	}
+	if err := AddEpigraph(section, NewEpigraph().AddBlock(badTable)); !errors.Is(err, ErrTableSpan) {
+		t.Errorf("AddEpigraph() error = %v, want %v", err, ErrTableSpan)
+	}
+	if err := d.AddBodyEpigraph(NewEpigraph("e").SetID("p1")); !errors.Is(err, ErrDuplicateID) {
+		t.Errorf("fb2.AddBodyEpigraph() error = %v, want %v", err, ErrDuplicateID)
+	}
+	if err := d.AddBodyEpigraph(NewEpigraph().AddBlock(badTable)); !errors.Is(err, ErrTableSpan) {
+		t.Errorf("fb2.AddBodyEpigraph() error = %v, want %v", err, ErrTableSpan)
+	}
+	if err := SetSectionAnnotation(section, NewCite("c").SetID("p1")); !errors.Is(err, ErrDuplicateID) {
+		t.Errorf("SetSectionAnnotation() error = %v, want %v", err, ErrDuplicateID)
+	}
+	if n := len(d.Body().FindElements("//epigraph")) + len(section.SelectElements("annotation")); n != 0 {
+		t.Errorf("rejected epigraphs and annotations must not be added, got %d", n)
+	}
+}
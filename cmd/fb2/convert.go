@@ -0,0 +1,272 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	iofs "io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	fb2 "github.com/karantin2020/go-fb2"
+)
+
+// readers open books by input format
+var readers = map[string]func(path string) (fb2.FB2, error){
+	".fb2":      fb2.OpenFB2,
+	".fb2.zip":  fb2.OpenFB2,
+	".txt":      fb2.OpenText,
+	".html":     fb2.OpenHTML,
+	".htm":      fb2.OpenHTML,
+	".xhtml":    fb2.OpenHTML,
+	".md":       fb2.OpenMarkdown,
+	".markdown": fb2.OpenMarkdown,
+	".epub":     fb2.OpenEPUB,
+}
+
+// writers write books by output format, name is output file name
+var writers = map[string]func(book fb2.FB2, w io.Writer, name string) error{
+	"fb2": func(book fb2.FB2, w io.Writer, name string) error {
+		s, err := book.WriteToString()
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(w, s)
+		return err
+	},
+	"fb2.zip": func(book fb2.FB2, w io.Writer, name string) error {
+		return book.WriteZip(w, strings.TrimSuffix(name, ".zip"))
+	},
+	"txt":  func(book fb2.FB2, w io.Writer, name string) error { return book.WriteText(w) },
+	"html": func(book fb2.FB2, w io.Writer, name string) error { return book.WriteHTML(w) },
+	"md":   func(book fb2.FB2, w io.Writer, name string) error { return book.WriteMarkdown(w) },
+	"epub": func(book fb2.FB2, w io.Writer, name string) error { return book.WriteEPUB(w) },
+}
+
+// inputFormat returns lowercase extension of book file, empty
+// for unsupported files
+func inputFormat(path string) string {
+	lower := strings.ToLower(path)
+	if strings.HasSuffix(lower, ".fb2.zip") {
+		return ".fb2.zip"
+	}
+	ext := filepath.Ext(lower)
+	if _, ok := readers[ext]; !ok {
+		return ""
+	}
+	return ext
+}
+
+// convertJob is input file with output directory relative to -o
+// and output path
+type convertJob struct {
+	path string
+	rel  string
+	dest string
+	// err is set for jobs that can't be run, like ones writing
+	// the same output as other jobs
+	err error
+}
+
+// convertResult is result of single conversion
+type convertResult struct {
+	job  convertJob
+	dest string
+	err  error
+}
+
+// convertInputs expands directories and globs into input files with
+// output paths in dir. Files of output format found by expansion are
+// skipped. Inputs given several times are converted once, jobs with
+// the same output path get errors.
+func convertInputs(args []string, dir, to string) ([]convertJob, []error) {
+	jobs, errs := expandInputs(args, to)
+	res := make([]convertJob, 0, len(jobs))
+	seen := map[string]bool{}
+	dests := map[string][]int{}
+	for _, job := range jobs {
+		if abs, err := filepath.Abs(job.path); err == nil {
+			if seen[abs] {
+				continue
+			}
+			seen[abs] = true
+		}
+		job.dest = outputPath(job, dir, to)
+		dests[job.dest] = append(dests[job.dest], len(res))
+		res = append(res, job)
+	}
+	for dest, idx := range dests {
+		if len(idx) < 2 {
+			continue
+		}
+		paths := make([]string, 0, len(idx))
+		for _, i := range idx {
+			paths = append(paths, res[i].path)
+		}
+		for _, i := range idx {
+			res[i].err = fmt.Errorf("output %s is written by several inputs: %s", dest, strings.Join(paths, ", "))
+		}
+	}
+	return res, errs
+}
+
+// expandInputs expands directories and globs into input files
+func expandInputs(args []string, to string) ([]convertJob, []error) {
+	jobs := []convertJob{}
+	errs := []error{}
+	skip := func(path string) bool {
+		f := inputFormat(path)
+		return f == "" || f == "."+to
+	}
+	for _, arg := range args {
+		st, err := os.Stat(arg)
+		switch {
+		case err == nil && st.IsDir():
+			err := filepath.WalkDir(arg, func(path string, e iofs.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+				if e.IsDir() || skip(path) {
+					return nil
+				}
+				rel, err := filepath.Rel(arg, filepath.Dir(path))
+				if err != nil {
+					return err
+				}
+				jobs = append(jobs, convertJob{path: path, rel: rel})
+				return nil
+			})
+			if err != nil {
+				errs = append(errs, err)
+			}
+		case err == nil:
+			jobs = append(jobs, convertJob{path: arg})
+		default:
+			matches, gerr := filepath.Glob(arg)
+			if gerr != nil || len(matches) == 0 {
+				errs = append(errs, err)
+				continue
+			}
+			for _, m := range matches {
+				if st, err := os.Stat(m); err == nil && !st.IsDir() && !skip(m) {
+					jobs = append(jobs, convertJob{path: m})
+				}
+			}
+		}
+	}
+	return jobs, errs
+}
+
+// outputPath returns path of converted book. Output is written
+// next to input if dir is empty.
+func outputPath(job convertJob, dir, to string) string {
+	name := filepath.Base(job.path)
+	name = name[:len(name)-len(inputFormat(name))]
+	if dir == "" {
+		dir = filepath.Dir(job.path)
+	} else {
+		dir = filepath.Join(dir, job.rel)
+	}
+	return filepath.Join(dir, name+"."+to)
+}
+
+// convertFile converts book from src to dest in format to
+func convertFile(src, dest, to string) error {
+	format := inputFormat(src)
+	read, ok := readers[format]
+	if !ok {
+		return fmt.Errorf("unsupported input format %q", filepath.Ext(src))
+	}
+	if filepath.Clean(src) == filepath.Clean(dest) {
+		return errors.New("output would overwrite input")
+	}
+	book, err := read(src)
+	if err != nil {
+		return err
+	}
+	if book.Title() == "" {
+		name := filepath.Base(src)
+		book.SetTitle(name[:len(name)-len(format)])
+	}
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+	return writeFile(dest, func(w io.Writer) error {
+		return writers[to](book, w, filepath.Base(dest))
+	})
+}
+
+func runConvert(args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("convert", "dirs|globs|files...", stderr)
+	to := fs.String("to", "fb2", "output format: fb2, fb2.zip, txt, html, md or epub")
+	out := fs.String("o", "", "output directory, next to input by default")
+	workers := fs.Int("j", runtime.NumCPU(), "number of parallel conversions")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := needFiles(fs); err != nil {
+		return err
+	}
+	if _, ok := writers[*to]; !ok {
+		return fmt.Errorf("unknown output format %q", *to)
+	}
+	if *workers < 1 {
+		*workers = 1
+	}
+	jobs, errs := convertInputs(fs.Args(), *out, *to)
+	for _, err := range errs {
+		fmt.Fprintln(stderr, err)
+	}
+
+	queue := make(chan convertJob)
+	results := make(chan convertResult)
+	var wg sync.WaitGroup
+	for i := 0; i < *workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range queue {
+				err := job.err
+				if err == nil {
+					err = convertFile(job.path, job.dest, *to)
+				}
+				results <- convertResult{job: job, dest: job.dest, err: err}
+			}
+		}()
+	}
+	go func() {
+		for _, job := range jobs {
+			queue <- job
+		}
+		close(queue)
+		wg.Wait()
+		close(results)
+	}()
+
+	failed := []convertResult{}
+	for r := range results {
+		if r.err != nil {
+			fmt.Fprintf(stderr, "%s: %v\n", r.job.path, r.err)
+			failed = append(failed, r)
+			continue
+		}
+		fmt.Fprintf(stdout, "%s: converted to %s\n", r.job.path, r.dest)
+	}
+
+	fmt.Fprintf(stdout, "converted %d of %d files, %d failed\n", len(jobs)-len(failed), len(jobs), len(failed))
+	if len(failed) != 0 {
+		sort.Slice(failed, func(i, j int) bool { return failed[i].job.path < failed[j].job.path })
+		fmt.Fprintln(stdout, "failed:")
+		for _, r := range failed {
+			fmt.Fprintf(stdout, "  %s: %v\n", r.job.path, r.err)
+		}
+	}
+	if len(failed) != 0 || len(errs) != 0 {
+		return errFailed
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	fb2 "github.com/karantin2020/go-fb2"
+)
+
+// bookName returns file name without .fb2 and .zip extensions
+func bookName(path string) string {
+	name := filepath.Base(path)
+	for _, ext := range []string{".zip", ".fb2"} {
+		if strings.EqualFold(filepath.Ext(name), ext) {
+			name = name[:len(name)-len(ext)]
+		}
+	}
+	return name
+}
+
+func runExtractImages(args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("extract-images", "files...", stderr)
+	dir := fs.String("d", ".", "output directory, books get subdirectories if several are given")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := needFiles(fs); err != nil {
+		return err
+	}
+	failed := false
+	for _, path := range fs.Args() {
+		out := *dir
+		if fs.NArg() > 1 {
+			out = filepath.Join(out, bookName(path))
+		}
+		n, err := extractImages(path, out)
+		if err != nil {
+			fmt.Fprintf(stderr, "%s: %v\n", path, err)
+			failed = true
+			continue
+		}
+		fmt.Fprintf(stdout, "%s: %d images saved to %s\n", path, n, out)
+	}
+	if failed {
+		return errFailed
+	}
+	return nil
+}
+
+// extractImages saves book binaries to dir and returns number of saved files
+func extractImages(path, dir string) (int, error) {
+	book, err := fb2.OpenFB2(path)
+	if err != nil {
+		return 0, err
+	}
+	paths, err := book.ExtractBinaries(dir)
+	return len(paths), err
+}
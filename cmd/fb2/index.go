@@ -0,0 +1,60 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	fb2 "github.com/karantin2020/go-fb2"
+)
+
+func runIndex(args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("index", "dir", stderr)
+	out := fs.String("o", "", "output INPX file, <dir>.inpx by default")
+	name := fs.String("name", "", "collection description, directory name by default")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := needFiles(fs); err != nil {
+		return err
+	}
+	if fs.NArg() > 1 {
+		return errors.New("index needs single library directory")
+	}
+	root := fs.Arg(0)
+	if st, err := os.Stat(root); err != nil {
+		return err
+	} else if !st.IsDir() {
+		return fmt.Errorf("%s is not a directory", root)
+	}
+	x, errs := fb2.IndexLibrary(root)
+	for _, err := range errs {
+		fmt.Fprintln(stderr, err)
+	}
+	if *name != "" {
+		x.Collection = *name
+	}
+	dest := *out
+	if dest == "" {
+		dest = filepath.Clean(root) + ".inpx"
+	}
+	f, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	if err := x.Write(f); err != nil {
+		f.Close()
+		os.Remove(dest)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "indexed %d books to %s, %d failed\n", len(x.Records), dest, len(errs))
+	if len(errs) != 0 {
+		return errFailed
+	}
+	return nil
+}
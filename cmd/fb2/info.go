@@ -0,0 +1,174 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	fb2 "github.com/karantin2020/go-fb2"
+	etree "github.com/rupor-github/fb2converter/etree"
+)
+
+func runInfo(args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("info", "files...", stderr)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := needFiles(fs); err != nil {
+		return err
+	}
+	failed := false
+	for i, path := range fs.Args() {
+		book, err := fb2.OpenFB2(path)
+		if err != nil {
+			fmt.Fprintf(stderr, "%s: %v\n", path, err)
+			failed = true
+			continue
+		}
+		if fs.NArg() > 1 {
+			if i > 0 {
+				fmt.Fprintln(stdout)
+			}
+			fmt.Fprintf(stdout, "== %s\n", path)
+		}
+		printInfo(stdout, book)
+	}
+	if failed {
+		return errFailed
+	}
+	return nil
+}
+
+func formatAuthors(authors []fb2.AuthorType) string {
+	names := make([]string, 0, len(authors))
+	for i := range authors {
+		names = append(names, authors[i].Format(fb2.AuthorFormatNickname))
+	}
+	return strings.Join(names, ", ")
+}
+
+func formatSequences(seqs []fb2.SequenceType) string {
+	res := make([]string, 0, len(seqs))
+	for i := range seqs {
+		res = append(res, seqs[i].String())
+	}
+	return strings.Join(res, ", ")
+}
+
+// printField prints non-empty metadata field
+func printField(w io.Writer, name, value string) {
+	if value != "" {
+		fmt.Fprintf(w, "%-12s %s\n", name+":", value)
+	}
+}
+
+func printInfo(w io.Writer, book fb2.FB2) {
+	printField(w, "Title", book.Title())
+	printField(w, "Authors", formatAuthors(book.Authors(fb2.RoleAuthor)))
+	printField(w, "Translators", formatAuthors(book.Authors(fb2.RoleTranslator)))
+	printField(w, "Genres", strings.Join(book.Genre(), ", "))
+	printField(w, "Lang", book.Lang())
+	printField(w, "Sequence", formatSequences(book.Sequence()))
+	pi := book.PublishInfo()
+	printField(w, "Publisher", pi.Publisher)
+	printField(w, "Year", pi.Year)
+	printField(w, "ISBN", book.ISBN())
+	printField(w, "Id", book.Identifier())
+	printField(w, "Version", book.Version())
+	if ann := book.Annotation(); ann != "" {
+		fmt.Fprintln(w, "Annotation:")
+		for _, line := range strings.Split(ann, "\n") {
+			if line != "" {
+				fmt.Fprintf(w, "  %s\n", line)
+			}
+		}
+	}
+	fmt.Fprintln(w, "Sections:")
+	printSections(w, book.Body(), 1)
+	printImages(w, book.Data())
+}
+
+// elementText returns text of e and its descendants
+func elementText(e *etree.Element) string {
+	var sb strings.Builder
+	for _, t := range e.Child {
+		switch c := t.(type) {
+		case *etree.CharData:
+			sb.WriteString(c.Data)
+		case *etree.Element:
+			sb.WriteString(elementText(c))
+			sb.WriteString(c.Tail())
+		}
+	}
+	return sb.String()
+}
+
+// sectionTitle returns section title paragraphs joined in single line
+func sectionTitle(section *etree.Element) string {
+	title := section.SelectElement("title")
+	if title == nil {
+		return ""
+	}
+	lines := []string{}
+	for _, p := range title.SelectElements("p") {
+		if s := strings.Join(strings.Fields(elementText(p)), " "); s != "" {
+			lines = append(lines, s)
+		}
+	}
+	return strings.Join(lines, ". ")
+}
+
+func printSections(w io.Writer, e *etree.Element, depth int) {
+	for _, s := range e.SelectElements("section") {
+		title := sectionTitle(s)
+		if title == "" {
+			title = "(untitled)"
+		}
+		if id := s.SelectAttrValue("id", ""); id != "" {
+			title += " #" + id
+		}
+		fmt.Fprintf(w, "%s%s (%d paragraphs)\n", strings.Repeat("  ", depth), title, len(s.SelectElements("p")))
+		printSections(w, s, depth+1)
+	}
+}
+
+func printImages(w io.Writer, data *fb2.FictionBookScheme) {
+	type stat struct{ count, size int }
+	stats := map[string]*stat{}
+	total := stat{}
+	invalid := 0
+	for _, b := range data.Binary {
+		raw, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(b.Text), ""))
+		if err != nil {
+			invalid++
+		}
+		s := stats[b.ContentType]
+		if s == nil {
+			s = &stat{}
+			stats[b.ContentType] = s
+		}
+		s.count++
+		s.size += len(raw)
+		total.count++
+		total.size += len(raw)
+	}
+	fmt.Fprintf(w, "Images:      %d, %d bytes\n", total.count, total.size)
+	types := make([]string, 0, len(stats))
+	for t := range stats {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	for _, t := range types {
+		fmt.Fprintf(w, "  %s: %d, %d bytes\n", t, stats[t].count, stats[t].size)
+	}
+	if invalid != 0 {
+		fmt.Fprintf(w, "  invalid: %d\n", invalid)
+	}
+	for _, c := range data.Description.TitleInfo.Coverpage {
+		if c.Image != nil {
+			printField(w, "Cover", strings.TrimPrefix(c.Image.XlinkHref, "#"))
+		}
+	}
+}
@@ -0,0 +1,139 @@
+// Command fb2 inspects and edits FictionBook files.
+//
+// Usage:
+//
+//	fb2 <command> [flags] files...
+//
+// Commands work with .fb2 and zipped .fb2 files, convert also reads
+// and writes plain text, HTML, markdown and EPUB.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// errFailed is returned when command failed for some files.
+// Errors are already reported, so only exit code is set.
+var errFailed = errors.New("failed")
+
+// command is fb2 subcommand
+type command struct {
+	usage string
+	run   func(args []string, stdout, stderr io.Writer) error
+}
+
+var commands = map[string]command{
+	"info":           {"print metadata, section tree and image stats", runInfo},
+	"validate":       {"check books and report problems", runValidate},
+	"set":            {"change title, authors, genres, sequence, lang or cover", runSet},
+	"extract-images": {"save embedded images to directory", runExtractImages},
+	"rezip":          {"pack books into .fb2.zip with best compression", runRezip},
+	"convert":        {"convert txt, html, md, epub and fb2 books between formats", runConvert},
+	"index":          {"write INPX catalogue of library directory", runIndex},
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "usage: fb2 <command> [flags] files...")
+	fmt.Fprintln(w, "commands:")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-15s %s\n", name, commands[name].usage)
+	}
+}
+
+// newFlagSet returns flag set of subcommand printing to stderr
+func newFlagSet(name, args string, stderr io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "usage: fb2 %s [flags] %s\n", name, args)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// run executes command line and returns process exit code
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		usage(stderr)
+		return 2
+	}
+	if args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		usage(stdout)
+		return 0
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "fb2: unknown command %q\n", args[0])
+		usage(stderr)
+		return 2
+	}
+	err := cmd.run(args[1:], stdout, stderr)
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, flag.ErrHelp):
+		return 2
+	case !errors.Is(err, errFailed):
+		fmt.Fprintf(stderr, "fb2 %s: %v\n", args[0], err)
+	}
+	return 1
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// parseFlags parses subcommand flags. Flag errors are already reported
+// by flag set, so usage error is returned.
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		return flag.ErrHelp
+	}
+	return nil
+}
+
+// needFiles returns usage error if no files are given
+func needFiles(fs *flag.FlagSet) error {
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+	return nil
+}
+
+// writeFile writes dest with write through temporary file renamed over
+// dest, so existing dest is kept if writing fails
+func writeFile(dest string, write func(w io.Writer) error) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(dest), "."+filepath.Base(dest)+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := write(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	mode := os.FileMode(0644)
+	if st, err := os.Stat(dest); err == nil {
+		mode = st.Mode().Perm()
+	}
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), dest)
+}
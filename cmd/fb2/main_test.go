@@ -0,0 +1,264 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	fb2 "github.com/karantin2020/go-fb2"
+)
+
+// testBook writes small valid book to dir and returns its path
+func testBook(t *testing.T, dir string) string {
+	t.Helper()
+	book := fb2.NewFB2("Test Book")
+	book.SetLang("en")
+	book.AddAuthor(fb2.RoleAuthor, fb2.AuthorType{FirstName: "John", LastName: "Doe"})
+	book.AddAuthor(fb2.RoleDocumentAuthor, fb2.AuthorType{Nickname: "editor"})
+	if err := book.SetGenre([]string{"sf"}); err != nil {
+		t.Fatal(err)
+	}
+	book.AddSectionBlocks("Chapter 1", fb2.P(fb2.Text("Text")))
+	if err := book.SetCover("../../testdata/avatar.jpeg"); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "book.fb2")
+	if err := book.WriteToFile(path); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func runTest(t *testing.T, args ...string) (int, string) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	code := run(args, &stdout, &stderr)
+	return code, stdout.String() + stderr.String()
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	path := testBook(t, dir)
+	zipped := filepath.Join(dir, "book.fb2.zip")
+	tests := []struct {
+		name string
+		args []string
+		code int
+		want []string
+	}{
+		{"no command", nil, 2, []string{"usage: fb2"}},
+		{"unknown command", []string{"foo"}, 2, []string{`unknown command "foo"`}},
+		{"no files", []string{"info"}, 2, []string{"usage: fb2 info"}},
+		{"info", []string{"info", path}, 0, []string{
+			"Title:       Test Book", "Authors:     John Doe", "Genres:      sf",
+			"  Chapter 1 (1 paragraphs)", "image/jpeg: 1, 39170 bytes", "Cover:       cover.jpeg",
+		}},
+		{"validate", []string{"validate", path}, 0, []string{path + ": ok"}},
+		{"rezip", []string{"rezip", path}, 0, []string{"packed to " + zipped}},
+		{"set", []string{"set", "-title", "New", "-author", "Doe, Jane", "-author", "Bob Roe",
+			"-genre", "sf, prose_classic", "-sequence", "Series", "-number", "3", "-lang", "ru", zipped}, 0,
+			[]string{zipped + ": updated"}},
+		{"info zipped", []string{"info", zipped}, 0, []string{
+			"Title:       New", "Authors:     Jane Doe, Bob Roe", "Genres:      sf, prose_classic",
+			"Lang:        ru", "Sequence:    Series: 3",
+		}},
+		{"set cover", []string{"set", "-cover", "../../testdata/AirPlane_400x600.jpg", zipped}, 0, nil},
+		{"set invalid genre", []string{"set", "-genre", "golang", zipped}, 1, []string{"unknown fb2 genre"}},
+		{"validate missing", []string{"validate", filepath.Join(dir, "missing.fb2")}, 1, []string{"no such file"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, out := runTest(t, tt.args...)
+			if code != tt.code {
+				t.Errorf("run() = %d, want %d:\n%s", code, tt.code, out)
+			}
+			for _, s := range tt.want {
+				if !strings.Contains(out, s) {
+					t.Errorf("run() output doesn't contain %q:\n%s", s, out)
+				}
+			}
+		})
+	}
+
+	book, err := fb2.OpenFB2(zipped)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cp := book.Data().Description.TitleInfo.Coverpage; len(cp) != 1 || len(book.Data().Binary) != 1 {
+		t.Errorf("set -cover left %d covers and %d binaries, want 1 and 1", len(cp), len(book.Data().Binary))
+	}
+}
+
+func TestSetNumber(t *testing.T) {
+	dir := t.TempDir()
+	book := fb2.NewFB2("Test Book")
+	series := fb2.NewSequence("Series", 1)
+	series.Sequence = []fb2.SequenceType{fb2.NewSequence("Subseries", 2)}
+	book.AddSequence(series)
+	book.AddSequence(fb2.NewSequence("Other", 7))
+	book.SetAnnotation("About the book.")
+	path := filepath.Join(dir, "book.fb2")
+	if err := book.WriteToFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if code, s := runTest(t, "set", "-number", "5", path); code != 0 {
+		t.Fatalf("run() = %d:\n%s", code, s)
+	}
+	book, err := fb2.OpenFB2(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	series.Number = "5"
+	want := []fb2.SequenceType{series, fb2.NewSequence("Other", 7)}
+	if got := book.Sequence(); !reflect.DeepEqual(got, want) {
+		t.Errorf("set -number sequences = %+v, want %+v", got, want)
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := bytes.Count(data, []byte("<annotation")); n != 1 || book.Annotation() != "About the book." {
+		t.Errorf("set wrote %d annotations, annotation %q", n, book.Annotation())
+	}
+	// book is replaced through temporary file
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || files[0].Mode().Perm() != 0644 {
+		t.Errorf("set left files %v", files)
+	}
+}
+
+func TestExtractImages(t *testing.T) {
+	dir := t.TempDir()
+	path := testBook(t, dir)
+	out := filepath.Join(dir, "images")
+	if code, s := runTest(t, "extract-images", "-d", out, path); code != 0 {
+		t.Fatalf("run() = %d:\n%s", code, s)
+	}
+	got, err := ioutil.ReadFile(filepath.Join(out, "cover.jpeg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := ioutil.ReadFile("../../testdata/avatar.jpeg")
+	if !bytes.Equal(got, want) {
+		t.Errorf("extracted image differs from source")
+	}
+
+	code, s := runTest(t, "extract-images", "-d", out, filepath.Join(dir, "missing.fb2"))
+	if code != 1 || strings.Contains(s, "images saved") {
+		t.Errorf("run() = %d, failed book reported as saved:\n%s", code, s)
+	}
+}
+
+func TestConvert(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	files := map[string]string{
+		"a.txt":       "Chapter 1\nText.",
+		"sub/b.md":    "# B\n\n## One\n\nText.",
+		"sub/c.html":  "<html><head><title>C</title></head><body><p>Text.</p></body></html>",
+		"bad.epub":    "not a zip",
+		"skip.pdf":    "ignored",
+		"sub/d.fb2":   "",
+		"notes/e.txt": "Text.",
+	}
+	for name, content := range files {
+		path := filepath.Join(src, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	out := filepath.Join(dir, "out")
+	code, s := runTest(t, "convert", "-o", out, "-j", "3", src)
+	if code != 1 {
+		t.Errorf("run() = %d, want 1:\n%s", code, s)
+	}
+	for _, want := range []string{"converted 4 of 5 files, 1 failed", "failed:\n  " + filepath.Join(src, "bad.epub")} {
+		if !strings.Contains(s, want) {
+			t.Errorf("run() output doesn't contain %q:\n%s", want, s)
+		}
+	}
+	for name, title := range map[string]string{"a.fb2": "a", "sub/b.fb2": "B", "sub/c.fb2": "C", "notes/e.fb2": "e"} {
+		book, err := fb2.OpenFB2(filepath.Join(out, filepath.FromSlash(name)))
+		if err != nil {
+			t.Errorf("converted %s: %v", name, err)
+			continue
+		}
+		if book.Title() != title {
+			t.Errorf("converted %s title = %q, want %q", name, book.Title(), title)
+		}
+	}
+
+	code, s = runTest(t, "convert", "-to", "epub", filepath.Join(out, "sub", "*.fb2"))
+	if code != 0 || !strings.Contains(s, "converted 2 of 2 files, 0 failed") {
+		t.Errorf("run() = %d:\n%s", code, s)
+	}
+	if _, err := fb2.OpenEPUB(filepath.Join(out, "sub", "b.epub")); err != nil {
+		t.Errorf("OpenEPUB() error = %v", err)
+	}
+	if code, s := runTest(t, "convert", "-to", "pdf", src); code != 1 || !strings.Contains(s, `unknown output format "pdf"`) {
+		t.Errorf("run() = %d:\n%s", code, s)
+	}
+}
+
+func TestIndex(t *testing.T) {
+	dir := t.TempDir()
+	lib := filepath.Join(dir, "lib")
+	if err := os.MkdirAll(lib, 0755); err != nil {
+		t.Fatal(err)
+	}
+	testBook(t, lib)
+	if err := ioutil.WriteFile(filepath.Join(lib, "bad.fb2"), []byte("<FictionBook>"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	code, s := runTest(t, "index", "-name", "My library", lib)
+	if code != 1 || !strings.Contains(s, "indexed 1 books to "+lib+".inpx, 1 failed") {
+		t.Errorf("run() = %d:\n%s", code, s)
+	}
+	x, err := fb2.OpenInpx(lib + ".inpx")
+	if err != nil {
+		t.Fatalf("OpenInpx() error = %v", err)
+	}
+	if x.Collection != "My library" || len(x.Records) != 1 || x.Records[0].Title != "Test Book" || x.Records[0].File != "book" {
+		t.Errorf("OpenInpx() = %+v", x)
+	}
+	if code, s := runTest(t, "index", filepath.Join(lib, "book.fb2")); code != 1 || !strings.Contains(s, "is not a directory") {
+		t.Errorf("run() = %d:\n%s", code, s)
+	}
+}
+
+func TestConvertDuplicates(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{"book.txt": "Text.", "book.md": "# Book", "other.txt": "Text."}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	out := filepath.Join(dir, "out")
+	code, s := runTest(t, "convert", "-o", out, dir, filepath.Join(dir, "other.txt"))
+	if code != 1 {
+		t.Errorf("run() = %d, want 1:\n%s", code, s)
+	}
+	dest := filepath.Join(out, "book.fb2")
+	for _, want := range []string{"converted 1 of 3 files, 2 failed", "output " + dest + " is written by several inputs"} {
+		if !strings.Contains(s, want) {
+			t.Errorf("run() output doesn't contain %q:\n%s", want, s)
+		}
+	}
+	if _, err := os.Stat(dest); !os.IsNotExist(err) {
+		t.Errorf("conflicting output %s is written", dest)
+	}
+	if _, err := fb2.OpenFB2(filepath.Join(out, "other.fb2")); err != nil {
+		t.Errorf("converted other.txt: %v", err)
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	fb2 "github.com/karantin2020/go-fb2"
+)
+
+func runRezip(args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("rezip", "files...", stderr)
+	out := fs.String("o", "", "output file, <name>.fb2.zip next to input by default")
+	remove := fs.Bool("rm", false, "remove unzipped input after packing")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := needFiles(fs); err != nil {
+		return err
+	}
+	if *out != "" && fs.NArg() > 1 {
+		return errors.New("-o needs single input file")
+	}
+	failed := false
+	for _, path := range fs.Args() {
+		dest := *out
+		if dest == "" {
+			dest = filepath.Join(filepath.Dir(path), bookName(path)+".fb2.zip")
+		}
+		if err := rezip(path, dest); err != nil {
+			fmt.Fprintf(stderr, "%s: %v\n", path, err)
+			failed = true
+			continue
+		}
+		if *remove && filepath.Clean(path) != filepath.Clean(dest) {
+			if err := os.Remove(path); err != nil {
+				fmt.Fprintf(stderr, "%s: %v\n", path, err)
+				failed = true
+			}
+		}
+		fmt.Fprintf(stdout, "%s: packed to %s\n", path, dest)
+	}
+	if failed {
+		return errFailed
+	}
+	return nil
+}
+
+// rezip packs book from src into dest. Book content is copied as is,
+// so it works for books this package can't parse.
+func rezip(src, dest string) error {
+	r, err := fb2.OpenFB2Stream(src)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	// dest may be src, so archive is written to temporary file first
+	return writeFile(dest, func(w io.Writer) error {
+		return fb2.ZipFB2(w, bookName(dest)+".fb2", r)
+	})
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	fb2 "github.com/karantin2020/go-fb2"
+)
+
+// stringList is repeatable string flag
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ", ")
+}
+
+func (l *stringList) Set(s string) error {
+	*l = append(*l, s)
+	return nil
+}
+
+// bookEdit are changes of set command
+type bookEdit struct {
+	title    *string
+	authors  []string
+	genres   *string
+	sequence *string
+	number   *int64
+	lang     *string
+	cover    *string
+	// set are names of flags given in command line
+	set map[string]bool
+}
+
+func runSet(args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("set", "files...", stderr)
+	var authors stringList
+	edit := bookEdit{
+		title:    fs.String("title", "", "book title"),
+		genres:   fs.String("genre", "", "comma separated genre codes"),
+		sequence: fs.String("sequence", "", "sequence name, empty removes sequences"),
+		number:   fs.Int64("number", 0, "number in sequence"),
+		lang:     fs.String("lang", "", "book language"),
+		cover:    fs.String("cover", "", "cover image file or URL"),
+		set:      map[string]bool{},
+	}
+	fs.Var(&authors, "author", "author name, repeat for several authors")
+	out := fs.String("o", "", "output file, input is overwritten by default")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := needFiles(fs); err != nil {
+		return err
+	}
+	fs.Visit(func(f *flag.Flag) {
+		edit.set[f.Name] = true
+	})
+	edit.authors = authors
+	if *out != "" && fs.NArg() > 1 {
+		return errors.New("-o needs single input file")
+	}
+	failed := false
+	for _, path := range fs.Args() {
+		dest := path
+		if *out != "" {
+			dest = *out
+		}
+		if err := edit.apply(path, dest); err != nil {
+			fmt.Fprintf(stderr, "%s: %v\n", path, err)
+			failed = true
+			continue
+		}
+		fmt.Fprintf(stdout, "%s: updated\n", dest)
+	}
+	if failed {
+		return errFailed
+	}
+	return nil
+}
+
+// apply edits book from src and writes it to dest
+func (e *bookEdit) apply(src, dest string) error {
+	book, err := fb2.OpenFB2(src)
+	if err != nil {
+		return err
+	}
+	if e.set["title"] {
+		book.SetTitle(*e.title)
+	}
+	if e.set["author"] {
+		for _, a := range book.Authors(fb2.RoleAuthor) {
+			book.RemoveAuthor(fb2.RoleAuthor, a)
+		}
+		for _, name := range e.authors {
+			book.AddAuthor(fb2.RoleAuthor, fb2.ParseAuthor(name, fb2.NameOrderAuto))
+		}
+	}
+	if e.set["genre"] {
+		genres := []string{}
+		for _, g := range strings.Split(*e.genres, ",") {
+			if g = strings.TrimSpace(g); g != "" {
+				genres = append(genres, g)
+			}
+		}
+		if err := book.SetGenre(genres); err != nil {
+			return err
+		}
+	}
+	switch {
+	case e.set["sequence"] && *e.sequence == "":
+		for _, s := range book.Sequence() {
+			book.RemoveSequence(s.Name)
+		}
+	case e.set["sequence"]:
+		book.SetSequence(*e.sequence, *e.number)
+	case e.set["number"]:
+		seqs := book.Sequence()
+		if len(seqs) == 0 {
+			return errors.New("-number needs book sequence")
+		}
+		// only number of the first sequence changes, other and
+		// nested sequences are kept
+		book.SetSequenceNumber(seqs[0].Name, *e.number)
+	}
+	if e.set["lang"] {
+		book.SetLang(*e.lang)
+	}
+	if e.set["cover"] {
+		removeCover(book)
+		if err := book.SetCover(*e.cover); err != nil {
+			return err
+		}
+	}
+	// dest is usually src, so it's replaced only after book is written
+	return writeFile(dest, func(w io.Writer) error {
+		if strings.EqualFold(filepath.Ext(dest), ".zip") {
+			return book.WriteZip(w, bookName(dest)+".fb2")
+		}
+		s, err := book.WriteToString()
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(w, s)
+		return err
+	})
+}
+
+// removeCover removes coverpage and cover binaries not used in book text
+func removeCover(book fb2.FB2) {
+	data := book.Data()
+	covers := map[string]bool{}
+	for _, c := range data.Description.TitleInfo.Coverpage {
+		if c.Image != nil {
+			covers[strings.TrimPrefix(c.Image.XlinkHref, "#")] = true
+		}
+	}
+	data.Description.TitleInfo.Coverpage = nil
+	for _, img := range book.Body().FindElements("//image") {
+		delete(covers, strings.TrimPrefix(img.SelectAttrValue("href", ""), "#"))
+	}
+	binaries := data.Binary[:0]
+	for _, b := range data.Binary {
+		if !covers[b.Id] {
+			binaries = append(binaries, b)
+		}
+	}
+	data.Binary = binaries
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	fb2 "github.com/karantin2020/go-fb2"
+)
+
+func runValidate(args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("validate", "files...", stderr)
+	quiet := fs.Bool("q", false, "report invalid books only")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := needFiles(fs); err != nil {
+		return err
+	}
+	failed := false
+	for _, path := range fs.Args() {
+		book, err := fb2.OpenFB2(path)
+		if err != nil {
+			fmt.Fprintf(stdout, "%s: %v\n", path, err)
+			failed = true
+			continue
+		}
+		errs := book.Validate()
+		if len(errs) == 0 {
+			if !*quiet {
+				fmt.Fprintf(stdout, "%s: ok\n", path)
+			}
+			continue
+		}
+		failed = true
+		for _, err := range errs {
+			fmt.Fprintf(stdout, "%s: %v\n", path, err)
+		}
+	}
+	if failed {
+		return errFailed
+	}
+	return nil
+}
@@ -0,0 +1,235 @@
+package fb2
+
+import (
+	"fmt"
+	"strings"
+
+	etree "github.com/rupor-github/fb2converter/etree"
+)
+
+// Inline is inline content of paragraphs, verses and table cells
+type Inline interface {
+	appendTo(e *etree.Element)
+}
+
+// Block is block content of sections, cites, epigraphs and annotations
+type Block interface {
+	Element() *etree.Element
+}
+
+type inlineText string
+
+func (t inlineText) appendTo(e *etree.Element) {
+	appendText(e, string(t))
+}
+
+type inlineElement struct {
+	tag      string
+	attr     []etree.Attr
+	children []Inline
+}
+
+func (s *inlineElement) appendTo(e *etree.Element) {
+	el := e.CreateElement(s.tag)
+	for _, a := range s.attr {
+		el.CreateAttr(a.Key, a.Value)
+	}
+	appendInline(el, s.children...)
+}
+
+// Text returns plain inline text
+func Text(s string) Inline {
+	return inlineText(s)
+}
+
+// Strong returns strong inline text
+func Strong(c ...Inline) Inline {
+	return &inlineElement{tag: "strong", children: c}
+}
+
+// Emphasis returns emphasized inline text
+func Emphasis(c ...Inline) Inline {
+	return &inlineElement{tag: "emphasis", children: c}
+}
+
+// Strikethrough returns strikethrough inline text
+func Strikethrough(c ...Inline) Inline {
+	return &inlineElement{tag: "strikethrough", children: c}
+}
+
+// Sub returns subscript inline text
+func Sub(c ...Inline) Inline {
+	return &inlineElement{tag: "sub", children: c}
+}
+
+// Sup returns superscript inline text
+func Sup(c ...Inline) Inline {
+	return &inlineElement{tag: "sup", children: c}
+}
+
+// Code returns monospace inline text
+func Code(c ...Inline) Inline {
+	return &inlineElement{tag: "code", children: c}
+}
+
+// NamedStyle returns inline text with style name
+func NamedStyle(name string, c ...Inline) Inline {
+	return &inlineElement{tag: "style", attr: []etree.Attr{{Key: "name", Value: name}}, children: c}
+}
+
+// Link returns link to href
+func Link(href string, c ...Inline) Inline {
+	return &inlineElement{tag: "a", attr: []etree.Attr{{Key: "l:href", Value: href}}, children: c}
+}
+
+// InlineImage returns inline image referencing binary id
+func InlineImage(id string) Inline {
+	return &inlineElement{tag: "image", attr: []etree.Attr{{Key: "l:href", Value: "#" + id}}}
+}
+
+// appendText appends text to e respecting mixed content tails
+func appendText(e *etree.Element, s string) {
+	if n := len(e.Child); n > 0 {
+		switch t := e.Child[n-1].(type) {
+		case *etree.Element:
+			t.SetTail(t.Tail() + s)
+			return
+		case *etree.CharData:
+			t.Data += s
+			return
+		}
+	}
+	e.CreateCharData(s)
+}
+
+func appendInline(e *etree.Element, c ...Inline) {
+	for _, i := range c {
+		i.appendTo(e)
+	}
+}
+
+type blockElement struct {
+	e *etree.Element
+}
+
+// Element returns a copy of block element
+func (b *blockElement) Element() *etree.Element {
+	return b.e.Copy()
+}
+
+func newBlock(tag string, c ...Inline) *blockElement {
+	e := etree.NewElement(tag)
+	appendInline(e, c...)
+	return &blockElement{e: e}
+}
+
+// P returns paragraph block
+func P(c ...Inline) Block {
+	return newBlock("p", c...)
+}
+
+// Subtitle returns subtitle block
+func Subtitle(c ...Inline) Block {
+	return newBlock("subtitle", c...)
+}
+
+// EmptyLine returns empty-line block
+func EmptyLine() Block {
+	return newBlock("empty-line")
+}
+
+// AppendBlocks appends blocks to parent element, e.g. to section
+// found in Body(). Nothing is appended if block tables have
+// invalid cell spans.
+func AppendBlocks(parent *etree.Element, blocks ...Block) error {
+	elems := make([]*etree.Element, 0, len(blocks))
+	for _, b := range blocks {
+		e := b.Element()
+		if err := checkTables(e); err != nil {
+			return fmt.Errorf("append blocks error: %w", err)
+		}
+		elems = append(elems, e)
+	}
+	appendElements(parent, elems...)
+	return nil
+}
+
+// appendElements appends block elements to parent without checks
+func appendElements(parent *etree.Element, elems ...*etree.Element) {
+	if len(parent.Child) == 0 {
+		parent.SetText("\n")
+	}
+	for _, e := range elems {
+		parent.AddChild(e.SetTail("\n"))
+	}
+}
+
+// appendBlocks appends blocks to parent without checks, builders
+// use it and their content is checked on insertion into book
+func appendBlocks(parent *etree.Element, blocks ...Block) {
+	for _, b := range blocks {
+		appendElements(parent, b.Element())
+	}
+}
+
+// newTitle returns title element with paragraph for each line
+func newTitle(lines ...string) *etree.Element {
+	title := etree.NewElement("title")
+	title.SetText("\n")
+	for _, l := range lines {
+		title.CreateElement("p").SetText(l).SetTail("\n")
+	}
+	return title
+}
+
+// AddSectionBlocks adds section built from blocks. Ids of blocks
+// must not be used in book.
+func (d *fb2) AddSectionBlocks(sectionTitle string, blocks ...Block) error {
+	d.Lock()
+	defer d.Unlock()
+	section := etree.NewElement("section")
+	section.SetText("\n")
+	section.AddChild(newTitle(sectionTitle).SetTail("\n"))
+	if err := AppendBlocks(section, blocks...); err != nil {
+		return fmt.Errorf("add section error: %w", err)
+	}
+	if err := d.checkIDs(nil, section); err != nil {
+		return fmt.Errorf("add section error: %w", err)
+	}
+	d.body.AddChild(section.SetTail("\n"))
+	d.assignSectionID(section)
+	return nil
+}
+
+// inlineTags are elements containing inline content only
+var inlineTags = []string{"p", "v", "subtitle", "text-author", "td", "th", "date"}
+
+// innerText returns text content of e including nested elements
+func innerText(e *etree.Element) string {
+	var sb strings.Builder
+	for _, t := range e.Child {
+		switch c := t.(type) {
+		case *etree.CharData:
+			sb.WriteString(c.Data)
+		case *etree.Element:
+			sb.WriteString(innerText(c))
+			sb.WriteString(c.Tail())
+		}
+	}
+	return sb.String()
+}
+
+// plainText returns text of block element with lines
+// separated by newlines
+func plainText(e *etree.Element) string {
+	if hasTag(e, inlineTags...) {
+		return strings.TrimSpace(innerText(e))
+	}
+	lines := []string{}
+	for _, c := range e.ChildElements() {
+		if l := plainText(c); l != "" {
+			lines = append(lines, l)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
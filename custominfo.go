@@ -0,0 +1,61 @@
+package fb2
+
+import "strings"
+
+// CustomInfo returns custom-info value for infoType
+func (d *fb2) CustomInfo(infoType string) (string, bool) {
+	d.Lock()
+	defer d.Unlock()
+	for _, ci := range d.data.Description.CustomInfo {
+		if ci.InfoType == infoType {
+			return strings.TrimSpace(ci.Text), true
+		}
+	}
+	return "", false
+}
+
+// CustomInfos returns all custom-info entries keyed by info-type.
+// For repeated info-type the first value is returned.
+func (d *fb2) CustomInfos() map[string]string {
+	d.Lock()
+	defer d.Unlock()
+	m := make(map[string]string, len(d.data.Description.CustomInfo))
+	for _, ci := range d.data.Description.CustomInfo {
+		if _, ok := m[ci.InfoType]; !ok {
+			m[ci.InfoType] = strings.TrimSpace(ci.Text)
+		}
+	}
+	return m
+}
+
+// SetCustomInfo adds custom-info entry or updates existing one
+func (d *fb2) SetCustomInfo(infoType, value string) {
+	d.Lock()
+	defer d.Unlock()
+	for i := range d.data.Description.CustomInfo {
+		if d.data.Description.CustomInfo[i].InfoType == infoType {
+			d.data.Description.CustomInfo[i].Text = value
+			return
+		}
+	}
+	d.data.Description.CustomInfo = append(d.data.Description.CustomInfo, DescriptionCustomInfo{
+		InfoType: infoType,
+		Text:     value,
+	})
+}
+
+// RemoveCustomInfo removes all custom-info entries with infoType.
+// It reports whether any entry was removed.
+func (d *fb2) RemoveCustomInfo(infoType string) bool {
+	d.Lock()
+	defer d.Unlock()
+	ci := d.data.Description.CustomInfo[:0]
+	for _, c := range d.data.Description.CustomInfo {
+		if c.InfoType != infoType {
+			ci = append(ci, c)
+		}
+	}
+	removed := len(ci) != len(d.data.Description.CustomInfo)
+	d.data.Description.CustomInfo = ci
+	return removed
+}
@@ -0,0 +1,48 @@
+package fb2
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_fb2_CustomInfo(t *testing.T) {
+	d, err := OpenFB2("./testdata/test1.fb2")
+	if err != nil {
+		t.Fatalf("OpenFB2() error = %v", err)
+	}
+	if got, ok := d.CustomInfo("general"); !ok || got != "Custom info text" {
+		t.Errorf("fb2.CustomInfo() = %v, %v", got, ok)
+	}
+	d.SetCustomInfo("source-url", "https://g.ve/test")
+	d.SetCustomInfo("content-hash", "abc")
+	d.SetCustomInfo("content-hash", "def")
+	if !d.RemoveCustomInfo("general") {
+		t.Errorf("fb2.RemoveCustomInfo() must remove existing entry")
+	}
+	if d.RemoveCustomInfo("general") {
+		t.Errorf("fb2.RemoveCustomInfo() must not remove missing entry")
+	}
+	want := map[string]string{
+		"source-url":   "https://g.ve/test",
+		"content-hash": "def",
+	}
+	if got := d.CustomInfos(); !reflect.DeepEqual(got, want) {
+		t.Errorf("fb2.CustomInfos() = %v, want %v", got, want)
+	}
+
+	n := NewFB2("Test1Title")
+	n.SetCustomInfo("scrape-time", "2021-12-01T10:00:00Z")
+	n.SetDescription("Test")
+	out, err := n.WriteToString()
+	if err != nil {
+		t.Fatalf("fb2.WriteToString() error = %v", err)
+	}
+	r, err := ReadFB2(strings.NewReader(out))
+	if err != nil {
+		t.Fatalf("ReadFB2() error = %v", err)
+	}
+	if got, _ := r.CustomInfo("scrape-time"); got != "2021-12-01T10:00:00Z" {
+		t.Errorf("fb2.CustomInfo() = %v", got)
+	}
+}
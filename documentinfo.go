@@ -0,0 +1,146 @@
+package fb2
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	etree "github.com/rupor-github/fb2converter/etree"
+)
+
+// programName is used in program-used and as document author nickname
+const programName = "go-fb2"
+
+// Revision describes a modification of an existing document
+type Revision struct {
+	// Major bumps major version number instead of minor one
+	Major bool
+	// Description is a history line describing the change
+	Description string
+	// Author is added to document authors. Defaults to the go-fb2 tool.
+	Author *AuthorType
+	// Program is added to program-used. Defaults to "go-fb2".
+	Program string
+	// Date of the change. Defaults to current time.
+	Date time.Time
+}
+
+// Version returns document version
+func (d *fb2) Version() string {
+	d.Lock()
+	defer d.Unlock()
+	return d.data.Description.DocumentInfo.Version
+}
+
+// History returns document history lines
+func (d *fb2) History() []string {
+	d.Lock()
+	defer d.Unlock()
+	h := []string{}
+	if d.history == nil {
+		for _, p := range d.data.Description.DocumentInfo.History.P {
+			h = append(h, strings.TrimSpace(p.Text))
+		}
+		return h
+	}
+	for _, p := range d.history.SelectElements("p") {
+		h = append(h, plainText(p))
+	}
+	return h
+}
+
+// historyElement returns history element, creating it
+// from decoded history if needed
+func (d *fb2) historyElement() *etree.Element {
+	if d.history == nil {
+		d.history = etree.NewElement("history")
+		for _, p := range d.data.Description.DocumentInfo.History.P {
+			appendBlocks(d.history, P(Text(strings.TrimSpace(p.Text))))
+		}
+		d.data.Description.DocumentInfo.History = AnnotationType{}
+	}
+	return d.history
+}
+
+// AddRevision bumps document version, appends dated history line,
+// adds revision author to document authors and updates program-used.
+// It returns new document version.
+func (d *fb2) AddRevision(rev Revision) string {
+	d.Lock()
+	defer d.Unlock()
+	di := &d.data.Description.DocumentInfo
+	di.Version = bumpVersion(di.Version, rev.Major)
+
+	if rev.Date.IsZero() {
+		rev.Date = time.Now()
+	}
+	line := fmt.Sprintf("v%s — %s", di.Version, rev.Date.Format(dateValueFmt))
+	if rev.Description != "" {
+		line += " — " + rev.Description
+	}
+	appendBlocks(d.historyElement(), P(Text(line)))
+
+	author := AuthorType{Nickname: programName}
+	if rev.Author != nil {
+		author = *rev.Author
+	}
+	found := false
+	for i := range di.Author {
+		if sameAuthor(&di.Author[i], &author) {
+			found = true
+			break
+		}
+	}
+	if !found {
+		di.Author = append(di.Author, author)
+	}
+
+	if rev.Program == "" {
+		rev.Program = programName
+	}
+	switch {
+	case strings.TrimSpace(di.ProgramUsed) == "":
+		di.ProgramUsed = rev.Program
+	case !hasProgram(di.ProgramUsed, rev.Program):
+		di.ProgramUsed += ", " + rev.Program
+	}
+	return di.Version
+}
+
+// hasProgram reports whether comma separated program list contains program
+func hasProgram(list, program string) bool {
+	for _, p := range strings.Split(list, ",") {
+		if strings.TrimSpace(p) == strings.TrimSpace(program) {
+			return true
+		}
+	}
+	return false
+}
+
+// bumpVersion increments "major.minor" version. Malformed
+// or empty version is treated as "1.0".
+func bumpVersion(version string, major bool) string {
+	parts := strings.SplitN(strings.TrimSpace(version), ".", 2)
+	maj, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		maj = 1
+	}
+	var min int64
+	if len(parts) == 2 && err == nil {
+		min, err = strconv.ParseInt(parts[1], 10, 64)
+		if err != nil {
+			min = 0
+		}
+	}
+	if major {
+		return fmt.Sprintf("%d.0", maj+1)
+	}
+	return fmt.Sprintf("%d.%d", maj, min+1)
+}
+
+// sameAuthor compares author names
+func sameAuthor(a, b *AuthorType) bool {
+	return a.FirstName == b.FirstName && a.MiddleName == b.MiddleName &&
+		a.LastName == b.LastName && a.Nickname == b.Nickname
+}
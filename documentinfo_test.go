@@ -0,0 +1,83 @@
+package fb2
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_bumpVersion(t *testing.T) {
+	tests := []struct {
+		version string
+		major   bool
+		want    string
+	}{
+		{"1.0", false, "1.1"},
+		{"1.9", false, "1.10"},
+		{"1.4", true, "2.0"},
+		{"", false, "1.1"},
+		{"1637669264873", false, "1637669264873.1"},
+		{"v1", true, "2.0"},
+	}
+	for _, tt := range tests {
+		if got := bumpVersion(tt.version, tt.major); got != tt.want {
+			t.Errorf("bumpVersion(%q, %v) = %v, want %v", tt.version, tt.major, got, tt.want)
+		}
+	}
+}
+
+func Test_fb2_AddRevision(t *testing.T) {
+	d, err := OpenFB2("./testdata/test1.fb2")
+	if err != nil {
+		t.Fatalf("OpenFB2() error = %v", err)
+	}
+	date := time.Date(2021, 12, 1, 0, 0, 0, 0, time.UTC)
+	if got := d.AddRevision(Revision{Description: "fixed typos", Date: date}); got != "1637669264873.1" {
+		t.Errorf("fb2.AddRevision() = %v", got)
+	}
+	if got := d.AddRevision(Revision{Major: true, Date: date}); got != "1637669264874.0" {
+		t.Errorf("fb2.AddRevision() = %v", got)
+	}
+	want := []string{
+		"v1637669264873.1 — 2021-12-01 — fixed typos",
+		"v1637669264874.0 — 2021-12-01",
+	}
+	if got := d.History(); !reflect.DeepEqual(got, want) {
+		t.Errorf("fb2.History() = %v, want %v", got, want)
+	}
+	di := d.Data().Description.DocumentInfo
+	if len(di.Author) != 2 || di.Author[1].Nickname != programName {
+		t.Errorf("fb2.AddRevision() document authors = %+v", di.Author)
+	}
+	if di.ProgramUsed != programName {
+		t.Errorf("fb2.AddRevision() program-used = %v", di.ProgramUsed)
+	}
+
+	n := NewFB2("Test1Title")
+	n.Data().Description.DocumentInfo.ProgramUsed = "FictionBook Editor 2.6"
+	n.AddRevision(Revision{Author: &AuthorType{Nickname: "editor"}, Description: "new cover"})
+	n.SetDescription("Test")
+	out, err := n.WriteToString()
+	if err != nil {
+		t.Fatalf("fb2.WriteToString() error = %v", err)
+	}
+	if !strings.Contains(out, "<program-used>FictionBook Editor 2.6, go-fb2</program-used>") ||
+		!strings.Contains(out, "<nickname>editor</nickname>") ||
+		!strings.Contains(out, "<version>1.1</version>") {
+		t.Errorf("fb2.WriteToString() wrong document-info:\n%s", out)
+	}
+
+	for _, tt := range []struct{ used, want string }{
+		{"go-fb2-tools", "go-fb2-tools, go-fb2"},
+		{"FB Editor, go-fb2 ", "FB Editor, go-fb2 "},
+		{"go-fb2,other", "go-fb2,other"},
+	} {
+		b := NewFB2("Test")
+		b.Data().Description.DocumentInfo.ProgramUsed = tt.used
+		b.AddRevision(Revision{})
+		if got := b.Data().Description.DocumentInfo.ProgramUsed; got != tt.want {
+			t.Errorf("fb2.AddRevision() program-used %q = %q, want %q", tt.used, got, tt.want)
+		}
+	}
+}
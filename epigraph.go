@@ -0,0 +1,135 @@
+package fb2
+
+import (
+	"fmt"
+
+	etree "github.com/rupor-github/fb2converter/etree"
+)
+
+// EpigraphBuilder builds epigraph element
+type EpigraphBuilder struct {
+	id         string
+	blocks     []Block
+	textAuthor [][]Inline
+}
+
+// NewEpigraph returns epigraph builder. Lines are added as paragraphs.
+func NewEpigraph(lines ...string) *EpigraphBuilder {
+	b := &EpigraphBuilder{}
+	for _, l := range lines {
+		b.blocks = append(b.blocks, P(Text(l)))
+	}
+	return b
+}
+
+// SetID sets epigraph id
+func (b *EpigraphBuilder) SetID(id string) *EpigraphBuilder {
+	b.id = id
+	return b
+}
+
+// AddParagraph appends paragraph
+func (b *EpigraphBuilder) AddParagraph(c ...Inline) *EpigraphBuilder {
+	b.blocks = append(b.blocks, P(c...))
+	return b
+}
+
+// AddBlock appends paragraph, poem, cite or empty line
+func (b *EpigraphBuilder) AddBlock(blocks ...Block) *EpigraphBuilder {
+	b.blocks = append(b.blocks, blocks...)
+	return b
+}
+
+// AddTextAuthor appends text-author line
+func (b *EpigraphBuilder) AddTextAuthor(c ...Inline) *EpigraphBuilder {
+	b.textAuthor = append(b.textAuthor, c)
+	return b
+}
+
+// Element returns epigraph element
+func (b *EpigraphBuilder) Element() *etree.Element {
+	e := etree.NewElement("epigraph")
+	if b.id != "" {
+		e.CreateAttr("id", b.id)
+	}
+	appendBlocks(e, b.blocks...)
+	for _, ta := range b.textAuthor {
+		appendBlocks(e, newBlock("text-author", ta...))
+	}
+	return e
+}
+
+// insertAfterHead inserts e into parent after leading child
+// elements with head tags
+func insertAfterHead(parent, e *etree.Element, head ...string) {
+	var before etree.Token
+	for _, c := range parent.ChildElements() {
+		if !hasTag(c, head...) {
+			before = c
+			break
+		}
+	}
+	if len(parent.Child) == 0 {
+		parent.SetText("\n")
+	}
+	parent.InsertChild(before, e.SetTail("\n"))
+}
+
+func hasTag(e *etree.Element, tags ...string) bool {
+	for _, t := range tags {
+		if e.Tag == t {
+			return true
+		}
+	}
+	return false
+}
+
+// AddEpigraph adds epigraph to section after its title and
+// other epigraphs. Section may be found in Body(). Epigraph ids
+// must not be used in section tree.
+func AddEpigraph(section *etree.Element, e *EpigraphBuilder) error {
+	el := e.Element()
+	if err := checkTables(el); err != nil {
+		return fmt.Errorf("add epigraph error: %w", err)
+	}
+	if err := checkTreeIDs(section, nil, el); err != nil {
+		return fmt.Errorf("add epigraph error: %w", err)
+	}
+	insertAfterHead(section, el, "title", "epigraph")
+	return nil
+}
+
+// SetSectionAnnotation replaces section annotation with blocks.
+// Empty blocks remove the annotation.
+func SetSectionAnnotation(section *etree.Element, blocks ...Block) error {
+	a := etree.NewElement("annotation")
+	if err := AppendBlocks(a, blocks...); err != nil {
+		return fmt.Errorf("set section annotation error: %w", err)
+	}
+	old := section.SelectElement("annotation")
+	if err := checkTreeIDs(section, old, a); err != nil {
+		return fmt.Errorf("set section annotation error: %w", err)
+	}
+	if old != nil {
+		section.RemoveChild(old)
+	}
+	if len(blocks) != 0 {
+		insertAfterHead(section, a, "title", "epigraph", "image")
+	}
+	return nil
+}
+
+// AddBodyEpigraph adds epigraph to the main body after body title
+func (d *fb2) AddBodyEpigraph(e *EpigraphBuilder) error {
+	d.Lock()
+	defer d.Unlock()
+	el := e.Element()
+	if err := checkTables(el); err != nil {
+		return fmt.Errorf("add body epigraph error: %w", err)
+	}
+	if err := d.checkIDs(nil, el); err != nil {
+		return fmt.Errorf("add body epigraph error: %w", err)
+	}
+	insertAfterHead(d.body, el, "image", "title", "epigraph")
+	return nil
+}
@@ -0,0 +1,659 @@
+package fb2
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid"
+	etree "github.com/rupor-github/fb2converter/etree"
+	xhtml "golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// ErrInvalidEPUB is returned for EPUB without readable package document
+var ErrInvalidEPUB = errors.New("invalid epub")
+
+// epubMimetype is content of EPUB mimetype file
+const epubMimetype = "application/epub+zip"
+
+// epubContainer is EPUB container file pointing to package document
+const epubContainer = `<?xml version="1.0" encoding="UTF-8"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles>
+    <rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/>
+  </rootfiles>
+</container>
+`
+
+// epubReader reads files of EPUB archive
+type epubReader struct {
+	files map[string]*zip.File
+}
+
+func (e *epubReader) read(name string) ([]byte, error) {
+	f, ok := e.files[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: missing %s", ErrInvalidEPUB, name)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return ioutil.ReadAll(rc)
+}
+
+func (e *epubReader) readXML(name string) (*etree.Document, error) {
+	data, err := e.read(name)
+	if err != nil {
+		return nil, err
+	}
+	doc := etree.NewDocument()
+	if err := doc.ReadFromBytes(data); err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalidEPUB, name, err)
+	}
+	return doc, nil
+}
+
+// epubRef resolves href relative to EPUB file base. It returns
+// archive path and fragment, external links are not resolved.
+func epubRef(base, href string) (string, string, bool) {
+	u, err := url.Parse(href)
+	if err != nil || u.Scheme != "" || u.Host != "" {
+		return "", "", false
+	}
+	if u.Path == "" {
+		return base, u.Fragment, true
+	}
+	return path.Join(path.Dir(base), u.Path), u.Fragment, true
+}
+
+// epubDoc is converted EPUB content document
+type epubDoc struct {
+	c *htmlConverter
+	// first is the first section converted from document
+	first *etree.Element
+}
+
+// setEPUBMeta sets book description from OPF metadata
+func (b *htmlBook) setEPUBMeta(meta *etree.Element) {
+	if meta == nil {
+		return
+	}
+	ti := &b.d.data.Description.TitleInfo
+	for _, m := range meta.ChildElements() {
+		text := strings.TrimSpace(m.Text())
+		switch m.Tag {
+		case "title":
+			if ti.BookTitle == "" {
+				ti.BookTitle = text
+			}
+		case "creator":
+			if text == "" {
+				continue
+			}
+			author := ParseAuthor(text, NameOrderAuto)
+			if m.SelectAttrValue("role", "aut") == "trl" {
+				ti.Translator = append(ti.Translator, author)
+				continue
+			}
+			ti.Author = append(ti.Author, author)
+		case "language":
+			if ti.Lang == "" && text != "und" {
+				ti.Lang = text
+			}
+		case "description":
+			if b.d.annotation == nil {
+				b.d.setAnnotationBlocks(textBlocks(htmlText(text))...)
+			}
+		case "subject":
+			if text == "" {
+				continue
+			}
+			if ti.Keywords != "" {
+				ti.Keywords += ", "
+			}
+			ti.Keywords += text
+		}
+	}
+}
+
+// htmlText returns text of HTML fragment, s without markup is
+// returned as is
+func htmlText(s string) string {
+	if !strings.Contains(s, "<") {
+		return s
+	}
+	nodes, err := xhtml.ParseFragment(strings.NewReader(s), &xhtml.Node{
+		Type:     xhtml.ElementNode,
+		Data:     "body",
+		DataAtom: atom.Body,
+	})
+	if err != nil {
+		return s
+	}
+	parts := []string{}
+	for _, n := range nodes {
+		if t := strings.TrimSpace(nodeText(n)); t != "" {
+			parts = append(parts, t)
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// isEmptyPage reports whether document has no text and no images
+// other than cover, like cover and blank pages
+func isEmptyPage(doc *xhtml.Node, image func(src string) string, cover string) bool {
+	body := findNode(doc, atom.Body)
+	if body == nil {
+		return true
+	}
+	if strings.TrimSpace(nodeText(body)) != "" {
+		return false
+	}
+	empty := true
+	var walk func(n *xhtml.Node)
+	walk = func(n *xhtml.Node) {
+		if n.DataAtom == atom.Img {
+			if id := image(getAttr(n, "src")); id != "" && id != cover {
+				empty = false
+			}
+		}
+		for ch := n.FirstChild; ch != nil; ch = ch.NextSibling {
+			walk(ch)
+		}
+	}
+	walk(body)
+	return empty
+}
+
+// titlePage removes book title from title page section. It reports
+// whether nothing but annotation is left, so section can be dropped.
+func (b *htmlBook) titlePage(section *etree.Element) bool {
+	elems := section.ChildElements()
+	if len(elems) == 0 || elems[0].Tag != "title" {
+		return false
+	}
+	found := false
+	for _, l := range strings.Split(plainText(elems[0]), "\n") {
+		found = found || l == b.d.data.Description.TitleInfo.BookTitle
+	}
+	if !found {
+		return false
+	}
+	section.RemoveChild(elems[0])
+	rest := strings.Join(strings.Fields(plainText(section)), " ")
+	if rest == "" {
+		return true
+	}
+	return b.d.annotation != nil && rest == strings.Join(strings.Fields(plainText(b.d.annotation)), " ")
+}
+
+// ReadEPUB converts EPUB book into FB2. Content documents of spine
+// are converted in reading order, each document starts new section.
+// Links between documents are kept.
+func ReadEPUB(r io.ReaderAt, size int64) (FB2, error) {
+	z, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("read epub error: %w", err)
+	}
+	e := &epubReader{files: map[string]*zip.File{}}
+	for _, f := range z.File {
+		e.files[f.Name] = f
+	}
+	container, err := e.readXML("META-INF/container.xml")
+	if err != nil {
+		return nil, fmt.Errorf("read epub error: %w", err)
+	}
+	rootfile := container.FindElement("//rootfile")
+	if rootfile == nil {
+		return nil, fmt.Errorf("read epub error: %w: no rootfile", ErrInvalidEPUB)
+	}
+	opfPath := rootfile.SelectAttrValue("full-path", "")
+	opf, err := e.readXML(opfPath)
+	if err != nil {
+		return nil, fmt.Errorf("read epub error: %w", err)
+	}
+	pkg := opf.Root()
+	if pkg == nil {
+		return nil, fmt.Errorf("read epub error: %w: empty package", ErrInvalidEPUB)
+	}
+
+	b := newHTMLBook()
+	b.setEPUBMeta(pkg.SelectElement("metadata"))
+	items := map[string]*etree.Element{}
+	if manifest := pkg.SelectElement("manifest"); manifest != nil {
+		for _, item := range manifest.SelectElements("item") {
+			items[item.SelectAttrValue("id", "")] = item
+		}
+	}
+	loadImage := func(name string) string {
+		data, err := e.read(name)
+		if err != nil {
+			return ""
+		}
+		return b.addImage(name, data)
+	}
+
+	cover := ""
+	coverItem := (*etree.Element)(nil)
+	if meta := pkg.SelectElement("metadata"); meta != nil {
+		for _, m := range meta.SelectElements("meta") {
+			if m.SelectAttrValue("name", "") == "cover" {
+				coverItem = items[m.SelectAttrValue("content", "")]
+			}
+		}
+	}
+	for _, item := range items {
+		if coverItem == nil && strings.Contains(item.SelectAttrValue("properties", ""), "cover-image") {
+			coverItem = item
+		}
+	}
+	if coverItem != nil {
+		if name, _, ok := epubRef(opfPath, coverItem.SelectAttrValue("href", "")); ok {
+			cover = loadImage(name)
+		}
+	}
+	if cover != "" {
+		b.d.data.Description.TitleInfo.Coverpage = []Coverpage{{
+			Image: &InlineImageType{XlinkHref: "#" + cover, Alt: "Cover"},
+		}}
+	}
+
+	docs := map[string]*epubDoc{}
+	order := []string{}
+	if spine := pkg.SelectElement("spine"); spine != nil {
+		for _, ref := range spine.SelectElements("itemref") {
+			item, ok := items[ref.SelectAttrValue("idref", "")]
+			if !ok {
+				continue
+			}
+			switch item.SelectAttrValue("media-type", "") {
+			case "application/xhtml+xml", "text/html":
+			default:
+				continue
+			}
+			name, _, ok := epubRef(opfPath, item.SelectAttrValue("href", ""))
+			if !ok || docs[name] != nil {
+				continue
+			}
+			data, err := e.read(name)
+			if err != nil {
+				return nil, fmt.Errorf("read epub error: %w", err)
+			}
+			doc, err := xhtml.Parse(strings.NewReader(decodeText(data)))
+			if err != nil {
+				return nil, fmt.Errorf("read epub error: %s: %w", name, err)
+			}
+			image := func(src string) string {
+				if data, ok := decodeDataURI(src); ok {
+					return b.addImage(src, data)
+				}
+				if p, _, ok := epubRef(name, src); ok {
+					return loadImage(p)
+				}
+				return ""
+			}
+			if isEmptyPage(doc, image, cover) {
+				continue
+			}
+			n := len(b.d.body.ChildElements())
+			ed := &epubDoc{c: b.addDocument(doc, image, false)}
+			if secs := b.d.body.ChildElements(); len(secs) > n {
+				ed.first = secs[n]
+				if len(docs) == 0 && len(secs) == n+1 && b.titlePage(ed.first) {
+					b.d.body.RemoveChild(ed.first)
+					ed.first = nil
+				}
+			}
+			docs[name] = ed
+			order = append(order, name)
+		}
+	}
+
+	for _, name := range order {
+		resolveLinks(docs[name].c.links, func(href string) (string, bool) {
+			p, frag, ok := epubRef(name, href)
+			if !ok {
+				return "", false
+			}
+			target, ok := docs[p]
+			if !ok {
+				return "", false
+			}
+			if frag != "" {
+				id, ok := target.c.anchors[frag]
+				return id, ok
+			}
+			if target.first == nil {
+				return "", false
+			}
+			id := target.first.SelectAttrValue("id", "")
+			if id == "" {
+				id = uniqueID(slugID(strings.TrimSuffix(path.Base(p), path.Ext(p))), b.ids)
+				target.first.CreateAttr("id", id)
+			}
+			return id, true
+		})
+	}
+	return b.d, nil
+}
+
+// OpenEPUB converts EPUB book file into FB2 like ReadEPUB
+func OpenEPUB(srcFilePath string) (FB2, error) {
+	f, err := os.Open(srcFilePath)
+	if err != nil {
+		return nil, fmt.Errorf("open epub error: %w", err)
+	}
+	defer f.Close()
+	st, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("open epub error: %w", err)
+	}
+	return ReadEPUB(f, st.Size())
+}
+
+// epubFile is content document of written EPUB
+type epubFile struct {
+	name  string
+	elems []*etree.Element
+}
+
+// epubNav writes navigation entries of sections with titles.
+// Sections without title are replaced with their subsections.
+func epubNav(sb *strings.Builder, file string, sections []*etree.Element) {
+	for _, s := range sections {
+		sub := s.SelectElements("section")
+		title := s.SelectElement("title")
+		if title == nil {
+			epubNav(sb, file, sub)
+			continue
+		}
+		text := strings.ReplaceAll(plainText(title), "\n", ". ")
+		sb.WriteString(`<li><a href="` + file + "#" + html.EscapeString(s.SelectAttrValue("id", "")) + `">` +
+			html.EscapeString(text) + "</a>")
+		if len(sub) != 0 {
+			sb.WriteString("\n<ol>\n")
+			epubNav(sb, file, sub)
+			sb.WriteString("</ol>\n")
+		}
+		sb.WriteString("</li>\n")
+	}
+}
+
+// xhtmlPage returns XHTML content document with body
+func xhtmlPage(lang, title, body string, nav bool) string {
+	ns := ""
+	if nav {
+		ns = ` xmlns:epub="http://www.idpf.org/2007/ops"`
+	}
+	lang = html.EscapeString(lang)
+	return `<?xml version="1.0" encoding="UTF-8"?>` + "\n<!DOCTYPE html>\n" +
+		`<html xmlns="http://www.w3.org/1999/xhtml"` + ns + ` lang="` + lang + `" xml:lang="` + lang + `">` + "\n" +
+		"<head>\n<meta charset=\"utf-8\"/>\n<title>" + html.EscapeString(title) + "</title>\n</head>\n<body>\n" +
+		body + "</body>\n</html>\n"
+}
+
+// epubFiles splits bodies into content documents. Every top section
+// and every notes body gets own document, sections with titles get
+// ids for navigation.
+func epubFiles(body *etree.Element, bodies []*etree.Element, ids map[string]bool) []*epubFile {
+	files := []*epubFile{}
+	var front *epubFile
+	for _, e := range body.ChildElements() {
+		if e.Tag == "section" {
+			files = append(files, &epubFile{name: fmt.Sprintf("text%d.xhtml", len(files)+1), elems: []*etree.Element{e}})
+			front = nil
+			continue
+		}
+		if front == nil {
+			front = &epubFile{name: fmt.Sprintf("text%d.xhtml", len(files)+1)}
+			files = append(files, front)
+		}
+		front.elems = append(front.elems, e)
+	}
+	for i, b := range bodies {
+		files = append(files, &epubFile{name: fmt.Sprintf("notes%d.xhtml", i+1), elems: []*etree.Element{b}})
+	}
+	for _, f := range files {
+		for _, e := range f.elems {
+			for _, s := range append([]*etree.Element{e}, e.FindElements(".//section")...) {
+				if s.Tag == "section" && s.SelectElement("title") != nil && s.SelectAttrValue("id", "") == "" {
+					s.CreateAttr("id", uniqueID("section", ids))
+				}
+			}
+		}
+	}
+	return files
+}
+
+// epubImage is image file of written EPUB
+type epubImage struct {
+	id          string
+	name        string
+	contentType string
+	data        []byte
+}
+
+// epubImages returns JPEG and PNG images of binaries with unique
+// file names. Binaries of other types are skipped.
+func epubImages(binaries []FictionBookBinary) []*epubImage {
+	images := []*epubImage{}
+	used := map[string]bool{}
+	for _, b := range binaries {
+		data, err := decodeBinary(b)
+		if err != nil {
+			continue
+		}
+		contentType := http.DetectContentType(data)
+		ext, ok := imageExts[contentType]
+		if !ok {
+			continue
+		}
+		name := slugID(strings.TrimSuffix(b.Id, path.Ext(b.Id)))
+		if name == "" {
+			name = "image"
+		}
+		images = append(images, &epubImage{
+			id:          b.Id,
+			name:        "images/" + uniqueID(name, used) + ext,
+			contentType: contentType,
+			data:        data,
+		})
+	}
+	return images
+}
+
+// WriteEPUB writes book as EPUB 3. Each top level section is written
+// into own content document, internal links and images are kept.
+func (d *fb2) WriteEPUB(w io.Writer) error {
+	d.Lock()
+	defer d.Unlock()
+	if err := d.writeEPUB(w); err != nil {
+		return fmt.Errorf("write epub error: %w", err)
+	}
+	return nil
+}
+
+func (d *fb2) writeEPUB(w io.Writer) error {
+	ti := &d.data.Description.TitleInfo
+	lang := ti.Lang
+	if lang == "" {
+		lang = "und"
+	}
+	body, bodies := d.exportBodies()
+	ids := d.ids()
+	files := epubFiles(body, bodies, ids)
+	// targets maps element ids to content documents
+	targets := map[string]string{}
+	for _, f := range files {
+		for _, e := range f.elems {
+			fileIDs := map[string]bool{}
+			collectIDs(e, fileIDs)
+			for id := range fileIDs {
+				targets[id] = f.name
+			}
+		}
+	}
+	images := epubImages(d.data.Binary)
+	byID := map[string]*epubImage{}
+	for _, img := range images {
+		byID[img.id] = img
+	}
+
+	zw := zip.NewWriter(w)
+	mw, err := zw.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(mw, epubMimetype); err != nil {
+		return err
+	}
+	write := func(name, content string) error {
+		fw, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: time.Now()})
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(fw, content)
+		return err
+	}
+	if err := write("META-INF/container.xml", epubContainer); err != nil {
+		return err
+	}
+
+	h := &htmlWriter{
+		image: func(href string) string {
+			if img, ok := byID[strings.TrimPrefix(href, "#")]; ok {
+				return img.name
+			}
+			return ""
+		},
+		link: func(href string) string {
+			if f, ok := targets[href[1:]]; ok {
+				return f + href
+			}
+			return href
+		},
+	}
+	for _, f := range files {
+		h.sb.Reset()
+		for _, e := range f.elems {
+			h.block(e, 1)
+		}
+		if err := write("OEBPS/"+f.name, xhtmlPage(lang, ti.BookTitle, h.sb.String(), false)); err != nil {
+			return err
+		}
+	}
+
+	var nav strings.Builder
+	nav.WriteString(`<nav epub:type="toc" id="toc">` + "\n<h1>" + html.EscapeString(ti.BookTitle) + "</h1>\n<ol>\n")
+	for _, f := range files {
+		for _, e := range f.elems {
+			if e.Tag == "section" {
+				epubNav(&nav, f.name, []*etree.Element{e})
+			}
+		}
+	}
+	nav.WriteString("</ol>\n</nav>\n")
+	if err := write("OEBPS/nav.xhtml", xhtmlPage(lang, ti.BookTitle, nav.String(), true)); err != nil {
+		return err
+	}
+
+	for _, img := range images {
+		if err := write("OEBPS/"+img.name, string(img.data)); err != nil {
+			return err
+		}
+	}
+
+	opf, err := d.epubPackage(lang, files, images)
+	if err != nil {
+		return err
+	}
+	if err := write("OEBPS/content.opf", opf); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// epubPackage returns OPF package document of written EPUB
+func (d *fb2) epubPackage(lang string, files []*epubFile, images []*epubImage) (string, error) {
+	ti := &d.data.Description.TitleInfo
+	doc := etree.NewDocument()
+	doc.CreateProcInst("xml", `version="1.0" encoding="UTF-8"`)
+	pkg := doc.CreateElement("package")
+	pkg.CreateAttr("xmlns", "http://www.idpf.org/2007/opf")
+	pkg.CreateAttr("version", "3.0")
+	pkg.CreateAttr("unique-identifier", "book-id")
+
+	meta := pkg.CreateElement("metadata")
+	meta.CreateAttr("xmlns:dc", "http://purl.org/dc/elements/1.1/")
+	id := d.data.Description.DocumentInfo.Id
+	if id == "" {
+		id = uuid.Must(uuid.NewV4()).String()
+	}
+	ident := meta.CreateElement("dc:identifier")
+	ident.CreateAttr("id", "book-id")
+	ident.SetText(id)
+	meta.CreateElement("dc:title").SetText(ti.BookTitle)
+	meta.CreateElement("dc:language").SetText(lang)
+	for i := range ti.Author {
+		if name := ti.Author[i].Format(AuthorFormatNickname); name != "" {
+			meta.CreateElement("dc:creator").SetText(name)
+		}
+	}
+	if d.annotation != nil {
+		meta.CreateElement("dc:description").SetText(plainText(d.annotation))
+	}
+	for _, g := range ti.Genre {
+		meta.CreateElement("dc:subject").SetText(g.Text)
+	}
+	modified := meta.CreateElement("meta")
+	modified.CreateAttr("property", "dcterms:modified")
+	modified.SetText(time.Now().UTC().Format("2006-01-02T15:04:05Z"))
+	cover := ""
+	if len(ti.Coverpage) != 0 && ti.Coverpage[0].Image != nil {
+		cover = strings.TrimPrefix(ti.Coverpage[0].Image.XlinkHref, "#")
+	}
+
+	manifest := pkg.CreateElement("manifest")
+	item := func(id, href, mediaType, props string) {
+		it := manifest.CreateElement("item")
+		it.CreateAttr("id", id)
+		it.CreateAttr("href", href)
+		it.CreateAttr("media-type", mediaType)
+		if props != "" {
+			it.CreateAttr("properties", props)
+		}
+	}
+	item("nav", "nav.xhtml", "application/xhtml+xml", "nav")
+	spine := etree.NewElement("spine")
+	for i, f := range files {
+		fid := fmt.Sprintf("doc%d", i+1)
+		item(fid, f.name, "application/xhtml+xml", "")
+		spine.CreateElement("itemref").CreateAttr("idref", fid)
+	}
+	for i, img := range images {
+		iid := fmt.Sprintf("img%d", i+1)
+		props := ""
+		if img.id == cover {
+			props = "cover-image"
+			m := meta.CreateElement("meta")
+			m.CreateAttr("name", "cover")
+			m.CreateAttr("content", iid)
+		}
+		item(iid, img.name, img.contentType, props)
+	}
+	pkg.AddChild(spine)
+	doc.Indent(2)
+	return doc.WriteToString()
+}
@@ -0,0 +1,88 @@
+package fb2
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"image"
+	"image/png"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEPUB(t *testing.T) {
+	var img bytes.Buffer
+	if err := png.Encode(&img, image.NewGray(image.Rect(0, 0, 1, 1))); err != nil {
+		t.Fatal(err)
+	}
+	src := strings.ReplaceAll(splitSrc, "BBBB", base64.StdEncoding.EncodeToString(img.Bytes()))
+	book, err := ReadFB2(strings.NewReader(src))
+	if err != nil {
+		t.Fatalf("ReadFB2() error = %v", err)
+	}
+	var buf bytes.Buffer
+	if err := book.WriteEPUB(&buf); err != nil {
+		t.Fatalf("WriteEPUB() error = %v", err)
+	}
+	z, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("zip.NewReader() error = %v", err)
+	}
+	names := []string{}
+	for _, f := range z.File {
+		names = append(names, f.Name)
+	}
+	wantNames := []string{
+		"mimetype", "META-INF/container.xml",
+		"OEBPS/text1.xhtml", "OEBPS/text2.xhtml", "OEBPS/text3.xhtml", "OEBPS/text4.xhtml",
+		"OEBPS/notes1.xhtml", "OEBPS/nav.xhtml", "OEBPS/images/map.png", "OEBPS/content.opf",
+	}
+	if !reflect.DeepEqual(names, wantNames) {
+		t.Errorf("WriteEPUB() files = %v, want %v", names, wantNames)
+	}
+	if z.File[0].Method != zip.Store {
+		t.Errorf("WriteEPUB() mimetype is compressed")
+	}
+
+	back, err := ReadEPUB(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("ReadEPUB() error = %v", err)
+	}
+	d := back.(*fb2)
+	ti := d.data.Description.TitleInfo
+	if ti.BookTitle != "Big Book" || ti.Lang != "en" || len(ti.Author) != 1 || ti.Author[0].LastName != "Writer" {
+		t.Errorf("ReadEPUB() title-info = %q, %q, %v", ti.BookTitle, ti.Lang, ti.Author)
+	}
+	titles := []string{}
+	for _, s := range d.body.SelectElements("section") {
+		if title := s.SelectElement("title"); title != nil {
+			titles = append(titles, plainText(title))
+		}
+	}
+	wantTitles := []string{"Part One", "Part Two", "Part Three", "Notes"}
+	if !reflect.DeepEqual(titles, wantTitles) {
+		t.Errorf("ReadEPUB() sections = %q, want %q", titles, wantTitles)
+	}
+	if len(d.data.Binary) != 1 || d.data.Binary[0].Id != "map.png" {
+		t.Errorf("ReadEPUB() binaries = %v", d.data.Binary)
+	}
+	if err := checkLinks(d.body); err != nil {
+		t.Errorf("ReadEPUB() links error = %v", err)
+	}
+	if a := d.body.FindElement(".//section/p/a"); a == nil || a.SelectAttrValue("href", "") != "#n1" {
+		t.Errorf("ReadEPUB() note link = %v", a)
+	}
+
+	if _, err := ReadEPUB(bytes.NewReader(nil), 0); err == nil {
+		t.Errorf("ReadEPUB() of empty input error = nil")
+	}
+	var empty bytes.Buffer
+	zw := zip.NewWriter(&empty)
+	zw.Create("mimetype")
+	zw.Close()
+	if _, err := ReadEPUB(bytes.NewReader(empty.Bytes()), int64(empty.Len())); !errors.Is(err, ErrInvalidEPUB) {
+		t.Errorf("ReadEPUB() error = %v, want %v", err, ErrInvalidEPUB)
+	}
+}
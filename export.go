@@ -0,0 +1,38 @@
+package fb2
+
+import (
+	"strings"
+
+	etree "github.com/rupor-github/fb2converter/etree"
+)
+
+// exportBodies returns copies of main body with generated title
+// and annotation and of other bodies for conversion to other formats
+func (d *fb2) exportBodies() (*etree.Element, []*etree.Element) {
+	body := d.body.Copy()
+	d.writeBodyTitle(body)
+	if d.annotation != nil {
+		insertAfterHead(body, d.annotation.Copy(), "image", "title")
+	}
+	bodies := make([]*etree.Element, 0, len(d.bodies))
+	for _, b := range d.bodies {
+		bodies = append(bodies, b.Copy())
+	}
+	return body, bodies
+}
+
+// binary returns book binary by id or link to it
+func (d *fb2) binary(href string) (FictionBookBinary, bool) {
+	id := strings.TrimPrefix(href, "#")
+	for _, b := range d.data.Binary {
+		if b.Id == id {
+			return b, true
+		}
+	}
+	return FictionBookBinary{}, false
+}
+
+// dataURI returns data URI of binary
+func dataURI(b FictionBookBinary) string {
+	return "data:" + b.ContentType + ";base64," + stripSpaces(b.Text)
+}
@@ -1,6 +1,7 @@
 package fb2
 
 import (
+	"bytes"
 	"encoding/base64"
 	"encoding/xml"
 	"errors"
@@ -27,7 +28,20 @@ type fb2 struct {
 	data FictionBookScheme
 	// srcFileName string
 	body       *etree.Element
+	bodies     []*etree.Element
 	annotation *etree.Element
+	// srcAnnotation and history are description elements with
+	// mixed content, kept as element trees like annotation
+	srcAnnotation *etree.Element
+	history       *etree.Element
+	// stylesheets are stylesheet elements of document
+	stylesheets []*etree.Element
+	bodyTitle   *BodyTitle
+	// sectionIDMode defines ids of new sections
+	sectionIDMode SectionIDMode
+	// allowBrokenLinks disables link check on write
+	allowBrokenLinks bool
+	toc              *TOC
 }
 
 var (
@@ -37,29 +51,24 @@ var (
 )
 
 func NewFB2(title string) FB2 {
+	bt := DefaultBodyTitle
 	v := &fb2{
-		body: etree.NewElement("body"),
+		body:      etree.NewElement("body"),
+		bodyTitle: &bt,
 	}
 	v.data.Description.TitleInfo.Author = []AuthorType{}
-	v.data.Description.TitleInfo.Genre = []string{}
+	v.data.Description.TitleInfo.Genre = []Genre{}
 	v.data.Description.TitleInfo.Translator = []AuthorType{}
 	v.data.Description.DocumentInfo.Author = []AuthorType{}
 	v.data.Description.DocumentInfo.Publisher = []AuthorType{}
 	v.body.SetText("\n")
-	bt := v.body.CreateElement("title")
-	bt.SetText("\n")
-	bt.CreateElement("p").SetTail("\n")
-	bTitle := bt.CreateElement("p")
-	bTitle.SetText(title)
-	bTitle.SetTail("\n")
-	bt.SetTail("\n")
 	v.body.SetTail("\n")
 	v.SetIdentifier(uuid.Must(uuid.NewV4()).String())
 	v.data.Description.TitleInfo.BookTitle = title
 	v.data.Description.PublishInfo.BookName = title
 	v.data.Description.DocumentInfo.Date.Value = time.Now().Format(dateValueFmt)
 	v.data.Description.DocumentInfo.Date.Text = time.Now().Format(dateTextFmt)
-	v.data.Description.DocumentInfo.ProgramUsed = "go-fb2"
+	v.data.Description.DocumentInfo.ProgramUsed = programName
 	v.data.Description.DocumentInfo.Version = "1.0"
 	return v
 }
@@ -68,22 +77,96 @@ type FB2 interface {
 	AddCSS(source string, mime string)
 	AddImage(source, internalFilename, mimeType string) (string, error)
 	AddSection(body string, sectionTitle string) error
+	AddSectionBlocks(sectionTitle string, blocks ...Block) error
+	Sections() []SectionInfo
+	Section(i int) (*etree.Element, error)
+	SectionByID(id string) (*etree.Element, error)
+	InsertSection(i int, sectionTitle string, blocks ...Block) error
+	MoveSection(from, to int) error
+	ReplaceSectionContent(i int, blocks ...Block) error
+	SetSectionTitle(i int, lines ...string) error
+	SetSectionID(i int, id string) error
+	RemoveSection(i int) error
+	SetSectionIDMode(mode SectionIDMode)
+	AssignSectionIDs(mode SectionIDMode) int
+	Anchor(e *etree.Element) string
+	SectionAnchor(i int) (string, error)
+	ParagraphAnchor(i, p int) (string, error)
+	CheckLinks() error
+	AllowBrokenLinks(allow bool)
+	SetTOC(t *TOC)
+	NormalizeTypography(rules TypoRule)
+	Hyphenate() error
+	AddBodyEpigraph(e *EpigraphBuilder) error
 	Title() string
 	Author() string
 	Description() string
 	Identifier() string
 	Lang() string
 	Genre() []string
-	Sequence() string
+	Sequence() []SequenceType
 	SetTitle(title string)
 	SetAuthor(author AuthorType)
+	SetBodyTitle(t *BodyTitle)
+	Authors(role AuthorRole) []AuthorType
+	AddAuthor(role AuthorRole, author AuthorType)
+	ReplaceAuthor(role AuthorRole, old, author AuthorType) bool
+	RemoveAuthor(role AuthorRole, author AuthorType) bool
 	SetCover(srcName string) error
 	SetDescription(desc string) error
+	SetAnnotation(text string)
+	SetAnnotationBlocks(blocks ...Block) error
+	SetAnnotationHTML(s string) error
+	Annotation() string
+	AnnotationElement() *etree.Element
 	SetIdentifier(identifier string)
 	SetLang(lang string)
 	SetSequence(name string, number int64)
-	SetGenre(g []string)
+	AddSequence(seq SequenceType)
+	SetSequenceNumber(name string, number int64) bool
+	RemoveSequence(name string) bool
+	SetGenre(g []string) error
+	Genres() []Genre
+	AddGenre(code string, match int64) error
+	SrcTitle() string
+	SrcAuthors() []AuthorType
+	SrcLang() string
+	SrcGenre() []string
+	SrcSequence() []SequenceType
+	SetSrcTitle(title string)
+	AddSrcAuthor(author AuthorType)
+	SetSrcLang(lang string)
+	SetSrcGenre(g []string) error
+	SetSrcSequence(name string, number int64)
+	PublishInfo() DescriptionPublishInfo
+	ISBN() string
+	SetPublishBookName(name string)
+	SetPublisher(publisher string)
+	SetPublishCity(city string)
+	SetPublishYear(year string) error
+	SetISBN(isbn string) error
+	PublishSequence() []SequenceType
+	SetPublishSequence(name string, number int64)
+	AddPublishSequence(seq SequenceType)
+	RemovePublishSequence(name string) bool
+	Version() string
+	History() []string
+	AddRevision(rev Revision) string
+	CustomInfo(infoType string) (string, bool)
+	CustomInfos() map[string]string
+	SetCustomInfo(infoType, value string)
+	RemoveCustomInfo(infoType string) bool
+	Validate() []error
 	WriteToFile(destFilePath string) error
+	WriteZip(w io.Writer, name string) error
+	WriteText(w io.Writer) error
+	WriteMarkdown(w io.Writer) error
+	WriteHTML(w io.Writer) error
+	WriteEPUB(w io.Writer) error
+	Binaries() []BinaryInfo
+	BinaryData(id string) ([]byte, error)
+	ExtractBinaries(dir string) (map[string]string, error)
+	ReplaceBinary(id string, data []byte) error
 	WriteToString() (string, error)
 	Body() *etree.Element
 	Data() *FictionBookScheme
@@ -144,7 +227,7 @@ func (d *fb2) AddSection(body string, sectionTitle string) error {
 	if err != nil {
 		return fmt.Errorf("read section body error: %w", err)
 	}
-	section := d.body.CreateElement("section")
+	section := etree.NewElement("section")
 	section.SetText("\n")
 	title := section.CreateElement("title")
 	title.CreateElement("p").SetText(sectionTitle)
@@ -157,8 +240,12 @@ func (d *fb2) AddSection(body string, sectionTitle string) error {
 	if len(sElems) != 0 {
 		sElems[len(sElems)-1].SetTail("\n")
 	}
+	if err := d.checkIDs(nil, section); err != nil {
+		return fmt.Errorf("add section error: %w", err)
+	}
 	section.SetTail("\n")
 	d.body.AddChild(section)
+	d.assignSectionID(section)
 	return nil
 }
 
@@ -184,6 +271,7 @@ func (d *fb2) getAuthor() string {
 	return authorsString
 }
 
+// Description returns annotation content as FB2 markup
 func (d *fb2) Description() string {
 	d.Lock()
 	defer d.Unlock()
@@ -191,12 +279,14 @@ func (d *fb2) Description() string {
 		return ""
 	}
 	doc := etree.NewDocument()
-	doc.SetRoot(d.annotation.Copy())
+	for _, e := range d.annotation.ChildElements() {
+		doc.AddChild(e.Copy())
+	}
 	desc, err := doc.WriteToString()
 	if err != nil {
 		return ""
 	}
-	return desc
+	return strings.TrimSpace(desc)
 }
 
 func (d *fb2) Identifier() string {
@@ -211,16 +301,16 @@ func (d *fb2) Lang() string {
 	return d.data.Description.TitleInfo.Lang
 }
 
-func (d *fb2) Sequence() string {
+func (d *fb2) Sequence() []SequenceType {
 	d.Lock()
 	defer d.Unlock()
-	return d.data.Description.TitleInfo.Sequence.String()
+	return copySequences(d.data.Description.TitleInfo.Sequence)
 }
 
 func (d *fb2) Genre() []string {
 	d.Lock()
 	defer d.Unlock()
-	return d.data.Description.TitleInfo.Genre
+	return genreCodes(d.data.Description.TitleInfo.Genre)
 }
 
 func (d *fb2) SetTitle(title string) {
@@ -233,10 +323,6 @@ func (d *fb2) SetAuthor(author AuthorType) {
 	d.Lock()
 	defer d.Unlock()
 	d.data.Description.TitleInfo.Author = append(d.data.Description.TitleInfo.Author, author)
-	bAuthor := d.body.FindElement("./title/p")
-	if bAuthor != nil {
-		bAuthor.SetText(d.getAuthor())
-	}
 }
 
 func (d *fb2) SetCover(srcName string) error {
@@ -293,23 +379,34 @@ func (d *fb2) addBinaryImage(data []byte, name string) (string, error) {
 	return name, nil
 }
 
+// SetDescription sets annotation from HTML if desc contains markup,
+// otherwise from plain text. Markup must be well-formed XML, use
+// SetAnnotationHTML for arbitrary HTML.
 func (d *fb2) SetDescription(desc string) error {
 	d.Lock()
 	defer d.Unlock()
-	doc := etree.NewDocument()
-	desc = strings.TrimSpace(desc)
-	if !strings.HasPrefix(desc, "<p>") {
-		desc = fmt.Sprintf(`<p>%s</p>`, desc)
+	if !strings.Contains(desc, "<") {
+		d.setAnnotationBlocks(textBlocks(desc)...)
+		return nil
 	}
-	desc = fmt.Sprintf(`<section>%s</section>`, desc)
-	err := doc.ReadFromString(desc)
-	if err != nil {
+	if err := checkMarkup(desc); err != nil {
+		return fmt.Errorf("error SetDescription: %w", err)
+	}
+	if err := d.setAnnotationHTML(desc); err != nil {
 		return fmt.Errorf("error SetDescription: %w", err)
 	}
-	d.annotation = doc.Root().Copy()
 	return nil
 }
 
+// checkMarkup checks that desc is well-formed paragraph markup
+func checkMarkup(desc string) error {
+	desc = strings.TrimSpace(desc)
+	if !strings.HasPrefix(desc, "<p>") {
+		desc = fmt.Sprintf(`<p>%s</p>`, desc)
+	}
+	return etree.NewDocument().ReadFromString(fmt.Sprintf(`<section>%s</section>`, desc))
+}
+
 func (d *fb2) SetIdentifier(identifier string) {
 	d.Lock()
 	defer d.Unlock()
@@ -325,19 +422,34 @@ func (d *fb2) SetLang(lang string) {
 func (d *fb2) SetSequence(name string, number int64) {
 	d.Lock()
 	defer d.Unlock()
-	d.data.Description.TitleInfo.Sequence.Name = name
-	d.data.Description.TitleInfo.Sequence.Number = fmt.Sprintf("%v", number)
+	d.data.Description.TitleInfo.Sequence = []SequenceType{NewSequence(name, number)}
 }
 
-func (d *fb2) SetGenre(g []string) {
+func (d *fb2) SetGenre(g []string) error {
 	d.Lock()
 	defer d.Unlock()
-	d.data.Description.TitleInfo.Genre = g
+	if err := validateGenres(g); err != nil {
+		return fmt.Errorf("set genre error: %w", err)
+	}
+	d.data.Description.TitleInfo.Genre = newGenres(g)
+	return nil
 }
 
+// WriteToFile writes book to file. Path ending with .zip
+// gets zip archive with single fb2 entry.
 func (d *fb2) WriteToFile(destFilePath string) error {
 	d.Lock()
 	defer d.Unlock()
+	if strings.EqualFold(filepath.Ext(destFilePath), ".zip") {
+		var buf bytes.Buffer
+		if err := d.writeZip(&buf, zipEntryName(destFilePath)); err != nil {
+			return fmt.Errorf("write to file error: %w", err)
+		}
+		if err := ioutil.WriteFile(destFilePath, buf.Bytes(), 0644); err != nil {
+			return fmt.Errorf("write to file error: %w", err)
+		}
+		return nil
+	}
 	book, err := d.writeToString()
 	if err != nil {
 		return fmt.Errorf("write to file error: %w", err)
@@ -373,18 +485,33 @@ func (d *fb2) writeToString() (string, error) {
 	fb := doc.Root()
 	fb.CreateAttr("xmlns:l", "http://www.w3.org/1999/xlink")
 	fb.CreateAttr("xmlns", "http://www.gribuser.ru/xml/fictionbook/2.0")
-	desc := doc.FindElement("//title-info/annotation")
-	if desc != nil {
-		desc.Child = nil
-		desc.AddChild(d.annotation)
+	setDescElement(doc.FindElement("//title-info"), d.annotation, "genre", "author", "book-title")
+	setDescElement(doc.FindElement("//src-title-info"), d.srcAnnotation, "genre", "author", "book-title")
+	setDescElement(doc.FindElement("//document-info"), d.history,
+		"author", "program-used", "date", "src-url", "src-ocr", "id", "version")
+	for _, ann := range doc.FindElements("//description/*/annotation") {
+		if len(ann.Child) == 0 {
+			ann.Parent().RemoveChild(ann)
+		}
+	}
+	if desc := fb.SelectElement("description"); desc != nil {
+		for _, s := range d.stylesheets {
+			fb.InsertChild(desc, s.Copy().SetTail("\n  "))
+		}
 	}
 	body := doc.FindElement("//body")
 	if body != nil {
 		*body = *(d.body.Copy())
+		d.writeBodyTitle(body)
+		d.writeTOC(body, d.ids())
+		for i := range d.bodies {
+			fb.InsertChild(fb.SelectElement("binary"), d.bodies[i].Copy())
+		}
 	}
-	bTitle := body.FindElements("//title/p")
-	if len(bTitle) < 2 {
-		return "", errors.New("invalid body title structure")
+	if !d.allowBrokenLinks {
+		if err := checkLinks(fb); err != nil {
+			return "", fmt.Errorf("write error: %w", err)
+		}
 	}
 	out, err := doc.WriteToString()
 	if err != nil {
@@ -393,6 +520,26 @@ func (d *fb2) writeToString() (string, error) {
 	return out, nil
 }
 
+// setDescElement replaces element of parent with the same tag by
+// copy of e or inserts it after head elements
+func setDescElement(parent, e *etree.Element, head ...string) {
+	if parent == nil || e == nil {
+		return
+	}
+	e = e.Copy()
+	if old := parent.SelectElement(e.Tag); old != nil {
+		parent.InsertChild(old, e.SetTail(old.Tail()))
+		parent.RemoveChild(old)
+		return
+	}
+	tail := "\n"
+	if c := parent.ChildElements(); len(c) != 0 {
+		tail = c[0].Tail()
+	}
+	insertAfterHead(parent, e, head...)
+	e.SetTail(tail)
+}
+
 func (d *fb2) Body() *etree.Element {
 	return d.body
 }
@@ -241,7 +241,7 @@ func Test_fb2_Description(t *testing.T) {
 			fields: fields{
 				testB,
 			},
-			want: `<p>Hello, <p>Hello, World</p>World</p>`,
+			want: "<p>Hello,</p>\n<p>Hello, World</p>\n<p>World</p>",
 		},
 	}
 	for _, tt := range tests {
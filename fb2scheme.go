@@ -56,17 +56,17 @@ type DescriptionDocumentInfo struct {
 type DescriptionPublishInfo struct {
 	XMLName xml.Name `xml:"publish-info"`
 
-	BookName string `xml:"book-name"`
+	BookName string `xml:"book-name,omitempty"`
 
-	Publisher string `xml:"publisher"`
+	Publisher string `xml:"publisher,omitempty"`
 
-	City string `xml:"city"`
+	City string `xml:"city,omitempty"`
 
-	Year string `xml:"year"`
+	Year string `xml:"year,omitempty"`
 
-	Isbn TextFieldType `xml:"isbn"`
+	Isbn *TextFieldType `xml:"isbn,omitempty"`
 
-	Sequence SequenceType `xml:"sequence,omitempty"`
+	Sequence []SequenceType `xml:"sequence,omitempty"`
 }
 
 // Element
@@ -153,25 +153,23 @@ type Coverpage struct {
 type BodyType string
 
 type AuthorType struct {
-	XMLName xml.Name `xml:"author"`
+	FirstName string `xml:"first-name,omitempty"`
 
-	FirstName string `xml:"first-name"`
+	MiddleName string `xml:"middle-name,omitempty"`
 
-	MiddleName string `xml:"middle-name"`
+	LastName string `xml:"last-name,omitempty"`
 
-	LastName string `xml:"last-name"`
-
-	Nickname string `xml:"nickname"`
+	Nickname string `xml:"nickname,omitempty"`
 
 	HomePage []string `xml:"home-page"`
 
 	Email []string `xml:"email"`
 
-	Id string `xml:"id"`
+	Id string `xml:"id,omitempty"`
 }
 
 func (a *AuthorType) String() string {
-	return fmt.Sprintf("%s %s", a.FirstName, a.LastName)
+	return a.Format(AuthorFormatFull)
 }
 
 type TextFieldType struct {
@@ -407,14 +405,19 @@ type StyleLinkType struct {
 }
 
 type SequenceType struct {
-	Name string `xml:"name,attr,omitempty"`
+	Name string `xml:"name,attr"`
 
 	Number string `xml:"number,attr,omitempty"`
 
 	XmlLang string `xml:"lang,attr,omitempty"`
+
+	Sequence []SequenceType `xml:"sequence,omitempty"`
 }
 
 func (st *SequenceType) String() string {
+	if st.Number == "" {
+		return st.Name
+	}
 	return fmt.Sprintf("%s: %s", st.Name, st.Number)
 }
 
@@ -427,7 +430,7 @@ type TableType struct {
 }
 
 type TitleInfoType struct {
-	Genre []string `xml:"genre,omitempty"`
+	Genre []Genre `xml:"genre,omitempty"`
 
 	Author []AuthorType `xml:"author"`
 
@@ -447,7 +450,7 @@ type TitleInfoType struct {
 
 	Translator []AuthorType `xml:"translator,omitempty"`
 
-	Sequence SequenceType `xml:"sequence,omitempty"`
+	Sequence []SequenceType `xml:"sequence,omitempty"`
 }
 
 type ShareInstructionType struct {
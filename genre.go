@@ -0,0 +1,211 @@
+package fb2
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+var (
+	// ErrUnknownGenre is returned for codes missing in FB2 genre list
+	ErrUnknownGenre = errors.New("unknown fb2 genre")
+	// ErrGenreMatch is returned for match percentage out of 1..100 range
+	ErrGenreMatch = errors.New("invalid genre match")
+)
+
+var (
+	genreIndex = map[string]int{}
+	genreNames = map[string]string{}
+	// genreKeywordsByLen is genreKeywords keys sorted longest first
+	genreKeywordsByLen []string
+	// childGenres maps genre groups to children's genres
+	// for juvenile BISAC-like labels
+	childGenres = map[string]string{
+		"sf":        "child_sf",
+		"detective": "child_det",
+		"adventure": "child_adv",
+		"poetry":    "child_verse",
+		"children":  "children",
+	}
+)
+
+func init() {
+	for i, g := range genreTable {
+		genreIndex[g.Code] = i
+		genreNames[strings.ToLower(g.En)] = g.Code
+		genreNames[strings.ToLower(g.Ru)] = g.Code
+	}
+	for k := range genreKeywords {
+		genreKeywordsByLen = append(genreKeywordsByLen, k)
+	}
+	sort.Slice(genreKeywordsByLen, func(i, j int) bool {
+		a, b := genreKeywordsByLen[i], genreKeywordsByLen[j]
+		if len(a) != len(b) {
+			return len(a) > len(b)
+		}
+		return a < b
+	})
+}
+
+// GenreList returns standard FB2 genre list
+func GenreList() []GenreInfo {
+	return append([]GenreInfo{}, genreTable...)
+}
+
+// LookupGenre returns genre description for code
+func LookupGenre(code string) (GenreInfo, bool) {
+	i, ok := genreIndex[code]
+	if !ok {
+		return GenreInfo{}, false
+	}
+	return genreTable[i], true
+}
+
+// Name returns genre name in lang. Russian and English are supported,
+// English is used for other languages.
+func (g GenreInfo) Name(lang string) string {
+	if strings.HasPrefix(strings.ToLower(lang), "ru") {
+		return g.Ru
+	}
+	return g.En
+}
+
+// ValidateGenre checks that code is in FB2 genre list
+func ValidateGenre(code string) error {
+	if _, ok := genreIndex[code]; !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownGenre, code)
+	}
+	return nil
+}
+
+// MapGenre maps free-text tag or BISAC-like label ("FICTION / Fantasy / Epic")
+// to FB2 genre code. The most specific label part is matched first.
+func MapGenre(tag string) (string, bool) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if _, ok := genreIndex[tag]; ok {
+		return tag, true
+	}
+	parts := strings.Split(tag, "/")
+	juvenile := strings.Contains(parts[0], "juvenile") || strings.Contains(parts[0], "young adult")
+	for i := len(parts) - 1; i >= 0; i-- {
+		code, ok := mapGenrePart(strings.TrimSpace(parts[i]))
+		if !ok {
+			continue
+		}
+		if juvenile {
+			g, _ := LookupGenre(code)
+			if c, ok := childGenres[g.Group]; ok {
+				return c, true
+			}
+			return "child_prose", true
+		}
+		return code, true
+	}
+	return "", false
+}
+
+func mapGenrePart(part string) (string, bool) {
+	if part == "" {
+		return "", false
+	}
+	if code, ok := genreNames[part]; ok {
+		return code, true
+	}
+	if code, ok := genreKeywords[part]; ok {
+		return code, true
+	}
+	for _, k := range genreKeywordsByLen {
+		if containsWord(part, k) {
+			return genreKeywords[k], true
+		}
+	}
+	return "", false
+}
+
+// containsWord reports whether s contains word w on word boundaries
+func containsWord(s, w string) bool {
+	for i := 0; ; {
+		j := strings.Index(s[i:], w)
+		if j < 0 {
+			return false
+		}
+		start, end := i+j, i+j+len(w)
+		if (start == 0 || !isWordRune(lastRune(s[:start]))) &&
+			(end == len(s) || !isWordRune([]rune(s[end:])[0])) {
+			return true
+		}
+		i = start + 1
+	}
+}
+
+func lastRune(s string) rune {
+	r := []rune(s)
+	return r[len(r)-1]
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// MapGenres maps tags to unique FB2 genre codes, skipping unknown tags
+func MapGenres(tags []string) []string {
+	res := []string{}
+	seen := map[string]bool{}
+	for _, t := range tags {
+		if code, ok := MapGenre(t); ok && !seen[code] {
+			seen[code] = true
+			res = append(res, code)
+		}
+	}
+	return res
+}
+
+func validateGenres(g []string) error {
+	for _, code := range g {
+		if err := ValidateGenre(code); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func genreCodes(g []Genre) []string {
+	res := make([]string, 0, len(g))
+	for _, v := range g {
+		res = append(res, strings.TrimSpace(v.Text))
+	}
+	return res
+}
+
+func newGenres(g []string) []Genre {
+	res := make([]Genre, 0, len(g))
+	for _, code := range g {
+		res = append(res, Genre{Text: code})
+	}
+	return res
+}
+
+// Genres returns book genres with match percentage
+func (d *fb2) Genres() []Genre {
+	d.Lock()
+	defer d.Unlock()
+	return append([]Genre{}, d.data.Description.TitleInfo.Genre...)
+}
+
+// AddGenre validates and appends genre with match percentage.
+// Zero match means the attribute is omitted (100% by FB2 spec).
+func (d *fb2) AddGenre(code string, match int64) error {
+	d.Lock()
+	defer d.Unlock()
+	if err := ValidateGenre(code); err != nil {
+		return err
+	}
+	if match < 0 || match > 100 {
+		return fmt.Errorf("%w: %d", ErrGenreMatch, match)
+	}
+	d.data.Description.TitleInfo.Genre = append(d.data.Description.TitleInfo.Genre,
+		Genre{Text: code, Match: match})
+	return nil
+}
@@ -0,0 +1,91 @@
+package fb2
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMapGenre(t *testing.T) {
+	tests := []struct {
+		tag    string
+		want   string
+		wantOk bool
+	}{
+		{"sf_fantasy", "sf_fantasy", true},
+		{"Фэнтези", "sf_fantasy", true},
+		{"Классический детектив", "det_classic", true},
+		{"FICTION / Science Fiction / Space Opera", "sf_space", true},
+		{"FICTION / Mystery & Detective / Police Procedural", "det_police", true},
+		{"FICTION / Thrillers / Espionage", "det_espionage", true},
+		{"JUVENILE FICTION / Fantasy & Magic", "child_sf", true},
+		{"JUVENILE FICTION / General", "children", true},
+		{"COMPUTERS / Programming / Open Source", "comp_programming", true},
+		{"dark fantasy", "sf_fantasy", true},
+		{"martial", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tag, func(t *testing.T) {
+			got, ok := MapGenre(tt.tag)
+			if got != tt.want || ok != tt.wantOk {
+				t.Errorf("MapGenre() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+	if got := MapGenres([]string{"Fantasy", "фэнтези", "unknown", "Poetry"}); !reflect.DeepEqual(got, []string{"sf_fantasy", "poetry"}) {
+		t.Errorf("MapGenres() = %v", got)
+	}
+}
+
+func TestLookupGenre(t *testing.T) {
+	g, ok := LookupGenre("prose_contemporary")
+	if !ok || g.Name("ru") != "Современная проза" || g.Name("en") != "Contemporary prose" {
+		t.Errorf("LookupGenre() = %+v, %v", g, ok)
+	}
+	for _, g := range GenreList() {
+		if err := ValidateGenre(g.Code); err != nil {
+			t.Errorf("ValidateGenre(%q) error = %v", g.Code, err)
+		}
+	}
+	for _, code := range genreKeywords {
+		if err := ValidateGenre(code); err != nil {
+			t.Errorf("genreKeywords: %v", err)
+		}
+	}
+}
+
+func Test_fb2_SetGenre(t *testing.T) {
+	d := NewFB2("Test1Title")
+	if err := d.SetGenre([]string{"sf_fantasy", "fantasy"}); !errors.Is(err, ErrUnknownGenre) {
+		t.Errorf("fb2.SetGenre() error = %v, want %v", err, ErrUnknownGenre)
+	}
+	if err := d.SetGenre([]string{"sf_fantasy"}); err != nil {
+		t.Errorf("fb2.SetGenre() error = %v", err)
+	}
+	if err := d.AddGenre("sf_epic", 40); err != nil {
+		t.Errorf("fb2.AddGenre() error = %v", err)
+	}
+	if err := d.AddGenre("sf_epic", 140); !errors.Is(err, ErrGenreMatch) {
+		t.Errorf("fb2.AddGenre() error = %v, want %v", err, ErrGenreMatch)
+	}
+	d.SetDescription("Test")
+	out, err := d.WriteToString()
+	if err != nil {
+		t.Fatalf("fb2.WriteToString() error = %v", err)
+	}
+	if !strings.Contains(out, `<genre match="40">sf_epic</genre>`) {
+		t.Errorf("fb2.WriteToString() genre match not found:\n%s", out)
+	}
+	r, err := ReadFB2(strings.NewReader(out))
+	if err != nil {
+		t.Fatalf("ReadFB2() error = %v", err)
+	}
+	if got := r.Genre(); !reflect.DeepEqual(got, []string{"sf_fantasy", "sf_epic"}) {
+		t.Errorf("fb2.Genre() = %v", got)
+	}
+	if got := r.Genres(); len(got) != 2 || got[1].Match != 40 {
+		t.Errorf("fb2.Genres() = %+v", got)
+	}
+}
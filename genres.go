@@ -0,0 +1,280 @@
+package fb2
+
+// GenreInfo describes standard FB2 genre code
+type GenreInfo struct {
+	Code  string
+	Group string
+	En    string
+	Ru    string
+}
+
+// genreTable is the standard FB2 genre list
+var genreTable = []GenreInfo{
+	// Science fiction and fantasy
+	{"sf_history", "sf", "Alternative history", "Альтернативная история"},
+	{"sf_action", "sf", "Action science fiction", "Боевая фантастика"},
+	{"sf_epic", "sf", "Epic science fiction", "Эпическая фантастика"},
+	{"sf_heroic", "sf", "Heroic science fiction", "Героическая фантастика"},
+	{"sf_detective", "sf", "Detective science fiction", "Детективная фантастика"},
+	{"sf_cyberpunk", "sf", "Cyberpunk", "Киберпанк"},
+	{"sf_space", "sf", "Space science fiction", "Космическая фантастика"},
+	{"sf_social", "sf", "Social science fiction", "Социально-психологическая фантастика"},
+	{"sf_horror", "sf", "Horror and mystic", "Ужасы и мистика"},
+	{"sf_humor", "sf", "Humor science fiction", "Юмористическая фантастика"},
+	{"sf_fantasy", "sf", "Fantasy", "Фэнтези"},
+	{"sf", "sf", "Science fiction", "Научная фантастика"},
+	// Detectives and thrillers
+	{"det_classic", "detective", "Classical detective", "Классический детектив"},
+	{"det_police", "detective", "Police stories", "Полицейский детектив"},
+	{"det_action", "detective", "Action", "Боевик"},
+	{"det_irony", "detective", "Ironical detective", "Иронический детектив"},
+	{"det_history", "detective", "Historical detective", "Исторический детектив"},
+	{"det_espionage", "detective", "Espionage detective", "Шпионский детектив"},
+	{"det_crime", "detective", "Crime detective", "Криминальный детектив"},
+	{"det_political", "detective", "Political detective", "Политический детектив"},
+	{"det_maniac", "detective", "Maniacs", "Маньяки"},
+	{"det_hard", "detective", "Hard-boiled", "Крутой детектив"},
+	{"thriller", "detective", "Thriller", "Триллер"},
+	{"detective", "detective", "Detective", "Детектив"},
+	// Prose
+	{"prose_classic", "prose", "Classical prose", "Классическая проза"},
+	{"prose_history", "prose", "Historical prose", "Историческая проза"},
+	{"prose_contemporary", "prose", "Contemporary prose", "Современная проза"},
+	{"prose_counter", "prose", "Counterculture", "Контркультура"},
+	{"prose_rus_classic", "prose", "Russian classical prose", "Русская классическая проза"},
+	{"prose_su_classics", "prose", "Soviet classical prose", "Советская классическая проза"},
+	// Romance
+	{"love_contemporary", "love", "Contemporary romance", "Современные любовные романы"},
+	{"love_history", "love", "Historical romance", "Исторические любовные романы"},
+	{"love_detective", "love", "Detective romance", "Остросюжетные любовные романы"},
+	{"love_short", "love", "Short romance", "Короткие любовные романы"},
+	{"love_erotica", "love", "Erotica", "Эротика"},
+	// Adventure
+	{"adv_western", "adventure", "Western", "Вестерн"},
+	{"adv_history", "adventure", "History adventure", "Исторические приключения"},
+	{"adv_indian", "adventure", "Indians", "Приключения про индейцев"},
+	{"adv_maritime", "adventure", "Maritime fiction", "Морские приключения"},
+	{"adv_geo", "adventure", "Travel and geography", "Путешествия и география"},
+	{"adv_animal", "adventure", "Nature and animals", "Природа и животные"},
+	{"adventure", "adventure", "Adventure", "Приключения"},
+	// Children
+	{"child_tale", "children", "Fairy tales", "Сказка"},
+	{"child_verse", "children", "Children's verses", "Детские стихи"},
+	{"child_prose", "children", "Children's prose", "Детская проза"},
+	{"child_sf", "children", "Children's science fiction", "Детская фантастика"},
+	{"child_det", "children", "Children's action", "Детские остросюжетные"},
+	{"child_adv", "children", "Children's adventure", "Детские приключения"},
+	{"child_education", "children", "Children's education", "Детская образовательная литература"},
+	{"children", "children", "Children's", "Детская литература"},
+	// Poetry and dramaturgy
+	{"poetry", "poetry", "Poetry", "Поэзия"},
+	{"dramaturgy", "poetry", "Dramaturgy", "Драматургия"},
+	// Antique literature
+	{"antique_ant", "antique", "Antique", "Античная литература"},
+	{"antique_european", "antique", "European antique", "Европейская старинная литература"},
+	{"antique_russian", "antique", "Old russian", "Древнерусская литература"},
+	{"antique_east", "antique", "Old east", "Древневосточная литература"},
+	{"antique_myths", "antique", "Myths and legends", "Мифы. Легенды. Эпос"},
+	{"antique", "antique", "Other antique", "Старинная литература"},
+	// Science and education
+	{"sci_history", "science", "History", "История"},
+	{"sci_psychology", "science", "Psychology", "Психология"},
+	{"sci_culture", "science", "Cultural science", "Культурология"},
+	{"sci_religion", "science", "Religious studies", "Религиоведение"},
+	{"sci_philosophy", "science", "Philosophy", "Философия"},
+	{"sci_politics", "science", "Politics", "Политика"},
+	{"sci_business", "science", "Business literature", "Деловая литература"},
+	{"sci_juris", "science", "Jurisprudence", "Юриспруденция"},
+	{"sci_linguistic", "science", "Linguistics", "Языкознание"},
+	{"sci_medicine", "science", "Medicine", "Медицина"},
+	{"sci_phys", "science", "Physics", "Физика"},
+	{"sci_math", "science", "Mathematics", "Математика"},
+	{"sci_chem", "science", "Chemistry", "Химия"},
+	{"sci_biology", "science", "Biology", "Биология"},
+	{"sci_tech", "science", "Technical", "Технические науки"},
+	{"science", "science", "Science", "Научная литература"},
+	// Computers and internet
+	{"comp_www", "computers", "Internet", "Интернет"},
+	{"comp_programming", "computers", "Programming", "Программирование"},
+	{"comp_hard", "computers", "Computer hardware", "Компьютерное железо"},
+	{"comp_soft", "computers", "Software", "Программы"},
+	{"comp_db", "computers", "Databases", "Базы данных"},
+	{"comp_osnet", "computers", "OS and networking", "ОС и сети"},
+	{"computers", "computers", "Computers", "Компьютерная литература"},
+	// Reference
+	{"ref_encyc", "reference", "Encyclopedias", "Энциклопедии"},
+	{"ref_dict", "reference", "Dictionaries", "Словари"},
+	{"ref_ref", "reference", "Reference", "Справочники"},
+	{"ref_guide", "reference", "Guidebooks", "Руководства"},
+	{"reference", "reference", "Other reference", "Справочная литература"},
+	// Non-fiction
+	{"nonf_biography", "nonfiction", "Biography and memoirs", "Биографии и мемуары"},
+	{"nonf_publicism", "nonfiction", "Publicism", "Публицистика"},
+	{"nonf_criticism", "nonfiction", "Criticism", "Критика"},
+	{"design", "nonfiction", "Art and design", "Искусство и дизайн"},
+	{"nonfiction", "nonfiction", "Other non-fiction", "Документальная литература"},
+	// Religion and spirituality
+	{"religion_rel", "religion", "Religion", "Религия"},
+	{"religion_esoterics", "religion", "Esoterics", "Эзотерика"},
+	{"religion_self", "religion", "Self-improvement", "Самосовершенствование"},
+	{"religion", "religion", "Other religion", "Религиозная литература"},
+	// Humor
+	{"humor_anecdote", "humor", "Anecdote", "Анекдоты"},
+	{"humor_prose", "humor", "Humor prose", "Юмористическая проза"},
+	{"humor_verse", "humor", "Humor verses", "Юмористические стихи"},
+	{"humor", "humor", "Other humor", "Юмор"},
+	// Home and family
+	{"home_cooking", "home", "Cooking", "Кулинария"},
+	{"home_pets", "home", "Pets", "Домашние животные"},
+	{"home_crafts", "home", "Hobbies and crafts", "Хобби и ремесла"},
+	{"home_entertain", "home", "Entertaining", "Развлечения"},
+	{"home_health", "home", "Health", "Здоровье"},
+	{"home_garden", "home", "Garden", "Сад и огород"},
+	{"home_diy", "home", "Do it yourself", "Сделай сам"},
+	{"home_sport", "home", "Sports", "Спорт"},
+	{"home_sex", "home", "Erotica and sex", "Эротика и секс"},
+	{"home", "home", "Other home", "Домоводство"},
+	// Business
+	{"economics", "business", "Economics", "Экономика"},
+	{"management", "business", "Management", "Управление, подбор персонала"},
+	{"marketing", "business", "Marketing", "Маркетинг, PR, реклама"},
+	{"banking", "business", "Banking", "Банковское дело"},
+	{"accounting", "business", "Accounting", "Бухучет и аудит"},
+	{"personal_finance", "business", "Personal finance", "Личные финансы"},
+	{"stock", "business", "Stock", "Ценные бумаги, инвестиции"},
+	{"small_business", "business", "Small business", "Малый бизнес"},
+	{"popular_business", "business", "Popular business", "Карьера, кадры"},
+	{"real_estate", "business", "Real estate", "Недвижимость"},
+}
+
+// genreKeywords maps lowercase free-text tags to genre codes.
+// Longer keywords are matched before shorter ones.
+var genreKeywords = map[string]string{
+	"alternate history":   "sf_history",
+	"alternative history": "sf_history",
+	"science fiction":     "sf",
+	"sci-fi":              "sf",
+	"scifi":               "sf",
+	"space opera":         "sf_space",
+	"military":            "sf_action",
+	"cyberpunk":           "sf_cyberpunk",
+	"dystopian":           "sf_social",
+	"post-apocalyptic":    "sf_social",
+	"epic":                "sf_epic",
+	"fantasy":             "sf_fantasy",
+	"horror":              "sf_horror",
+	"ghost":               "sf_horror",
+	"paranormal":          "sf_horror",
+	"mystery":             "detective",
+	"detective":           "detective",
+	"cozy":                "det_irony",
+	"police procedural":   "det_police",
+	"espionage":           "det_espionage",
+	"spy":                 "det_espionage",
+	"crime":               "det_crime",
+	"noir":                "det_hard",
+	"hard-boiled":         "det_hard",
+	"thriller":            "thriller",
+	"suspense":            "thriller",
+	"action":              "det_action",
+	"historical romance":  "love_history",
+	"erotica":             "love_erotica",
+	"romance":             "love_contemporary",
+	"love":                "love_contemporary",
+	"western":             "adv_western",
+	"sea stories":         "adv_maritime",
+	"travel":              "adv_geo",
+	"animals":             "adv_animal",
+	"adventure":           "adventure",
+	"fairy tales":         "child_tale",
+	"juvenile":            "children",
+	"young adult":         "children",
+	"children":            "children",
+	"poetry":              "poetry",
+	"drama":               "dramaturgy",
+	"plays":               "dramaturgy",
+	"mythology":           "antique_myths",
+	"classics":            "prose_classic",
+	"historical":          "prose_history",
+	"literary":            "prose_contemporary",
+	"contemporary":        "prose_contemporary",
+	"fiction":             "prose_contemporary",
+	"history":             "sci_history",
+	"psychology":          "sci_psychology",
+	"philosophy":          "sci_philosophy",
+	"political science":   "sci_politics",
+	"politics":            "sci_politics",
+	"law":                 "sci_juris",
+	"language arts":       "sci_linguistic",
+	"linguistics":         "sci_linguistic",
+	"medical":             "sci_medicine",
+	"medicine":            "sci_medicine",
+	"physics":             "sci_phys",
+	"mathematics":         "sci_math",
+	"chemistry":           "sci_chem",
+	"biology":             "sci_biology",
+	"technology":          "sci_tech",
+	"engineering":         "sci_tech",
+	"science":             "science",
+	"programming":         "comp_programming",
+	"software":            "comp_soft",
+	"hardware":            "comp_hard",
+	"databases":           "comp_db",
+	"networking":          "comp_osnet",
+	"internet":            "comp_www",
+	"computers":           "computers",
+	"encyclopedias":       "ref_encyc",
+	"dictionaries":        "ref_dict",
+	"reference":           "reference",
+	"biography":           "nonf_biography",
+	"memoir":              "nonf_biography",
+	"essays":              "nonf_publicism",
+	"criticism":           "nonf_criticism",
+	"art":                 "design",
+	"design":              "design",
+	"nonfiction":          "nonfiction",
+	"religion":            "religion_rel",
+	"occult":              "religion_esoterics",
+	"self-help":           "religion_self",
+	"humor":               "humor",
+	"cooking":             "home_cooking",
+	"pets":                "home_pets",
+	"crafts":              "home_crafts",
+	"health":              "home_health",
+	"gardening":           "home_garden",
+	"sports":              "home_sport",
+	"business":            "sci_business",
+	"economics":           "economics",
+	"management":          "management",
+	"marketing":           "marketing",
+	"finance":             "personal_finance",
+	"investing":           "stock",
+	"real estate":         "real_estate",
+	"фантастика":          "sf",
+	"фэнтези":             "sf_fantasy",
+	"фентези":             "sf_fantasy",
+	"киберпанк":           "sf_cyberpunk",
+	"ужасы":               "sf_horror",
+	"мистика":             "sf_horror",
+	"детектив":            "detective",
+	"триллер":             "thriller",
+	"боевик":              "det_action",
+	"любовный роман":      "love_contemporary",
+	"роман":               "prose_contemporary",
+	"проза":               "prose_contemporary",
+	"приключения":         "adventure",
+	"сказка":              "child_tale",
+	"детская":             "children",
+	"стихи":               "poetry",
+	"поэзия":              "poetry",
+	"история":             "sci_history",
+	"психология":          "sci_psychology",
+	"философия":           "sci_philosophy",
+	"биография":           "nonf_biography",
+	"мемуары":             "nonf_biography",
+	"публицистика":        "nonf_publicism",
+	"религия":             "religion_rel",
+	"юмор":                "humor",
+	"кулинария":           "home_cooking",
+	"программирование":    "comp_programming",
+}
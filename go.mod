@@ -6,4 +6,5 @@ require (
 	github.com/DaRealFreak/cloudflare-bp-go v1.0.1
 	github.com/gofrs/uuid v4.0.0+incompatible
 	github.com/rupor-github/fb2converter v1.58.1
+	golang.org/x/net v0.0.0-20211015210444-4f30a5c0130f
 )
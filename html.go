@@ -0,0 +1,403 @@
+package fb2
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	etree "github.com/rupor-github/fb2converter/etree"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// htmlInlineTags maps HTML inline tags to FB2 style elements
+var htmlInlineTags = map[atom.Atom]string{
+	atom.B:      "strong",
+	atom.Strong: "strong",
+	atom.I:      "emphasis",
+	atom.Em:     "emphasis",
+	atom.Cite:   "emphasis",
+	atom.S:      "strikethrough",
+	atom.Strike: "strikethrough",
+	atom.Del:    "strikethrough",
+	atom.Sub:    "sub",
+	atom.Sup:    "sup",
+	atom.Code:   "code",
+	atom.Tt:     "code",
+	atom.Kbd:    "code",
+	atom.Samp:   "code",
+}
+
+// htmlConverter converts HTML node tree into FB2 block elements
+type htmlConverter struct {
+	// cur is the paragraph collecting inline content
+	cur *etree.Element
+	// image returns binary id of image source, empty id keeps
+	// image alt text. Nil image keeps alt text of all images.
+	image func(src string) string
+	// anchors maps HTML ids to ids of converted elements.
+	// Nil anchors drops HTML ids.
+	anchors map[string]string
+	// ids are ids used in book
+	ids map[string]bool
+	// pending are HTML ids waiting for the next block element
+	pending []string
+	// links are converted links to documents and anchors
+	links []*etree.Element
+	// blockImages turns paragraphs with single image into block
+	// images. Annotations allow inline images only.
+	blockImages bool
+}
+
+// htmlToFB2 parses HTML fragment from r and appends converted
+// FB2 blocks to parent
+func htmlToFB2(r io.Reader, parent *etree.Element) error {
+	nodes, err := html.ParseFragment(r, &html.Node{
+		Type:     html.ElementNode,
+		Data:     "body",
+		DataAtom: atom.Body,
+	})
+	if err != nil {
+		return fmt.Errorf("parse html error: %w", err)
+	}
+	root := &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+	for _, n := range nodes {
+		root.AppendChild(n)
+	}
+	c := &htmlConverter{}
+	if len(parent.Child) == 0 {
+		parent.SetText("\n")
+	}
+	c.block(root, parent)
+	c.flush()
+	return nil
+}
+
+// newBlockChild creates block child element of parent
+func newBlockChild(parent *etree.Element, tag string) *etree.Element {
+	return parent.CreateElement(tag).SetTail("\n")
+}
+
+// newBlock creates block child element of parent with pending anchors
+func (c *htmlConverter) newBlock(parent *etree.Element, tag string) *etree.Element {
+	e := newBlockChild(parent, tag)
+	c.anchor(e)
+	return e
+}
+
+// anchor gives e pending HTML ids
+func (c *htmlConverter) anchor(e *etree.Element) {
+	for _, id := range c.pending {
+		if _, ok := c.anchors[id]; ok {
+			continue
+		}
+		if e.SelectAttrValue("id", "") == "" {
+			e.CreateAttr("id", uniqueID(id, c.ids))
+		}
+		c.anchors[id] = e.SelectAttrValue("id", "")
+	}
+	c.pending = nil
+}
+
+// addAnchor remembers HTML id of n. Ids inside paragraph refer to it.
+func (c *htmlConverter) addAnchor(n *html.Node) {
+	if c.anchors == nil {
+		return
+	}
+	if id := getAttr(n, "id"); id != "" {
+		c.pending = append(c.pending, id)
+	}
+	if c.cur != nil {
+		c.anchor(c.cur)
+	}
+}
+
+// para returns current paragraph, creating it if needed
+func (c *htmlConverter) para(parent *etree.Element) *etree.Element {
+	if c.cur == nil {
+		c.cur = c.newBlock(parent, "p")
+	}
+	return c.cur
+}
+
+// flush closes current paragraph, removing it if empty.
+// Paragraph with single image becomes block image if blockImages is set.
+func (c *htmlConverter) flush() {
+	if c.cur == nil {
+		return
+	}
+	trimInline(c.cur)
+	if c.blockImages && len(c.cur.Child) == 1 && c.cur.Tag == "p" {
+		if img, ok := c.cur.Child[0].(*etree.Element); ok && img.Tag == "image" {
+			c.cur.Tag = "image"
+			c.cur.Attr = append(img.Attr, c.cur.Attr...)
+			c.cur.Child = nil
+		}
+	}
+	if len(c.cur.Child) == 0 && c.cur.Tag != "image" {
+		if id := c.cur.SelectAttrValue("id", ""); id != "" {
+			// anchors move to the next block
+			moved := []string{}
+			for k, v := range c.anchors {
+				if v == id {
+					moved = append(moved, k)
+					delete(c.anchors, k)
+				}
+			}
+			sort.Strings(moved)
+			c.pending = append(moved, c.pending...)
+			delete(c.ids, id)
+		}
+		c.cur.Parent().RemoveChild(c.cur)
+	}
+	c.cur = nil
+}
+
+func (c *htmlConverter) block(n *html.Node, parent *etree.Element) {
+	for ch := n.FirstChild; ch != nil; ch = ch.NextSibling {
+		switch ch.Type {
+		case html.TextNode:
+			if c.cur == nil && strings.TrimSpace(ch.Data) == "" {
+				continue
+			}
+			c.inline(ch, c.para(parent))
+		case html.ElementNode:
+			c.blockElement(ch, parent)
+		}
+	}
+}
+
+func (c *htmlConverter) blockElement(n *html.Node, parent *etree.Element) {
+	switch n.DataAtom {
+	case atom.Script, atom.Style, atom.Head, atom.Title, atom.Noscript:
+	default:
+		c.addAnchor(n)
+	}
+	switch n.DataAtom {
+	case atom.Script, atom.Style, atom.Head, atom.Title, atom.Noscript:
+	case atom.Br:
+		c.flush()
+	case atom.Hr:
+		c.flush()
+		newBlockChild(parent, "empty-line")
+	case atom.P:
+		if getAttr(n, "class") == "subtitle" {
+			c.subtitle(n, parent)
+			break
+		}
+		c.flush()
+		c.block(n, parent)
+		c.flush()
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		c.subtitle(n, parent)
+	case atom.Blockquote:
+		c.flush()
+		cite := c.newBlock(parent, "cite")
+		cite.SetText("\n")
+		c.block(n, cite)
+		c.flush()
+	case atom.Ul, atom.Ol:
+		c.flush()
+		i := 0
+		for li := n.FirstChild; li != nil; li = li.NextSibling {
+			if li.DataAtom != atom.Li {
+				continue
+			}
+			i++
+			c.addAnchor(li)
+			c.cur = c.newBlock(parent, "p")
+			if n.DataAtom == atom.Ol {
+				appendText(c.cur, fmt.Sprintf("%d. ", i))
+			} else {
+				appendText(c.cur, "• ")
+			}
+			c.inlineChildren(li, c.cur)
+			c.flush()
+		}
+	case atom.Pre:
+		c.flush()
+		for _, l := range strings.Split(strings.Trim(nodeText(n), "\n"), "\n") {
+			p := c.newBlock(parent, "p")
+			p.CreateElement("code").SetText(l)
+		}
+	case atom.Table:
+		c.flush()
+		c.table(n, parent)
+	case atom.Div, atom.Section, atom.Article, atom.Main, atom.Header,
+		atom.Footer, atom.Aside, atom.Nav, atom.Body, atom.Html, atom.Figure,
+		atom.Figcaption, atom.Dl, atom.Dt, atom.Dd, atom.Center, atom.Address:
+		c.flush()
+		c.block(n, parent)
+		c.flush()
+	default:
+		c.inline(n, c.para(parent))
+	}
+}
+
+// subtitle converts inline content of n into subtitle
+func (c *htmlConverter) subtitle(n *html.Node, parent *etree.Element) {
+	c.flush()
+	c.cur = c.newBlock(parent, "subtitle")
+	c.inlineChildren(n, c.cur)
+	c.flush()
+}
+
+// inline appends inline content of n to e
+func (c *htmlConverter) inline(n *html.Node, e *etree.Element) {
+	switch n.Type {
+	case html.TextNode:
+		appendText(e, collapseSpace(n.Data))
+		return
+	case html.ElementNode:
+	default:
+		return
+	}
+	switch n.DataAtom {
+	case atom.Script, atom.Style:
+		return
+	}
+	c.addAnchor(n)
+	switch n.DataAtom {
+	case atom.Br:
+		appendText(e, " ")
+		return
+	case atom.Img:
+		if c.image != nil {
+			if id := c.image(getAttr(n, "src")); id != "" {
+				img := e.CreateElement("image")
+				img.CreateAttr("l:href", "#"+id)
+				if alt := getAttr(n, "alt"); alt != "" {
+					img.CreateAttr("alt", alt)
+				}
+				return
+			}
+		}
+		if alt := getAttr(n, "alt"); alt != "" {
+			appendText(e, alt)
+		}
+		return
+	case atom.A:
+		if href := getAttr(n, "href"); href != "" {
+			a := e.CreateElement("a")
+			a.CreateAttr("l:href", href)
+			if !isExternalURL(href) {
+				c.links = append(c.links, a)
+			}
+			c.inlineChildren(n, a)
+			return
+		}
+	}
+	if tag, ok := htmlInlineTags[n.DataAtom]; ok {
+		c.inlineChildren(n, e.CreateElement(tag))
+		return
+	}
+	c.inlineChildren(n, e)
+}
+
+func (c *htmlConverter) inlineChildren(n *html.Node, e *etree.Element) {
+	for ch := n.FirstChild; ch != nil; ch = ch.NextSibling {
+		c.inline(ch, e)
+	}
+}
+
+func (c *htmlConverter) table(n *html.Node, parent *etree.Element) {
+	table := newBlockChild(parent, "table")
+	table.SetText("\n")
+	var rows func(n *html.Node)
+	rows = func(n *html.Node) {
+		for ch := n.FirstChild; ch != nil; ch = ch.NextSibling {
+			switch ch.DataAtom {
+			case atom.Thead, atom.Tbody, atom.Tfoot:
+				rows(ch)
+			case atom.Tr:
+				tr := newBlockChild(table, "tr")
+				tr.SetText("\n")
+				for td := ch.FirstChild; td != nil; td = td.NextSibling {
+					if td.DataAtom != atom.Td && td.DataAtom != atom.Th {
+						continue
+					}
+					cell := newBlockChild(tr, td.Data)
+					for _, a := range []string{"colspan", "rowspan", "align", "valign"} {
+						if v := getAttr(td, a); v != "" {
+							cell.CreateAttr(a, v)
+						}
+					}
+					c.inlineChildren(td, cell)
+					trimInline(cell)
+				}
+			}
+		}
+	}
+	rows(n)
+}
+
+// isExternalURL reports whether href has URL scheme like http:
+func isExternalURL(href string) bool {
+	i := strings.IndexAny(href, ":/#?")
+	return i > 0 && href[i] == ':'
+}
+
+func getAttr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// nodeText returns raw text of HTML node
+func nodeText(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var sb strings.Builder
+	for ch := n.FirstChild; ch != nil; ch = ch.NextSibling {
+		sb.WriteString(nodeText(ch))
+	}
+	return sb.String()
+}
+
+// collapseSpace replaces whitespace runs with single space
+func collapseSpace(s string) string {
+	var sb strings.Builder
+	space := false
+	for _, r := range s {
+		if r == ' ' || r == '\t' || r == '\n' || r == '\r' || r == '\f' {
+			if !space {
+				sb.WriteByte(' ')
+			}
+			space = true
+			continue
+		}
+		space = false
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// trimInline trims leading and trailing spaces of inline content
+func trimInline(e *etree.Element) {
+	if len(e.Child) == 0 {
+		return
+	}
+	if cd, ok := e.Child[0].(*etree.CharData); ok {
+		cd.Data = strings.TrimLeft(cd.Data, " ")
+		if cd.Data == "" {
+			e.RemoveChild(cd)
+		}
+	}
+	if len(e.Child) == 0 {
+		return
+	}
+	switch t := e.Child[len(e.Child)-1].(type) {
+	case *etree.CharData:
+		t.Data = strings.TrimRight(t.Data, " ")
+		if t.Data == "" {
+			e.RemoveChild(t)
+		}
+	case *etree.Element:
+		t.SetTail(strings.TrimRight(t.Tail(), " "))
+	}
+}
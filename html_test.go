@@ -0,0 +1,76 @@
+package fb2
+
+import (
+	"strings"
+	"testing"
+
+	etree "github.com/rupor-github/fb2converter/etree"
+)
+
+func Test_htmlToFB2(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{
+		{
+			name: "paragraphs and styles",
+			html: `<p>Hello, <b>bold</b> and <i>italic</i>
+				world</p><p>Second<br>line</p>`,
+			want: `<section>
+<p>Hello, <strong>bold</strong> and <emphasis>italic</emphasis> world</p>
+<p>Second</p>
+<p>line</p>
+</section>`,
+		},
+		{
+			name: "headings, links and rules",
+			html: `<h2>Title</h2>text <a href="http://example.com">link</a><hr/><script>x()</script>`,
+			want: `<section>
+<subtitle>Title</subtitle>
+<p>text <a l:href="http://example.com">link</a></p>
+<empty-line/>
+</section>`,
+		},
+		{
+			name: "lists and quotes",
+			html: `<ul><li>one</li><li>two</li></ul><ol><li>first</li></ol><blockquote>quote</blockquote>`,
+			want: `<section>
+<p>• one</p>
+<p>• two</p>
+<p>1. first</p>
+<cite>
+<p>quote</p>
+</cite>
+</section>`,
+		},
+		{
+			name: "pre and table",
+			html: "<pre>a := 1\nb := 2</pre><table><tr><th colspan=\"2\">H</th></tr><tr><td>1</td><td> 2 </td></tr></table>",
+			want: `<section>
+<p><code>a := 1</code></p>
+<p><code>b := 2</code></p>
+<table>
+<tr>
+<th colspan="2">H</th>
+</tr>
+<tr>
+<td>1</td>
+<td>2</td>
+</tr>
+</table>
+</section>`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := etree.NewElement("section")
+			if err := htmlToFB2(strings.NewReader(tt.html), e); err != nil {
+				t.Fatalf("htmlToFB2() error = %v", err)
+			}
+			if got := elementString(t, e); got != tt.want {
+				t.Errorf("htmlToFB2() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
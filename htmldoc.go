@@ -0,0 +1,382 @@
+package fb2
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	etree "github.com/rupor-github/fb2converter/etree"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// imageExts are binary file extensions by supported image content type
+var imageExts = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+}
+
+// htmlBook builds FB2 book from HTML documents
+type htmlBook struct {
+	d *fb2
+	// ids are ids used in book
+	ids map[string]bool
+	// images are binary ids by image source, empty for unsupported images
+	images map[string]string
+}
+
+func newHTMLBook() *htmlBook {
+	return &htmlBook{
+		d:      NewFB2("").(*fb2),
+		ids:    map[string]bool{},
+		images: map[string]string{},
+	}
+}
+
+// addImage adds image binary once per source key and returns its id.
+// Only JPEG and PNG images are added, empty id is returned for others.
+func (b *htmlBook) addImage(key string, data []byte) string {
+	if id, ok := b.images[key]; ok {
+		return id
+	}
+	contentType := http.DetectContentType(data)
+	ext, ok := imageExts[contentType]
+	if !ok {
+		b.images[key] = ""
+		return ""
+	}
+	name := "image"
+	if !strings.HasPrefix(key, "data:") {
+		base := path.Base(key)
+		if s := slugID(strings.TrimSuffix(base, path.Ext(base))); s != "" {
+			name = s
+		}
+	}
+	id := uniqueBinaryID(name+ext, b.ids)
+	b.d.data.Binary = append(b.d.data.Binary, FictionBookBinary{
+		ContentType: contentType,
+		Id:          id,
+		Text:        base64.StdEncoding.EncodeToString(data),
+	})
+	b.images[key] = id
+	return id
+}
+
+// decodeDataURI returns content of base64 data URI
+func decodeDataURI(src string) ([]byte, bool) {
+	if !strings.HasPrefix(src, "data:") {
+		return nil, false
+	}
+	i := strings.IndexByte(src, ',')
+	if i < 0 || !strings.HasSuffix(src[:i], ";base64") {
+		return nil, false
+	}
+	data, err := base64.StdEncoding.DecodeString(stripSpaces(src[i+1:]))
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+// headingLevel returns level of h1-h6 node, 0 for other nodes
+func headingLevel(n *html.Node) int {
+	switch n.DataAtom {
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		return int(n.Data[1] - '0')
+	}
+	return 0
+}
+
+// findHeadings calls f for headings of n in document order
+func findHeadings(n *html.Node, f func(h *html.Node, level int)) {
+	for ch := n.FirstChild; ch != nil; ch = ch.NextSibling {
+		if l := headingLevel(ch); l != 0 {
+			f(ch, l)
+			continue
+		}
+		findHeadings(ch, f)
+	}
+}
+
+// chapterLevel returns heading level splitting document into sections.
+// Single heading of the top level followed by lower level headings
+// is returned as book title.
+func chapterLevel(body *html.Node) (int, *html.Node) {
+	counts := [7]int{}
+	first := [7]*html.Node{}
+	findHeadings(body, func(h *html.Node, level int) {
+		if counts[level] == 0 {
+			first[level] = h
+		}
+		counts[level]++
+	})
+	for top := 1; top <= 6; top++ {
+		if counts[top] == 0 {
+			continue
+		}
+		if counts[top] == 1 {
+			for l := top + 1; l <= 6; l++ {
+				if counts[l] != 0 {
+					return l, first[top]
+				}
+			}
+		}
+		return top, nil
+	}
+	return 0, nil
+}
+
+// htmlSection is part of HTML document converted into FB2 section
+type htmlSection struct {
+	title *html.Node
+	// ids are HTML ids of section start
+	ids   []string
+	nodes []*html.Node
+}
+
+// hasHeading reports whether n contains heading of level or higher
+func hasHeading(n *html.Node, level int) bool {
+	found := false
+	findHeadings(n, func(h *html.Node, l int) {
+		found = found || l <= level
+	})
+	return found
+}
+
+// splitHTML splits children of n into sections at headings of level
+// or higher. Containers with headings are split too.
+func splitHTML(n *html.Node, level int, secs []*htmlSection, ids []string) ([]*htmlSection, []string) {
+	children := []*html.Node{}
+	for ch := n.FirstChild; ch != nil; ch = ch.NextSibling {
+		children = append(children, ch)
+	}
+	for _, ch := range children {
+		switch l := headingLevel(ch); {
+		case l != 0 && l <= level:
+			if id := getAttr(ch, "id"); id != "" {
+				ids = append(ids, id)
+			}
+			secs = append(secs, &htmlSection{title: ch, ids: ids})
+			ids = nil
+		case ch.Type == html.ElementNode && hasHeading(ch, level):
+			if id := getAttr(ch, "id"); id != "" {
+				ids = append(ids, id)
+			}
+			secs, ids = splitHTML(ch, level, secs, ids)
+		default:
+			if len(secs) == 0 {
+				if ch.Type != html.ElementNode && strings.TrimSpace(ch.Data) == "" {
+					continue
+				}
+				secs = append(secs, &htmlSection{})
+			}
+			last := secs[len(secs)-1]
+			last.nodes = append(last.nodes, ch)
+		}
+	}
+	return secs, ids
+}
+
+// headingLines returns text lines of heading split at line breaks
+func headingLines(n *html.Node) []string {
+	lines := []string{}
+	var sb strings.Builder
+	flush := func() {
+		if s := strings.Join(strings.Fields(sb.String()), " "); s != "" {
+			lines = append(lines, s)
+		}
+		sb.Reset()
+	}
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		switch {
+		case n.Type == html.TextNode:
+			sb.WriteString(n.Data)
+		case n.DataAtom == atom.Br:
+			flush()
+		case n.DataAtom == atom.Img:
+			sb.WriteString(getAttr(n, "alt"))
+		}
+		for ch := n.FirstChild; ch != nil; ch = ch.NextSibling {
+			walk(ch)
+		}
+	}
+	walk(n)
+	flush()
+	return lines
+}
+
+// findNode returns first descendant of n with tag a
+func findNode(n *html.Node, a atom.Atom) *html.Node {
+	for ch := n.FirstChild; ch != nil; ch = ch.NextSibling {
+		if ch.DataAtom == a {
+			return ch
+		}
+		if f := findNode(ch, a); f != nil {
+			return f
+		}
+	}
+	return nil
+}
+
+// addDocument converts HTML document into book sections. Images
+// are resolved by image. Single top heading becomes book title if
+// title is set. It returns converter with document anchors and links.
+func (b *htmlBook) addDocument(doc *html.Node, image func(src string) string, title bool) *htmlConverter {
+	c := &htmlConverter{
+		image:       image,
+		anchors:     map[string]string{},
+		ids:         b.ids,
+		blockImages: true,
+	}
+	body := findNode(doc, atom.Body)
+	if body == nil {
+		return c
+	}
+	level, head := chapterLevel(body)
+	if head != nil && title {
+		if ti := &b.d.data.Description.TitleInfo; ti.BookTitle == "" {
+			ti.BookTitle = strings.Join(headingLines(head), ". ")
+		}
+		head.Parent.RemoveChild(head)
+	} else if head != nil {
+		level = headingLevel(head)
+	}
+	secs, _ := splitHTML(body, level, nil, nil)
+	for _, s := range secs {
+		section := newBlockChild(b.d.body, "section")
+		section.SetText("\n")
+		if len(s.ids) != 0 {
+			c.pending = s.ids
+			c.anchor(section)
+		}
+		if s.title != nil {
+			section.AddChild(newTitle(headingLines(s.title)...).SetTail("\n"))
+		}
+		root := &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+		for _, n := range s.nodes {
+			n.Parent.RemoveChild(n)
+			root.AppendChild(n)
+		}
+		c.block(root, section)
+		c.flush()
+	}
+	return c
+}
+
+// resolveLinks sets link targets by resolve and replaces links
+// that can't be resolved with their text
+func resolveLinks(links []*etree.Element, resolve func(href string) (string, bool)) {
+	for _, a := range links {
+		if id, ok := resolve(a.SelectAttrValue("href", "")); ok {
+			a.CreateAttr("l:href", "#"+id)
+			continue
+		}
+		unwrapElement(a)
+	}
+}
+
+// setHTMLMeta sets title, language, authors and annotation
+// from HTML document head
+func (b *htmlBook) setHTMLMeta(doc *html.Node) {
+	ti := &b.d.data.Description.TitleInfo
+	if h := findNode(doc, atom.Html); h != nil {
+		ti.Lang = getAttr(h, "lang")
+	}
+	head := findNode(doc, atom.Head)
+	if head == nil {
+		return
+	}
+	if t := findNode(head, atom.Title); t != nil {
+		ti.BookTitle = strings.Join(strings.Fields(nodeText(t)), " ")
+	}
+	for m := head.FirstChild; m != nil; m = m.NextSibling {
+		if m.DataAtom != atom.Meta {
+			continue
+		}
+		content := strings.TrimSpace(getAttr(m, "content"))
+		switch strings.ToLower(getAttr(m, "name")) {
+		case "author":
+			if content != "" {
+				ti.Author = append(ti.Author, ParseAuthor(content, NameOrderAuto))
+			}
+		case "description":
+			b.d.setAnnotationBlocks(textBlocks(content)...)
+		case "keywords":
+			ti.Keywords = content
+		}
+	}
+}
+
+// readHTML converts HTML book from r. Images are loaded by load,
+// nil load keeps image alt text.
+func readHTML(r io.Reader, load func(src string) ([]byte, error)) (*fb2, error) {
+	src, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("read html error: %w", err)
+	}
+	doc, err := html.Parse(strings.NewReader(decodeText(src)))
+	if err != nil {
+		return nil, fmt.Errorf("read html error: %w", err)
+	}
+	b := newHTMLBook()
+	b.setHTMLMeta(doc)
+	image := func(src string) string {
+		if data, ok := decodeDataURI(src); ok {
+			return b.addImage(src, data)
+		}
+		if load == nil {
+			return ""
+		}
+		data, err := load(src)
+		if err != nil {
+			return ""
+		}
+		return b.addImage(src, data)
+	}
+	c := b.addDocument(doc, image, true)
+	resolveLinks(c.links, func(href string) (string, bool) {
+		if !strings.HasPrefix(href, "#") {
+			return "", false
+		}
+		id, ok := c.anchors[href[1:]]
+		return id, ok
+	})
+	return b.d, nil
+}
+
+// ReadHTML converts HTML book into FB2. Sections start at headings
+// of the top level, a single top heading is taken as book title.
+// Only images embedded as data URIs are kept.
+func ReadHTML(r io.Reader) (FB2, error) {
+	return readHTML(r, nil)
+}
+
+// localLoader returns loader of local files relative to dir
+func localLoader(dir string) func(src string) ([]byte, error) {
+	return func(src string) ([]byte, error) {
+		u, err := url.Parse(src)
+		if err != nil || u.Scheme != "" || u.Host != "" {
+			return nil, fmt.Errorf("image %q is not local", src)
+		}
+		return ioutil.ReadFile(filepath.Join(dir, filepath.FromSlash(u.Path)))
+	}
+}
+
+// OpenHTML converts HTML book file into FB2 like ReadHTML.
+// Local images are loaded relative to the file.
+func OpenHTML(srcFilePath string) (FB2, error) {
+	f, err := os.Open(srcFilePath)
+	if err != nil {
+		return nil, fmt.Errorf("open html error: %w", err)
+	}
+	defer f.Close()
+	return readHTML(f, localLoader(filepath.Dir(srcFilePath)))
+}
@@ -0,0 +1,63 @@
+package fb2
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func TestReadHTML(t *testing.T) {
+	var img bytes.Buffer
+	if err := png.Encode(&img, image.NewGray(image.Rect(0, 0, 1, 1))); err != nil {
+		t.Fatal(err)
+	}
+	src := `<!DOCTYPE html><html lang="en"><head><title>Book</title>
+<meta name="author" content="Ann Writer"><meta name="description" content="About it.">
+</head><body>
+<h1>Book</h1>
+<h2 id="one">One</h2>
+<p>See <a href="#two">two</a> and <a href="other.html">other</a>.</p>
+<p><img src="data:image/png;base64,` + base64.StdEncoding.EncodeToString(img.Bytes()) + `" alt="pic"></p>
+<h2>Two</h2>
+<p id="two">Back to <a href="#one">one</a>.</p>
+</body></html>`
+	book, err := ReadHTML(strings.NewReader(src))
+	if err != nil {
+		t.Fatalf("ReadHTML() error = %v", err)
+	}
+	d := book.(*fb2)
+	ti := d.data.Description.TitleInfo
+	if ti.BookTitle != "Book" || ti.Lang != "en" || len(ti.Author) != 1 || ti.Author[0].LastName != "Writer" {
+		t.Errorf("ReadHTML() title-info = %q, %q, %v", ti.BookTitle, ti.Lang, ti.Author)
+	}
+	if d.annotation == nil || plainText(d.annotation) != "About it." {
+		t.Errorf("ReadHTML() annotation = %v", d.annotation)
+	}
+	if len(d.data.Binary) != 1 || d.data.Binary[0].Id != "image.png" {
+		t.Errorf("ReadHTML() binaries = %v", d.data.Binary)
+	}
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"One", "<section id=\"one\">\n<title>\n<p>One</p>\n</title>\n" +
+			"<p>See <a l:href=\"#two\">two</a> and other.</p>\n" +
+			"<image l:href=\"#image.png\" alt=\"pic\"/>\n</section>\n"},
+		{"Two", "<section>\n<title>\n<p>Two</p>\n</title>\n" +
+			"<p id=\"two\">Back to <a l:href=\"#one\">one</a>.</p>\n</section>\n"},
+	}
+	sections := d.body.SelectElements("section")
+	if len(sections) != len(tests) {
+		t.Fatalf("ReadHTML() sections = %d, want %d", len(sections), len(tests))
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := elementString(t, sections[i]); got != tt.want {
+				t.Errorf("section = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
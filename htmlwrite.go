@@ -0,0 +1,244 @@
+package fb2
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"strconv"
+	"strings"
+
+	etree "github.com/rupor-github/fb2converter/etree"
+)
+
+// htmlStyleTags maps FB2 style elements to HTML inline tags
+var htmlStyleTags = map[string]string{
+	"strong":        "strong",
+	"emphasis":      "em",
+	"strikethrough": "del",
+	"sub":           "sub",
+	"sup":           "sup",
+	"code":          "code",
+}
+
+// htmlWriter renders FB2 elements as HTML. Output is well-formed
+// XML, so it is valid XHTML too.
+type htmlWriter struct {
+	sb strings.Builder
+	// image returns source of image link, empty source keeps alt text
+	image func(href string) string
+	// link returns target of internal link
+	link func(href string) string
+}
+
+func (h *htmlWriter) text(s string) {
+	h.sb.WriteString(html.EscapeString(s))
+}
+
+// open writes start tag with id of e and class
+func (h *htmlWriter) open(tag string, e *etree.Element, class string) {
+	h.sb.WriteString("<" + tag)
+	if id := e.SelectAttrValue("id", ""); id != "" {
+		h.sb.WriteString(` id="` + html.EscapeString(id) + `"`)
+	}
+	if class != "" {
+		h.sb.WriteString(` class="` + class + `"`)
+	}
+	h.sb.WriteString(">")
+}
+
+func (h *htmlWriter) inline(e *etree.Element) {
+	for _, t := range e.Child {
+		switch c := t.(type) {
+		case *etree.CharData:
+			h.text(c.Data)
+		case *etree.Element:
+			h.inlineElement(c)
+			h.text(c.Tail())
+		}
+	}
+}
+
+func (h *htmlWriter) inlineElement(e *etree.Element) {
+	switch e.Tag {
+	case "a":
+		href := e.SelectAttrValue("href", "")
+		if strings.HasPrefix(href, "#") && h.link != nil {
+			href = h.link(href)
+		}
+		h.sb.WriteString(`<a href="` + html.EscapeString(href) + `"`)
+		if e.SelectAttrValue("type", "") == "note" {
+			h.sb.WriteString(` class="note"`)
+		}
+		h.sb.WriteString(">")
+		h.inline(e)
+		h.sb.WriteString("</a>")
+	case "image":
+		h.img(e)
+	default:
+		tag, ok := htmlStyleTags[e.Tag]
+		if !ok {
+			h.inline(e)
+			return
+		}
+		h.sb.WriteString("<" + tag + ">")
+		h.inline(e)
+		h.sb.WriteString("</" + tag + ">")
+	}
+}
+
+// img writes image element or its alt text if image can't be shown
+func (h *htmlWriter) img(e *etree.Element) {
+	alt := e.SelectAttrValue("alt", "")
+	src := ""
+	if h.image != nil {
+		src = h.image(e.SelectAttrValue("href", ""))
+	}
+	if src == "" {
+		h.text(alt)
+		return
+	}
+	h.sb.WriteString(`<img src="` + html.EscapeString(src) + `" alt="` + html.EscapeString(alt) + `"/>`)
+}
+
+// para writes inline content of e as paragraph of tag
+func (h *htmlWriter) para(tag string, e *etree.Element, class string) {
+	h.open(tag, e, class)
+	h.inline(e)
+	h.sb.WriteString("</" + tag + ">\n")
+}
+
+// container writes block children of e wrapped in tag
+func (h *htmlWriter) container(tag string, e *etree.Element, class string, level int) {
+	h.open(tag, e, class)
+	h.sb.WriteString("\n")
+	for _, c := range e.ChildElements() {
+		h.block(c, level)
+	}
+	h.sb.WriteString("</" + tag + ">\n")
+}
+
+// heading writes section title as heading of level
+func (h *htmlWriter) heading(title *etree.Element, level int) {
+	tag := "h" + strconv.Itoa(minInt(level, 6))
+	h.open(tag, title, "")
+	for i, c := range title.ChildElements() {
+		if i != 0 {
+			h.sb.WriteString("<br/>")
+		}
+		h.inline(c)
+	}
+	h.sb.WriteString("</" + tag + ">\n")
+}
+
+// block writes block element e, level is heading level of its titles
+func (h *htmlWriter) block(e *etree.Element, level int) {
+	switch e.Tag {
+	case "body":
+		for _, c := range e.ChildElements() {
+			if c.Tag == "section" {
+				h.block(c, level+1)
+				continue
+			}
+			h.block(c, level)
+		}
+	case "section":
+		h.open("section", e, "")
+		h.sb.WriteString("\n")
+		for _, c := range e.ChildElements() {
+			if c.Tag == "section" {
+				h.block(c, level+1)
+				continue
+			}
+			h.block(c, level)
+		}
+		h.sb.WriteString("</section>\n")
+	case "title":
+		if p := e.Parent(); p != nil && (p.Tag == "section" || p.Tag == "body") {
+			h.heading(e, level)
+			return
+		}
+		h.container("div", e, "title", level)
+	case "p":
+		h.para("p", e, "")
+	case "subtitle", "v", "text-author", "date":
+		h.para("p", e, e.Tag)
+	case "epigraph", "cite", "annotation":
+		h.container("blockquote", e, e.Tag, level+1)
+	case "poem", "stanza":
+		h.container("div", e, e.Tag, level)
+	case "empty-line":
+		h.sb.WriteString("<hr/>\n")
+	case "image":
+		h.open("div", e, "image")
+		h.img(e)
+		h.sb.WriteString("</div>\n")
+	case "table":
+		h.open("table", e, "")
+		h.sb.WriteString("\n")
+		for _, tr := range e.SelectElements("tr") {
+			h.sb.WriteString("<tr>")
+			for _, c := range tr.ChildElements() {
+				h.sb.WriteString("<" + c.Tag)
+				for _, a := range []string{"colspan", "rowspan", "align", "valign"} {
+					if v := c.SelectAttrValue(a, ""); v != "" {
+						h.sb.WriteString(" " + a + `="` + html.EscapeString(v) + `"`)
+					}
+				}
+				h.sb.WriteString(">")
+				h.inline(c)
+				h.sb.WriteString("</" + c.Tag + ">")
+			}
+			h.sb.WriteString("</tr>\n")
+		}
+		h.sb.WriteString("</table>\n")
+	default:
+		for _, c := range e.ChildElements() {
+			h.block(c, level)
+		}
+	}
+}
+
+// htmlHead returns HTML head content with book title and authors
+func (d *fb2) htmlHead() string {
+	ti := &d.data.Description.TitleInfo
+	var sb strings.Builder
+	sb.WriteString("<title>" + html.EscapeString(ti.BookTitle) + "</title>\n")
+	for i := range ti.Author {
+		if name := ti.Author[i].Format(AuthorFormatNickname); name != "" {
+			sb.WriteString(`<meta name="author" content="` + html.EscapeString(name) + `"/>` + "\n")
+		}
+	}
+	if ti.Keywords != "" {
+		sb.WriteString(`<meta name="keywords" content="` + html.EscapeString(ti.Keywords) + `"/>` + "\n")
+	}
+	return sb.String()
+}
+
+// WriteHTML writes book as single HTML document. Sections become
+// headings, images are embedded as data URIs, notes follow
+// the main text.
+func (d *fb2) WriteHTML(w io.Writer) error {
+	d.Lock()
+	defer d.Unlock()
+	body, bodies := d.exportBodies()
+	h := &htmlWriter{
+		image: func(href string) string {
+			if b, ok := d.binary(href); ok {
+				return dataURI(b)
+			}
+			return ""
+		},
+	}
+	h.sb.WriteString("<!DOCTYPE html>\n")
+	h.sb.WriteString(`<html lang="` + html.EscapeString(d.data.Description.TitleInfo.Lang) + `">` + "\n")
+	h.sb.WriteString("<head>\n<meta charset=\"utf-8\"/>\n" + d.htmlHead() + "</head>\n<body>\n")
+	h.block(body, 1)
+	for _, b := range bodies {
+		h.block(b, 1)
+	}
+	h.sb.WriteString("</body>\n</html>\n")
+	if _, err := io.WriteString(w, h.sb.String()); err != nil {
+		return fmt.Errorf("write html error: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package fb2
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWriteHTML(t *testing.T) {
+	book, err := ReadFB2(strings.NewReader(splitSrc))
+	if err != nil {
+		t.Fatalf("ReadFB2() error = %v", err)
+	}
+	var sb strings.Builder
+	if err := book.WriteHTML(&sb); err != nil {
+		t.Fatalf("WriteHTML() error = %v", err)
+	}
+	for _, want := range []string{
+		"<html lang=\"en\">\n<head>\n<meta charset=\"utf-8\"/>\n<title>Big Book</title>\n<meta name=\"author\" content=\"Ann Writer\"/>\n</head>",
+		"<h1>Big Book</h1>\n<blockquote class=\"epigraph\">\n<p>Epigraph</p>\n</blockquote>\n",
+		"<section id=\"p1\">\n<h2>Part One</h2>\n<p>Short<a href=\"#n1\" class=\"note\">[1]</a></p>\n</section>\n",
+		"<div class=\"image\"><img src=\"data:image/png;base64,BBBB\" alt=\"\"/></div>\n",
+		"<h1>Notes</h1>\n<section id=\"n1\">\n<h2>1</h2>\n",
+	} {
+		if !strings.Contains(sb.String(), want) {
+			t.Errorf("WriteHTML() = %q, want to contain %q", sb.String(), want)
+		}
+	}
+	// written document converts back
+	back, err := ReadHTML(strings.NewReader(sb.String()))
+	if err != nil {
+		t.Fatalf("ReadHTML() error = %v", err)
+	}
+	if back.Title() != "Big Book" {
+		t.Errorf("ReadHTML() title = %q, want %q", back.Title(), "Big Book")
+	}
+}
@@ -0,0 +1,299 @@
+package fb2
+
+import (
+	"bufio"
+	"embed"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"unicode"
+
+	etree "github.com/rupor-github/fb2converter/etree"
+)
+
+// ErrHyphenationLang is returned when book language has no hyphenation patterns
+var ErrHyphenationLang = errors.New("unsupported hyphenation language")
+
+const softHyphen = '\u00ad'
+
+//go:embed hyphenation/*.pat
+var hyphenationPatterns embed.FS
+
+// hyphenMins are minimal numbers of letters before and after hyphen
+// by language
+var hyphenMins = map[string][2]int{
+	"en": {2, 3},
+	"ru": {2, 2},
+	"uk": {2, 2},
+	"de": {2, 2},
+}
+
+// hyphenator hyphenates words with Liang patterns
+type hyphenator struct {
+	// patterns are pattern values by pattern letters
+	patterns map[string][]byte
+	maxLen   int
+	// exceptions are hyphen positions of exception words
+	exceptions map[string][]int
+	left       int
+	right      int
+}
+
+var (
+	hyphenatorsMu sync.Mutex
+	hyphenators   = map[string]*hyphenator{}
+)
+
+// baseLang returns lowercase language code without region
+func baseLang(lang string) string {
+	lang = strings.ToLower(lang)
+	if i := strings.IndexAny(lang, "-_"); i >= 0 {
+		lang = lang[:i]
+	}
+	return lang
+}
+
+// getHyphenator returns hyphenator for lang loading its patterns
+// on first use, nil if lang isn't supported
+func getHyphenator(lang string) *hyphenator {
+	lang = baseLang(lang)
+	mins, ok := hyphenMins[lang]
+	if !ok {
+		return nil
+	}
+	hyphenatorsMu.Lock()
+	defer hyphenatorsMu.Unlock()
+	if h := hyphenators[lang]; h != nil {
+		return h
+	}
+	f, err := hyphenationPatterns.Open("hyphenation/" + lang + ".pat")
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	h := &hyphenator{
+		patterns:   map[string][]byte{},
+		exceptions: map[string][]int{},
+		left:       mins[0],
+		right:      mins[1],
+	}
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		switch {
+		case line == "" || line[0] == '%':
+		case strings.ContainsAny(line, "0123456789"):
+			h.addPattern(line)
+		default:
+			h.addException(line)
+		}
+	}
+	hyphenators[lang] = h
+	return h
+}
+
+// addPattern parses pattern like .ach4 into letters and values
+func (h *hyphenator) addPattern(p string) {
+	letters := []rune{}
+	values := []byte{0}
+	for _, r := range p {
+		if r >= '0' && r <= '9' {
+			values[len(values)-1] = byte(r - '0')
+			continue
+		}
+		letters = append(letters, r)
+		values = append(values, 0)
+	}
+	h.patterns[string(letters)] = values
+	if len(letters) > h.maxLen {
+		h.maxLen = len(letters)
+	}
+}
+
+// addException parses exception word like ac-cu-sa-tive
+func (h *hyphenator) addException(w string) {
+	pos := []int{}
+	n := 0
+	for _, r := range w {
+		if r == '-' {
+			pos = append(pos, n)
+			continue
+		}
+		n++
+	}
+	h.exceptions[strings.ReplaceAll(w, "-", "")] = pos
+}
+
+// lookupRune returns rune as used in patterns
+func lookupRune(r rune) rune {
+	r = unicode.ToLower(r)
+	if r == 'ё' {
+		return 'е'
+	}
+	return r
+}
+
+// breaks returns positions of word runes before which hyphen may be inserted
+func (h *hyphenator) breaks(word []rune) []int {
+	if len(word) < h.left+h.right {
+		return nil
+	}
+	w := make([]rune, 0, len(word)+2)
+	w = append(w, '.')
+	for _, r := range word {
+		w = append(w, lookupRune(r))
+	}
+	w = append(w, '.')
+	if pos, ok := h.exceptions[string(w[1:len(w)-1])]; ok {
+		return pos
+	}
+	points := make([]byte, len(w)+1)
+	for i := range w {
+		for j := i + 1; j <= len(w) && j-i <= h.maxLen; j++ {
+			values, ok := h.patterns[string(w[i:j])]
+			if !ok {
+				continue
+			}
+			for k, v := range values {
+				if v > points[i+k] {
+					points[i+k] = v
+				}
+			}
+		}
+	}
+	res := []int{}
+	for i := h.left; i <= len(word)-h.right; i++ {
+		// points are shifted by leading dot
+		if points[i+1]%2 == 1 {
+			res = append(res, i)
+		}
+	}
+	return res
+}
+
+// isApostrophe reports whether r joins word parts
+func isApostrophe(r rune) bool {
+	return r == '\'' || r == '’'
+}
+
+// hyphenateRunes returns positions of text runes before which soft hyphens
+// are inserted. Words containing soft hyphens and all caps words
+// are not hyphenated.
+func (h *hyphenator) hyphenateRunes(text []rune) []int {
+	res := []int{}
+	for i := 0; i < len(text); {
+		if !unicode.IsLetter(text[i]) {
+			i++
+			continue
+		}
+		start := i
+		skip := false
+		upper := true
+		for ; i < len(text); i++ {
+			r := text[i]
+			if r == softHyphen {
+				skip = true
+				continue
+			}
+			if isApostrophe(r) && i+1 < len(text) && unicode.IsLetter(text[i+1]) {
+				continue
+			}
+			if !unicode.IsLetter(r) {
+				break
+			}
+			upper = upper && unicode.IsUpper(r)
+		}
+		word := text[start:i]
+		if skip || upper && len(word) > 1 {
+			continue
+		}
+		for _, p := range h.breaks(word) {
+			res = append(res, start+p)
+		}
+	}
+	return res
+}
+
+// hyphenatePieces inserts soft hyphens into text pieces. Pieces are
+// joined, so words split by inline markup are hyphenated as a whole.
+func (h *hyphenator) hyphenatePieces(pieces []textPiece) {
+	type pos struct{ piece, off int }
+	text := []rune{}
+	at := []pos{}
+	runes := make([][]rune, len(pieces))
+	for i, p := range pieces {
+		runes[i] = []rune(*p.s)
+		for j, r := range runes[i] {
+			if p.code {
+				// code separates words
+				r = ' '
+			}
+			text = append(text, r)
+			at = append(at, pos{i, j})
+		}
+	}
+	breaks := map[int][]int{}
+	for _, b := range h.hyphenateRunes(text) {
+		breaks[at[b].piece] = append(breaks[at[b].piece], at[b].off)
+	}
+	for i, offs := range breaks {
+		var sb strings.Builder
+		prev := 0
+		for _, off := range offs {
+			sb.WriteString(string(runes[i][prev:off]))
+			sb.WriteRune(softHyphen)
+			prev = off
+		}
+		sb.WriteString(string(runes[i][prev:]))
+		*pieces[i].s = sb.String()
+	}
+}
+
+// HyphenateText inserts soft hyphens into words of s using
+// hyphenation patterns of language lang. Supported languages are
+// ru, uk, en and de, s is returned unchanged for others.
+func HyphenateText(s, lang string) string {
+	h := getHyphenator(lang)
+	if h == nil {
+		return s
+	}
+	h.hyphenatePieces([]textPiece{{&s, false}})
+	return s
+}
+
+// hyphenateElement inserts soft hyphens into paragraphs of e. Element
+// lang attribute overrides lang. Titles, subtitles, links and code are skipped.
+func hyphenateElement(e *etree.Element, lang string) {
+	if l := e.SelectAttrValue("lang", ""); l != "" {
+		lang = l
+	}
+	if hasTag(e, "title", "subtitle") {
+		return
+	}
+	if hasTag(e, inlineTags...) {
+		if h := getHyphenator(lang); h != nil {
+			h.hyphenatePieces(textPieces(e, false, "code", "a"))
+		}
+		return
+	}
+	for _, c := range e.ChildElements() {
+		hyphenateElement(c, lang)
+	}
+}
+
+// Hyphenate inserts soft hyphens into book bodies for readers without
+// hyphenation support. Book language must be ru, uk, en or de.
+func (d *fb2) Hyphenate() error {
+	d.Lock()
+	defer d.Unlock()
+	lang := d.data.Description.TitleInfo.Lang
+	if getHyphenator(lang) == nil {
+		return fmt.Errorf("hyphenate %q error: %w", lang, ErrHyphenationLang)
+	}
+	hyphenateElement(d.body, lang)
+	for _, b := range d.bodies {
+		hyphenateElement(b, lang)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package fb2
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestHyphenateText(t *testing.T) {
+	tests := []struct {
+		name string
+		lang string
+		in   string
+		want string
+	}{
+		{"en", "en-US", "hyphenation table", "hy-phen-a-tion ta-ble"},
+		{"en exception", "en", "present", "present"},
+		{"ru", "ru", "Пример переноса, ёлочка", "При-мер пе-ре-но-са, ёлоч-ка"},
+		{"uk apostrophe", "uk", "м'ясо", "м'я-со"},
+		{"de", "de", "Silbentrennung", "Sil-ben-tren-nung"},
+		{"all caps", "en", "NASA", "NASA"},
+		{"short", "ru", "мир", "мир"},
+		{"unknown lang", "xx", "hyphenation", "hyphenation"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := strings.ReplaceAll(HyphenateText(tt.in, tt.lang), "\u00ad", "-")
+			if got != tt.want {
+				t.Errorf("HyphenateText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+	if got := HyphenateText("hy\u00adphenation", "en"); got != "hy\u00adphenation" {
+		t.Errorf("HyphenateText() hyphenated word twice: %q", got)
+	}
+}
+
+func Test_fb2_Hyphenate(t *testing.T) {
+	d := NewFB2("Test")
+	d.SetLang("en")
+	d.AddSectionBlocks("Hyphenation",
+		P(Text("hyphe"), Emphasis(Text("nation")), Text(" "), Code(Text("computer"))),
+		P(Link("https://example.com", Text("computer")), Text(" table")),
+	)
+	if err := d.AddSection(`<p xml:lang="ru">Пример</p>`, "Ru"); err != nil {
+		t.Fatalf("fb2.AddSection() error = %v", err)
+	}
+	if err := d.Hyphenate(); err != nil {
+		t.Fatalf("fb2.Hyphenate() error = %v", err)
+	}
+	out, err := d.WriteToString()
+	if err != nil {
+		t.Fatalf("fb2.WriteToString() error = %v", err)
+	}
+	out = strings.ReplaceAll(out, "\u00ad", "-")
+	for _, s := range []string{
+		"<title>\n<p>Hyphenation</p>",
+		"<p>hy-phe<emphasis>n-a-tion</emphasis> <code>computer</code></p>",
+		">computer</a> ta-ble</p>",
+		`<p xml:lang="ru">При-мер</p>`,
+	} {
+		if !strings.Contains(out, s) {
+			t.Errorf("fb2.WriteToString() doesn't contain %q:\n%s", s, out)
+		}
+	}
+
+	d = NewFB2("Test")
+	d.SetLang("fr")
+	if err := d.Hyphenate(); !errors.Is(err, ErrHyphenationLang) {
+		t.Errorf("fb2.Hyphenate() error = %v, want %v", err, ErrHyphenationLang)
+	}
+}
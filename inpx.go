@@ -0,0 +1,479 @@
+package fb2
+
+import (
+	"archive/zip"
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidInpx is returned for INPX archives without records
+// or with malformed records
+var ErrInvalidInpx = errors.New("invalid inpx")
+
+// inpxFields are fields of written INPX records
+var inpxFields = []string{
+	"AUTHOR", "GENRE", "TITLE", "SERIES", "SERNO", "FILE", "SIZE",
+	"LIBID", "DEL", "EXT", "DATE", "FOLDER", "LANG", "KEYWORDS",
+}
+
+// inpxDefaultFields are fields of INPX records without structure.info
+var inpxDefaultFields = []string{
+	"AUTHOR", "GENRE", "TITLE", "SERIES", "SERNO", "FILE", "SIZE",
+	"LIBID", "DEL", "EXT", "DATE", "LANG", "LIBRATE", "KEYWORDS",
+}
+
+// inpxSeparator separates fields of INPX record
+const inpxSeparator = "\x04"
+
+// InpxRecord is book record of INPX catalogue
+type InpxRecord struct {
+	Authors []AuthorType
+	Genres  []string
+	Title   string
+	Series  string
+	// SeriesNumber is number of book in series, empty if unknown
+	SeriesNumber string
+	// File is book file name in archive without extension
+	File string
+	// Size is book file size in bytes
+	Size int64
+	// LibID is book id in library
+	LibID   string
+	Deleted bool
+	// Ext is book file extension without dot
+	Ext string
+	// Date is date book was added in YYYY-MM-DD form
+	Date     string
+	Lang     string
+	Keywords string
+	// Folder is path of zip archive containing book or of directory
+	// with book file, relative to library root and slash separated.
+	// It's empty for book files in library root.
+	Folder string
+}
+
+// FileName returns book file name, in archive or in directory
+func (rec *InpxRecord) FileName() string {
+	if rec.Ext == "" {
+		return rec.File
+	}
+	return rec.File + "." + rec.Ext
+}
+
+// InArchive reports whether book is in zip archive
+func (rec *InpxRecord) InArchive() bool {
+	return strings.EqualFold(path.Ext(rec.Folder), ".zip")
+}
+
+// Path returns path of book in library root. For books in archives
+// it's path of archive, book is its FileName() entry.
+func (rec *InpxRecord) Path(root string) string {
+	if rec.InArchive() {
+		return filepath.Join(root, filepath.FromSlash(rec.Folder))
+	}
+	return filepath.Join(root, filepath.FromSlash(rec.Folder), filepath.FromSlash(rec.FileName()))
+}
+
+// Inpx is INPX catalogue of library
+type Inpx struct {
+	// Collection is collection description from collection.info
+	Collection string
+	// Version is catalogue version from version.info, usually date
+	// in YYYYMMDD form
+	Version string
+	Records []InpxRecord
+}
+
+// inpxRecord returns INPX record of book description
+func inpxRecord(desc *FictionBookDescription) InpxRecord {
+	ti := &desc.TitleInfo
+	rec := InpxRecord{
+		Authors:  ti.Author,
+		Title:    ti.BookTitle,
+		Lang:     ti.Lang,
+		Keywords: ti.Keywords,
+		Ext:      "fb2",
+	}
+	for _, g := range ti.Genre {
+		rec.Genres = append(rec.Genres, strings.TrimSpace(g.Text))
+	}
+	if len(ti.Sequence) != 0 {
+		rec.Series = ti.Sequence[0].Name
+		rec.SeriesNumber = ti.Sequence[0].Number
+	}
+	return rec
+}
+
+// bookRecord returns INPX record of book file name read from r
+func bookRecord(r io.Reader, name string, size int64, modified time.Time) (InpxRecord, error) {
+	m, err := ReadMetadata(r, false)
+	if err != nil {
+		return InpxRecord{}, err
+	}
+	rec := inpxRecord(&m.Description)
+	rec.File = strings.TrimSuffix(name, filepath.Ext(name))
+	if rec.File != "" && allDigits(rec.File) {
+		rec.LibID = rec.File
+	}
+	rec.Size = size
+	rec.Date = modified.Format("2006-01-02")
+	return rec, nil
+}
+
+// indexArchive adds records of fb2 books in zip archive
+func (x *Inpx) indexArchive(path, folder string) []error {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return []error{fmt.Errorf("%s: %w", path, err)}
+	}
+	defer zr.Close()
+	errs := []error{}
+	for _, f := range zr.File {
+		if !strings.EqualFold(filepath.Ext(f.Name), ".fb2") {
+			continue
+		}
+		r, err := f.Open()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s/%s: %w", path, f.Name, err))
+			continue
+		}
+		rec, err := bookRecord(r, f.Name, int64(f.UncompressedSize64), f.Modified)
+		r.Close()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s/%s: %w", path, f.Name, err))
+			continue
+		}
+		rec.Folder = folder
+		x.Records = append(x.Records, rec)
+	}
+	return errs
+}
+
+// indexFile adds record of fb2 book file
+func (x *Inpx) indexFile(path, folder string, info os.FileInfo) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	rec, err := bookRecord(f, filepath.Base(path), info.Size(), info.ModTime())
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	rec.Folder = folder
+	x.Records = append(x.Records, rec)
+	return nil
+}
+
+// IndexLibrary builds INPX catalogue of books under root. Zip archives
+// are indexed as collections of fb2 books, fb2 files outside of archives
+// are indexed by directory. Record folders are archive or directory paths
+// relative to root. Only book descriptions are read. It returns
+// errors of books and archives that can't be read, other books are
+// indexed anyway.
+func IndexLibrary(root string) (*Inpx, []error) {
+	x := &Inpx{
+		Collection: filepath.Base(filepath.Clean(root)),
+		Version:    time.Now().Format("20060102"),
+	}
+	errs := []error{}
+	err := filepath.Walk(root, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			errs = append(errs, err)
+			return nil
+		}
+		if info.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		switch strings.ToLower(filepath.Ext(p)) {
+		case ".zip":
+			errs = append(errs, x.indexArchive(p, rel)...)
+		case ".fb2":
+			folder := path.Dir(rel)
+			if folder == "." {
+				folder = ""
+			}
+			if err := x.indexFile(p, folder, info); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		errs = append(errs, err)
+	}
+	return x, errs
+}
+
+// inpName returns name of inp file of folder. Names of archives in
+// library root are kept, so readers without FOLDER support find them.
+func inpName(folder string) string {
+	name := strings.ReplaceAll(folder, "/", "_")
+	if strings.EqualFold(path.Ext(name), ".zip") {
+		name = name[:len(name)-len(".zip")]
+	}
+	if name == "" {
+		name = "_"
+	}
+	return name + ".inp"
+}
+
+// uniqueInpName returns inp file name not in used, numbering name
+// if needed. Names are compared case-insensitively, as archive
+// may be unpacked on case-insensitive file system.
+func uniqueInpName(name string, used map[string]bool) string {
+	base := strings.TrimSuffix(name, ".inp")
+	res := name
+	for n := 2; used[strings.ToLower(res)]; n++ {
+		res = base + "-" + strconv.Itoa(n) + ".inp"
+	}
+	used[strings.ToLower(res)] = true
+	return res
+}
+
+// inpxClean removes characters breaking INPX record from s
+func inpxClean(s string) string {
+	return strings.NewReplacer(inpxSeparator, " ", "\r", " ", "\n", " ").Replace(strings.TrimSpace(s))
+}
+
+// inpxLine returns INPX record line with written fields
+func inpxLine(rec *InpxRecord) string {
+	var authors strings.Builder
+	for _, a := range rec.Authors {
+		last := a.LastName
+		if last == "" {
+			last = a.Nickname
+		}
+		authors.WriteString(inpxClean(last) + "," + inpxClean(a.FirstName) + "," + inpxClean(a.MiddleName) + ":")
+	}
+	var genres strings.Builder
+	for _, g := range rec.Genres {
+		genres.WriteString(inpxClean(g) + ":")
+	}
+	del := ""
+	if rec.Deleted {
+		del = "1"
+	}
+	fields := []string{
+		authors.String(), genres.String(), inpxClean(rec.Title), inpxClean(rec.Series),
+		inpxClean(rec.SeriesNumber), inpxClean(rec.File), strconv.FormatInt(rec.Size, 10),
+		inpxClean(rec.LibID), del, inpxClean(rec.Ext), inpxClean(rec.Date),
+		inpxClean(rec.Folder), inpxClean(rec.Lang), inpxClean(rec.Keywords),
+	}
+	return strings.Join(fields, inpxSeparator) + inpxSeparator + "\r\n"
+}
+
+// Write writes catalogue as INPX archive with inp file per record folder
+func (x *Inpx) Write(w io.Writer) error {
+	zw := zip.NewWriter(w)
+	write := func(name, content string) error {
+		f, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: time.Now()})
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(f, content)
+		return err
+	}
+	files := map[string]*strings.Builder{}
+	// folders with the same inp name get numbered ones
+	folders := map[string]string{}
+	used := map[string]bool{}
+	names := []string{}
+	for i := range x.Records {
+		folder := x.Records[i].Folder
+		name, ok := folders[folder]
+		if !ok {
+			name = uniqueInpName(inpName(folder), used)
+			folders[folder] = name
+			files[name] = &strings.Builder{}
+			names = append(names, name)
+		}
+		files[name].WriteString(inpxLine(&x.Records[i]))
+	}
+	sort.Strings(names)
+	info := []struct{ name, content string }{
+		{"collection.info", x.Collection + "\r\n"},
+		{"version.info", x.Version + "\r\n"},
+		{"structure.info", strings.Join(inpxFields, ";") + ";\r\n"},
+	}
+	for _, f := range info {
+		if err := write(f.name, f.content); err != nil {
+			return fmt.Errorf("write inpx error: %w", err)
+		}
+	}
+	for _, name := range names {
+		if err := write(name, files[name].String()); err != nil {
+			return fmt.Errorf("write inpx error: %w", err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("write inpx error: %w", err)
+	}
+	return nil
+}
+
+// parseInpxLine parses INPX record line with fields
+func parseInpxLine(line string, fields []string) InpxRecord {
+	rec := InpxRecord{}
+	values := strings.Split(line, inpxSeparator)
+	for i, field := range fields {
+		if i >= len(values) {
+			break
+		}
+		v := strings.TrimSpace(values[i])
+		switch field {
+		case "AUTHOR":
+			for _, a := range strings.Split(v, ":") {
+				if a == "" {
+					continue
+				}
+				parts := append(strings.Split(a, ","), "", "")
+				rec.Authors = append(rec.Authors, AuthorType{LastName: parts[0], FirstName: parts[1], MiddleName: parts[2]})
+			}
+		case "GENRE":
+			for _, g := range strings.Split(v, ":") {
+				if g != "" {
+					rec.Genres = append(rec.Genres, g)
+				}
+			}
+		case "TITLE":
+			rec.Title = v
+		case "SERIES":
+			rec.Series = v
+		case "SERNO":
+			rec.SeriesNumber = v
+		case "FILE":
+			rec.File = v
+		case "SIZE":
+			rec.Size, _ = strconv.ParseInt(v, 10, 64)
+		case "LIBID":
+			rec.LibID = v
+		case "DEL":
+			rec.Deleted = v == "1"
+		case "EXT":
+			rec.Ext = v
+		case "DATE":
+			rec.Date = v
+		case "FOLDER":
+			rec.Folder = v
+		case "LANG":
+			rec.Lang = v
+		case "KEYWORDS":
+			rec.Keywords = v
+		}
+	}
+	return rec
+}
+
+// readZipText returns text of zip file
+func readZipText(f *zip.File) (string, error) {
+	r, err := f.Open()
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+	return decodeText(b), nil
+}
+
+// ReadInpx reads INPX catalogue. Record fields are taken from
+// structure.info if it's present. Records without FOLDER field
+// are in archive named like their inp file.
+func ReadInpx(r io.ReaderAt, size int64) (*Inpx, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("read inpx error: %w", err)
+	}
+	x := &Inpx{}
+	fields := inpxDefaultFields
+	hasFolder := false
+	inps := []*zip.File{}
+	for _, f := range zr.File {
+		switch name := strings.ToLower(f.Name); {
+		case name == "collection.info" || name == "version.info" || name == "structure.info":
+			text, err := readZipText(f)
+			if err != nil {
+				return nil, fmt.Errorf("read inpx error: %w", err)
+			}
+			line := strings.TrimSpace(strings.SplitN(text, "\n", 2)[0])
+			switch name {
+			case "collection.info":
+				x.Collection = line
+			case "version.info":
+				x.Version = line
+			default:
+				fields = strings.Split(strings.TrimSuffix(strings.ToUpper(line), ";"), ";")
+				for _, f := range fields {
+					hasFolder = hasFolder || f == "FOLDER"
+				}
+			}
+		case strings.HasSuffix(name, ".inp"):
+			inps = append(inps, f)
+		}
+	}
+	if len(inps) == 0 {
+		return nil, fmt.Errorf("read inpx error: %w: no inp files", ErrInvalidInpx)
+	}
+	for _, f := range inps {
+		archive := strings.TrimSuffix(f.Name, path.Ext(f.Name)) + ".zip"
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("read inpx error: %w", err)
+		}
+		s := bufio.NewScanner(rc)
+		s.Buffer(nil, 1<<20)
+		for s.Scan() {
+			line := strings.TrimRight(s.Text(), "\r")
+			if line == "" {
+				continue
+			}
+			if !strings.Contains(line, inpxSeparator) {
+				rc.Close()
+				return nil, fmt.Errorf("read inpx error: %w: %s: record without fields", ErrInvalidInpx, f.Name)
+			}
+			rec := parseInpxLine(line, fields)
+			if rec.Folder == "" && !hasFolder {
+				rec.Folder = archive
+			}
+			x.Records = append(x.Records, rec)
+		}
+		err = s.Err()
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("read inpx error: %w", err)
+		}
+	}
+	return x, nil
+}
+
+// OpenInpx reads INPX catalogue file like ReadInpx
+func OpenInpx(srcFilePath string) (*Inpx, error) {
+	f, err := os.Open(srcFilePath)
+	if err != nil {
+		return nil, fmt.Errorf("open inpx error: %w", err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("open inpx error: %w", err)
+	}
+	return ReadInpx(f, info.Size())
+}
@@ -0,0 +1,274 @@
+package fb2
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestIndexLibrary(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "lib")
+	for _, dir := range []string{"loose", "sub/dir"} {
+		if err := os.MkdirAll(filepath.Join(root, filepath.FromSlash(dir)), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	series := strings.Replace(splitSrc, "<lang>en</lang>",
+		"<keywords>big, book</keywords><lang>en</lang><sequence name=\"Books\" number=\"2\"/>", 1)
+	var archive bytes.Buffer
+	zw := zip.NewWriter(&archive)
+	for name, src := range map[string]string{"101.fb2": series, "bad.fb2": "<FictionBook>", "readme.txt": "text"} {
+		f, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Write([]byte(src))
+	}
+	zw.Close()
+	var nested bytes.Buffer
+	if err := ZipFB2(&nested, "202.fb2", strings.NewReader(splitSrc)); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string][]byte{
+		"books.zip":       archive.Bytes(),
+		"sub/dir/ab.zip":  nested.Bytes(),
+		"loose/book.fb2":  []byte(splitSrc),
+		"loose/notes.txt": []byte("text"),
+		"top.fb2":         []byte(splitSrc),
+	}
+	for name, data := range files {
+		if err := ioutil.WriteFile(filepath.Join(root, filepath.FromSlash(name)), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	x, errs := IndexLibrary(root)
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "bad.fb2") {
+		t.Errorf("IndexLibrary() errors = %v, want bad.fb2 error", errs)
+	}
+	if x.Collection != "lib" || len(x.Records) != 4 {
+		t.Fatalf("IndexLibrary() = %+v", x)
+	}
+	recs := map[string]InpxRecord{}
+	for _, rec := range x.Records {
+		recs[rec.File] = rec
+	}
+	rec := recs["101"]
+	if rec.Folder != "books.zip" || rec.LibID != "101" || rec.Size != int64(len(series)) ||
+		rec.Series != "Books" || rec.SeriesNumber != "2" || rec.Keywords != "big, book" {
+		t.Errorf("IndexLibrary() archived record = %+v", rec)
+	}
+	rec = recs["book"]
+	if rec.Folder != "loose" || rec.LibID != "" || rec.Title != "Big Book" || rec.Ext != "fb2" ||
+		!reflect.DeepEqual(rec.Genres, []string{"prose"}) || len(rec.Authors) != 1 || rec.Authors[0].LastName != "Writer" {
+		t.Errorf("IndexLibrary() loose record = %+v", rec)
+	}
+	if rec := recs["202"]; rec.Folder != "sub/dir/ab.zip" {
+		t.Errorf("IndexLibrary() nested archive record = %+v", rec)
+	}
+	if rec := recs["top"]; rec.Folder != "" {
+		t.Errorf("IndexLibrary() root record = %+v", rec)
+	}
+
+	// records read back resolve to book files
+	var buf bytes.Buffer
+	if err := x.Write(&buf); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	got, err := ReadInpx(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("ReadInpx() error = %v", err)
+	}
+	if len(got.Records) != len(x.Records) {
+		t.Fatalf("ReadInpx() = %d records, want %d", len(got.Records), len(x.Records))
+	}
+	for _, rec := range got.Records {
+		p := rec.Path(root)
+		if !rec.InArchive() {
+			if m, err := OpenMetadata(p, false); err != nil || m.Description.TitleInfo.BookTitle != rec.Title {
+				t.Errorf("record %s/%s: OpenMetadata(%s) = %v", rec.Folder, rec.File, p, err)
+			}
+			continue
+		}
+		zr, err := zip.OpenReader(p)
+		if err != nil {
+			t.Errorf("record %s/%s: %v", rec.Folder, rec.File, err)
+			continue
+		}
+		found := false
+		for _, f := range zr.File {
+			found = found || f.Name == rec.FileName()
+		}
+		zr.Close()
+		if !found {
+			t.Errorf("record %s/%s: no %s in %s", rec.Folder, rec.File, rec.FileName(), p)
+		}
+	}
+}
+
+func TestInpx_Write(t *testing.T) {
+	x := &Inpx{
+		Collection: "Library",
+		Version:    "20260101",
+		Records: []InpxRecord{
+			{
+				Authors:      []AuthorType{{FirstName: "Ann", MiddleName: "B", LastName: "Writer"}, {Nickname: "nick"}},
+				Genres:       []string{"prose", "sf"},
+				Title:        "Big\x04Book",
+				Series:       "Books",
+				SeriesNumber: "2",
+				File:         "101",
+				Size:         1234,
+				LibID:        "101",
+				Deleted:      true,
+				Ext:          "fb2",
+				Date:         "2026-01-01",
+				Lang:         "en",
+				Keywords:     "big, book",
+				Folder:       "books.zip",
+			},
+			{Title: "Other", File: "other", Ext: "fb2", Folder: "more/other.zip"},
+			{Title: "Loose", File: "loose", Ext: "fb2"},
+		},
+	}
+	var buf bytes.Buffer
+	if err := x.Write(&buf); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := []string{}
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+	}
+	want := []string{"collection.info", "version.info", "structure.info", "_.inp", "books.inp", "more_other.inp"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("Write() files = %v, want %v", names, want)
+	}
+	inp, _ := readZipText(zr.File[4])
+	line := "Writer,Ann,B:nick,,:\x04prose:sf:\x04Big Book\x04Books\x042\x04101\x041234\x04101\x041\x04fb2\x042026-01-01\x04books.zip\x04en\x04big, book\x04\r\n"
+	if inp != line {
+		t.Errorf("Write() books.inp = %q, want %q", inp, line)
+	}
+
+	got, err := ReadInpx(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("ReadInpx() error = %v", err)
+	}
+	x.Records[0].Title = "Big Book"
+	x.Records[0].Authors[1] = AuthorType{LastName: "nick"}
+	// records are read in inp file order
+	x.Records = []InpxRecord{x.Records[2], x.Records[0], x.Records[1]}
+	if !reflect.DeepEqual(got, x) {
+		t.Errorf("ReadInpx() = %+v, want %+v", got, x)
+	}
+
+	path := filepath.Join(t.TempDir(), "lib.inpx")
+	if err := ioutil.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := OpenInpx(path); err != nil || len(got.Records) != 3 {
+		t.Errorf("OpenInpx() = %+v, %v", got, err)
+	}
+}
+
+func TestInpx_WriteNameClash(t *testing.T) {
+	x := &Inpx{Records: []InpxRecord{
+		{Title: "One", File: "1", Ext: "fb2", Folder: "a/b.zip"},
+		{Title: "Two", File: "2", Ext: "fb2", Folder: "a_b.zip"},
+		{Title: "Three", File: "3", Ext: "fb2", Folder: "A_B.zip"},
+	}}
+	var buf bytes.Buffer
+	if err := x.Write(&buf); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := []string{}
+	for _, f := range zr.File[3:] {
+		names = append(names, f.Name)
+	}
+	if want := []string{"A_B-3.inp", "a_b-2.inp", "a_b.inp"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("Write() inp files = %v, want %v", names, want)
+	}
+	got, err := ReadInpx(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("ReadInpx() error = %v", err)
+	}
+	folders := map[string]string{}
+	for _, rec := range got.Records {
+		folders[rec.Title] = rec.Folder
+	}
+	if want := map[string]string{"One": "a/b.zip", "Two": "a_b.zip", "Three": "A_B.zip"}; !reflect.DeepEqual(folders, want) {
+		t.Errorf("ReadInpx() folders = %v, want %v", folders, want)
+	}
+}
+
+func TestReadInpx(t *testing.T) {
+	build := func(files map[string]string) []byte {
+		var buf bytes.Buffer
+		zw := zip.NewWriter(&buf)
+		for name, content := range files {
+			f, err := zw.Create(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			f.Write([]byte(content))
+		}
+		zw.Close()
+		return buf.Bytes()
+	}
+	tests := []struct {
+		name    string
+		data    []byte
+		want    []InpxRecord
+		wantErr error
+	}{
+		{
+			name: "default structure",
+			data: build(map[string]string{"a.inp": "Writer,Ann,:\x04prose:\x04Title\x04\x04\x0412\x04100\x0412\x04\x04fb2\x042020-01-02\x04ru\x045\x04kw\x04\r\n\r\n"}),
+			want: []InpxRecord{{
+				Authors: []AuthorType{{LastName: "Writer", FirstName: "Ann"}}, Genres: []string{"prose"},
+				Title: "Title", File: "12", Size: 100, LibID: "12", Ext: "fb2", Date: "2020-01-02",
+				Lang: "ru", Keywords: "kw", Folder: "a.zip",
+			}},
+		},
+		{
+			name: "custom structure",
+			data: build(map[string]string{
+				"structure.info": "TITLE;FILE;EXT;DEL\r\n",
+				"b.inp":          "Title\x04f\x04fb2\x041\r\n",
+			}),
+			want: []InpxRecord{{Title: "Title", File: "f", Ext: "fb2", Deleted: true, Folder: "b.zip"}},
+		},
+		{name: "no inp", data: build(map[string]string{"collection.info": "x"}), wantErr: ErrInvalidInpx},
+		{name: "bad record", data: build(map[string]string{"c.inp": "no fields\r\n"}), wantErr: ErrInvalidInpx},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReadInpx(bytes.NewReader(tt.data), int64(len(tt.data)))
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("ReadInpx() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ReadInpx() error = %v", err)
+			}
+			if !reflect.DeepEqual(got.Records, tt.want) {
+				t.Errorf("ReadInpx() records = %+v, want %+v", got.Records, tt.want)
+			}
+		})
+	}
+}
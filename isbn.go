@@ -0,0 +1,222 @@
+package fb2
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrISBNFormat is returned when ISBN has wrong length or symbols
+	ErrISBNFormat = errors.New("invalid isbn format")
+	// ErrISBNChecksum is returned when ISBN check digit doesn't match
+	ErrISBNChecksum = errors.New("invalid isbn checksum")
+)
+
+// isbnRange is a publisher range inside registration group.
+// from and to are 7-digit prefixes of the number part after the group,
+// length is the publisher element length for this range.
+type isbnRange struct {
+	from, to int
+	length   int
+}
+
+func newISBNRange(lo, hi string) isbnRange {
+	from, _ := strconv.Atoi((lo + "0000000")[:7])
+	to, _ := strconv.Atoi((hi + "9999999")[:7])
+	return isbnRange{from: from, to: to, length: len(lo)}
+}
+
+// isbnPublisherRanges contains publisher ranges for the most common
+// registration groups. ISBNs from other groups are hyphenated
+// only up to the registration group.
+var isbnPublisherRanges = map[string][]isbnRange{
+	"978-0": {
+		newISBNRange("00", "19"), newISBNRange("200", "699"),
+		newISBNRange("7000", "8499"), newISBNRange("85000", "89999"),
+		newISBNRange("900000", "949999"), newISBNRange("9500000", "9999999"),
+	},
+	"978-1": {
+		newISBNRange("00", "09"), newISBNRange("100", "399"),
+		newISBNRange("4000", "5499"), newISBNRange("55000", "86979"),
+		newISBNRange("869800", "998999"), newISBNRange("9990000", "9999999"),
+	},
+	"978-2": {
+		newISBNRange("00", "19"), newISBNRange("200", "349"),
+		newISBNRange("35000", "39999"), newISBNRange("400", "699"),
+		newISBNRange("7000", "8399"), newISBNRange("84000", "89999"),
+		newISBNRange("900000", "949999"), newISBNRange("9500000", "9999999"),
+	},
+	"978-3": {
+		newISBNRange("00", "02"), newISBNRange("030", "033"),
+		newISBNRange("0340", "0369"), newISBNRange("03700", "03999"),
+		newISBNRange("04", "19"), newISBNRange("200", "699"),
+		newISBNRange("7000", "8499"), newISBNRange("85000", "89999"),
+		newISBNRange("900000", "949999"), newISBNRange("9500000", "9539999"),
+		newISBNRange("95400", "96999"), newISBNRange("9700000", "9849999"),
+		newISBNRange("98500", "99999"),
+	},
+	"978-5": {
+		newISBNRange("00000", "00499"), newISBNRange("0050", "0099"),
+		newISBNRange("01", "19"), newISBNRange("200", "420"),
+		newISBNRange("4210", "4299"), newISBNRange("430", "430"),
+		newISBNRange("4310", "4399"), newISBNRange("440", "440"),
+		newISBNRange("4410", "4499"), newISBNRange("450", "603"),
+		newISBNRange("6040000", "6049999"), newISBNRange("605", "699"),
+		newISBNRange("7000", "8499"), newISBNRange("85000", "89999"),
+		newISBNRange("900000", "909999"), newISBNRange("91000", "91999"),
+		newISBNRange("9200", "9299"), newISBNRange("93000", "94999"),
+		newISBNRange("9500000", "9500999"), newISBNRange("9501", "9799"),
+		newISBNRange("98000", "98999"), newISBNRange("9900000", "9909999"),
+		newISBNRange("9910", "9999"),
+	},
+}
+
+// cleanISBN strips "ISBN" label, spaces and hyphens
+func cleanISBN(isbn string) string {
+	isbn = strings.ToUpper(strings.TrimSpace(isbn))
+	for _, p := range []string{"ISBN-13", "ISBN-10", "ISBN13", "ISBN10", "ISBN"} {
+		if strings.HasPrefix(isbn, p) {
+			isbn = strings.TrimLeft(isbn[len(p):], ": ")
+			break
+		}
+	}
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' || r == '‐' || r == '‑' {
+			return -1
+		}
+		return r
+	}, isbn)
+}
+
+func isbn10Check(digits string) byte {
+	sum := 0
+	for i := 0; i < 9; i++ {
+		sum += (10 - i) * int(digits[i]-'0')
+	}
+	c := (11 - sum%11) % 11
+	if c == 10 {
+		return 'X'
+	}
+	return byte('0' + c)
+}
+
+func isbn13Check(digits string) byte {
+	sum := 0
+	for i := 0; i < 12; i++ {
+		w := 1
+		if i%2 == 1 {
+			w = 3
+		}
+		sum += w * int(digits[i]-'0')
+	}
+	return byte('0' + (10-sum%10)%10)
+}
+
+func allDigits(s string) bool {
+	for i := range s {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// compactISBN validates ISBN-10 or ISBN-13 and returns it without separators
+func compactISBN(isbn string) (string, error) {
+	s := cleanISBN(isbn)
+	switch len(s) {
+	case 10:
+		if !allDigits(s[:9]) || !(allDigits(s[9:]) || s[9] == 'X') {
+			return "", ErrISBNFormat
+		}
+		if isbn10Check(s) != s[9] {
+			return "", ErrISBNChecksum
+		}
+	case 13:
+		if !allDigits(s) || !(strings.HasPrefix(s, "978") || strings.HasPrefix(s, "979")) {
+			return "", ErrISBNFormat
+		}
+		if isbn13Check(s) != s[12] {
+			return "", ErrISBNChecksum
+		}
+	default:
+		return "", ErrISBNFormat
+	}
+	return s, nil
+}
+
+// ValidateISBN checks ISBN-10 or ISBN-13 format and checksum
+func ValidateISBN(isbn string) error {
+	_, err := compactISBN(isbn)
+	return err
+}
+
+// ISBN10To13 converts ISBN-10 to compact ISBN-13
+func ISBN10To13(isbn string) (string, error) {
+	s, err := compactISBN(isbn)
+	if err != nil {
+		return "", err
+	}
+	if len(s) == 13 {
+		return s, nil
+	}
+	s = "978" + s[:9]
+	return s + string(isbn13Check(s)), nil
+}
+
+// NormalizeISBN validates isbn, converts it to ISBN-13 and hyphenates it
+func NormalizeISBN(isbn string) (string, error) {
+	s, err := ISBN10To13(isbn)
+	if err != nil {
+		return "", err
+	}
+	return hyphenateISBN13(s), nil
+}
+
+// isbnGroupLength returns registration group length for compact ISBN-13
+func isbnGroupLength(s string) int {
+	prefix, g := s[:3], s[3:]
+	if prefix == "979" {
+		if g[0] == '1' {
+			return 2
+		}
+		return 1
+	}
+	switch {
+	case g[0] <= '5' || g[0] == '7':
+		return 1
+	case g[0] == '6':
+		if g[:3] < "650" {
+			return 3
+		}
+		return 2
+	case g[0] == '8' || g[:2] < "95":
+		return 2
+	}
+	switch {
+	case g[:5] < "99000":
+		return 3
+	case g[:5] < "99900":
+		return 4
+	}
+	return 5
+}
+
+func hyphenateISBN13(s string) string {
+	gl := isbnGroupLength(s)
+	prefix, group, rest := s[:3], s[3:3+gl], s[3+gl:12]
+	ranges, ok := isbnPublisherRanges[prefix+"-"+group]
+	if !ok {
+		return fmt.Sprintf("%s-%s-%s-%c", prefix, group, rest, s[12])
+	}
+	n, _ := strconv.Atoi((rest + "0000000")[:7])
+	for _, r := range ranges {
+		if n >= r.from && n <= r.to && r.length < len(rest) {
+			return fmt.Sprintf("%s-%s-%s-%s-%c",
+				prefix, group, rest[:r.length], rest[r.length:], s[12])
+		}
+	}
+	return fmt.Sprintf("%s-%s-%s-%c", prefix, group, rest, s[12])
+}
@@ -0,0 +1,38 @@
+package fb2
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNormalizeISBN(t *testing.T) {
+	tests := []struct {
+		name    string
+		isbn    string
+		want    string
+		wantErr error
+	}{
+		{"ISBN-10 to 13", "0-306-40615-2", "978-0-306-40615-7", nil},
+		{"ISBN-13 compact", "9780306406157", "978-0-306-40615-7", nil},
+		{"ISBN-13 with label", "ISBN 978-3-16-148410-0", "978-3-16-148410-0", nil},
+		{"ISBN-10 with X", "ISBN-10: 0-8044-2957-X", "978-0-8044-2957-3", nil},
+		{"Russian group", "5-17-013452-5", "978-5-17-013452-6", nil},
+		{"Unknown group", "9786012345674", "978-601-234567-4", nil},
+		{"Bad checksum", "978-0-306-40615-8", "", ErrISBNChecksum},
+		{"Bad ISBN-10 checksum", "0-306-40615-3", "", ErrISBNChecksum},
+		{"Bad length", "978-0-306", "", ErrISBNFormat},
+		{"Bad prefix", "9770306406157", "", ErrISBNFormat},
+		{"Bad symbols", "0-306-4A615-2", "", ErrISBNFormat},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizeISBN(tt.isbn)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("NormalizeISBN() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("NormalizeISBN() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
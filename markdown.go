@@ -0,0 +1,417 @@
+package fb2
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	etree "github.com/rupor-github/fb2converter/etree"
+)
+
+var (
+	mdHeadingRe  = regexp.MustCompile(`^ {0,3}(#{1,6})(?:\s+(.*?))??(?:\s+#+)?\s*$`)
+	mdRuleRe     = regexp.MustCompile(`^ {0,3}(?:(?:\*\s*){3,}|(?:-\s*){3,}|(?:_\s*){3,})$`)
+	mdFenceRe    = regexp.MustCompile("^ {0,3}(`{3,}|~{3,})")
+	mdListRe     = regexp.MustCompile(`^\s*([-*+]|\d{1,9}[.)])\s+(.*)$`)
+	mdQuoteRe    = regexp.MustCompile(`^ {0,3}> ?(.*)$`)
+	mdTableSepRe = regexp.MustCompile(`^\s*\|?\s*:?-+:?\s*(?:\|\s*:?-+:?\s*)*\|?\s*$`)
+	mdEscapeRe   = regexp.MustCompile(`\\([!-/:-@\[-` + "`" + `{-~])`)
+	mdImageRe    = regexp.MustCompile(`!\[([^\]]*)\]\(([^)\s]+)(?:\s+&quot;[^)]*&quot;)?\)`)
+	mdLinkRe     = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)(?:\s+&quot;[^)]*&quot;)?\)`)
+	mdAutoLinkRe = regexp.MustCompile(`&lt;((?:https?|mailto):[^\s&]+)&gt;`)
+	mdStrongRe   = regexp.MustCompile(`\*\*([^\s*](?:.*?[^\s*])?)\*\*|__([^\s_](?:.*?[^\s_])?)__`)
+	mdEmRe       = regexp.MustCompile(`\*([^\s*](?:[^*]*[^\s*])?)\*`)
+	mdUnderEmRe  = regexp.MustCompile(`(^|[^\p{L}\p{N}_])_([^\s_](?:[^_]*[^\s_])?)_($|[^\p{L}\p{N}_])`)
+	mdDelRe      = regexp.MustCompile(`~~([^~]+)~~`)
+)
+
+// mdSpans converts markdown inline markup of text without code spans
+func mdSpans(s string) string {
+	s = html.EscapeString(s)
+	// escaped punctuation becomes character reference, so it isn't
+	// taken as markup
+	s = mdEscapeRe.ReplaceAllStringFunc(s, func(m string) string {
+		return "&#" + strconv.Itoa(int(m[1])) + ";"
+	})
+	s = mdImageRe.ReplaceAllString(s, `<img src="$2" alt="$1">`)
+	s = mdLinkRe.ReplaceAllString(s, `<a href="$2">$1</a>`)
+	s = mdAutoLinkRe.ReplaceAllString(s, `<a href="$1">$1</a>`)
+	s = mdStrongRe.ReplaceAllString(s, `<strong>$1$2</strong>`)
+	s = mdEmRe.ReplaceAllString(s, `<em>$1</em>`)
+	s = mdUnderEmRe.ReplaceAllString(s, `$1<em>$2</em>$3`)
+	s = mdDelRe.ReplaceAllString(s, `<del>$1</del>`)
+	return s
+}
+
+// mdInline converts markdown inline markup into HTML
+func mdInline(s string) string {
+	var sb strings.Builder
+	for {
+		i := strings.IndexByte(s, '`')
+		if i < 0 {
+			break
+		}
+		n := i
+		for n < len(s) && s[n] == '`' {
+			n++
+		}
+		fence := s[i:n]
+		j := strings.Index(s[n:], fence)
+		if j < 0 {
+			sb.WriteString(mdSpans(s[:n]))
+			s = s[n:]
+			continue
+		}
+		sb.WriteString(mdSpans(s[:i]))
+		sb.WriteString("<code>" + html.EscapeString(strings.TrimSpace(s[n:n+j])) + "</code>")
+		s = s[n+j+len(fence):]
+	}
+	sb.WriteString(mdSpans(s))
+	return sb.String()
+}
+
+// mdTableRow splits markdown table row into cells
+func mdTableRow(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	line = strings.TrimSuffix(line, "|")
+	cells := strings.Split(line, "|")
+	for i := range cells {
+		cells[i] = strings.TrimSpace(cells[i])
+	}
+	return cells
+}
+
+// markdownToHTML converts common markdown subset into HTML: headings,
+// paragraphs, emphasis, code, links, images, quotes, lists, rules
+// and tables
+func markdownToHTML(src string) string {
+	lines := strings.Split(strings.ReplaceAll(src, "\r\n", "\n"), "\n")
+	var sb strings.Builder
+	para := []string{}
+	flush := func() {
+		if len(para) == 0 {
+			return
+		}
+		sb.WriteString("<p>")
+		for i, l := range para {
+			br := strings.HasSuffix(l, "  ") || strings.HasSuffix(l, "\\")
+			sb.WriteString(mdInline(strings.TrimRight(strings.TrimSpace(l), "\\")))
+			if i+1 < len(para) {
+				if br {
+					sb.WriteString("<br>")
+				}
+				sb.WriteString("\n")
+			}
+		}
+		sb.WriteString("</p>\n")
+		para = nil
+	}
+	for i := 0; i < len(lines); i++ {
+		l := lines[i]
+		if m := mdFenceRe.FindStringSubmatch(l); m != nil {
+			flush()
+			sb.WriteString("<pre><code>")
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), m[1]); i++ {
+				sb.WriteString(html.EscapeString(lines[i]) + "\n")
+			}
+			sb.WriteString("</code></pre>\n")
+			continue
+		}
+		if strings.TrimSpace(l) == "" {
+			flush()
+			continue
+		}
+		if m := mdHeadingRe.FindStringSubmatch(l); m != nil {
+			flush()
+			n := strconv.Itoa(len(m[1]))
+			sb.WriteString("<h" + n + ">" + mdInline(m[2]) + "</h" + n + ">\n")
+			continue
+		}
+		if mdRuleRe.MatchString(l) {
+			flush()
+			sb.WriteString("<hr>\n")
+			continue
+		}
+		if mdQuoteRe.MatchString(l) {
+			flush()
+			quote := []string{}
+			for ; i < len(lines); i++ {
+				m := mdQuoteRe.FindStringSubmatch(lines[i])
+				if m == nil {
+					break
+				}
+				quote = append(quote, m[1])
+			}
+			i--
+			sb.WriteString("<blockquote>\n" + markdownToHTML(strings.Join(quote, "\n")) + "</blockquote>\n")
+			continue
+		}
+		if m := mdListRe.FindStringSubmatch(l); m != nil && len(para) == 0 {
+			ordered := m[1][0] >= '0' && m[1][0] <= '9'
+			tag := "ul"
+			if ordered {
+				tag = "ol"
+			}
+			sb.WriteString("<" + tag + ">\n")
+			items := []string{}
+			for ; i < len(lines); i++ {
+				l := lines[i]
+				if m := mdListRe.FindStringSubmatch(l); m != nil {
+					items = append(items, m[2])
+					continue
+				}
+				// item continues on indented lines
+				if strings.TrimSpace(l) == "" || !strings.HasPrefix(l, " ") && !strings.HasPrefix(l, "\t") {
+					break
+				}
+				items[len(items)-1] += " " + strings.TrimSpace(l)
+			}
+			i--
+			for _, item := range items {
+				sb.WriteString("<li>" + mdInline(item) + "</li>\n")
+			}
+			sb.WriteString("</" + tag + ">\n")
+			continue
+		}
+		if strings.Contains(l, "|") && len(para) == 0 && i+1 < len(lines) && mdTableSepRe.MatchString(lines[i+1]) {
+			sb.WriteString("<table>\n<tr>")
+			for _, c := range mdTableRow(l) {
+				sb.WriteString("<th>" + mdInline(c) + "</th>")
+			}
+			sb.WriteString("</tr>\n")
+			for i += 2; i < len(lines) && strings.Contains(lines[i], "|"); i++ {
+				sb.WriteString("<tr>")
+				for _, c := range mdTableRow(lines[i]) {
+					sb.WriteString("<td>" + mdInline(c) + "</td>")
+				}
+				sb.WriteString("</tr>\n")
+			}
+			i--
+			sb.WriteString("</table>\n")
+			continue
+		}
+		para = append(para, l)
+	}
+	flush()
+	return sb.String()
+}
+
+// ReadMarkdown converts markdown book into FB2. Headings split book
+// into sections like in ReadHTML, a single top heading is book title.
+// Only images embedded as data URIs are kept.
+func ReadMarkdown(r io.Reader) (FB2, error) {
+	src, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("read markdown error: %w", err)
+	}
+	return readHTML(strings.NewReader(markdownToHTML(decodeText(src))), nil)
+}
+
+// OpenMarkdown converts markdown book file into FB2 like ReadMarkdown.
+// Local images are loaded relative to the file.
+func OpenMarkdown(srcFilePath string) (FB2, error) {
+	src, err := ioutil.ReadFile(srcFilePath)
+	if err != nil {
+		return nil, fmt.Errorf("open markdown error: %w", err)
+	}
+	return readHTML(strings.NewReader(markdownToHTML(decodeText(src))), localLoader(filepath.Dir(srcFilePath)))
+}
+
+// mdLineStartRe matches line starts taken as markdown block markup
+var mdLineStartRe = regexp.MustCompile(`^(\s*)(#|>|[-+]\s|(\d+)[.)]\s)`)
+
+// mdEscape escapes markdown markup characters of text
+func mdEscape(s string) string {
+	var sb strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune("\\`*_[]<~|", r) {
+			sb.WriteByte('\\')
+		}
+		sb.WriteRune(r)
+	}
+	s = sb.String()
+	if m := mdLineStartRe.FindStringSubmatchIndex(s); m != nil {
+		if m[6] >= 0 {
+			// escape dot of ordered list marker
+			return s[:m[7]] + "\\" + s[m[7]:]
+		}
+		return s[:m[4]] + "\\" + s[m[4]:]
+	}
+	return s
+}
+
+// mdWriter renders FB2 elements as markdown blocks
+type mdWriter struct {
+	d      *fb2
+	blocks []string
+}
+
+func (m *mdWriter) add(s string) {
+	if strings.TrimSpace(s) != "" {
+		m.blocks = append(m.blocks, s)
+	}
+}
+
+// inline renders inline content of e
+func (m *mdWriter) inline(e *etree.Element) string {
+	var sb strings.Builder
+	for _, t := range e.Child {
+		switch c := t.(type) {
+		case *etree.CharData:
+			sb.WriteString(mdEscape(collapseSpace(c.Data)))
+		case *etree.Element:
+			sb.WriteString(m.inlineElement(c))
+			sb.WriteString(mdEscape(collapseSpace(c.Tail())))
+		}
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+func (m *mdWriter) inlineElement(e *etree.Element) string {
+	switch e.Tag {
+	case "strong":
+		return "**" + m.inline(e) + "**"
+	case "emphasis":
+		return "*" + m.inline(e) + "*"
+	case "strikethrough":
+		return "~~" + m.inline(e) + "~~"
+	case "code":
+		return "`" + strings.ReplaceAll(innerText(e), "`", "'") + "`"
+	case "a":
+		return "[" + m.inline(e) + "](" + e.SelectAttrValue("href", "") + ")"
+	case "image":
+		return m.image(e)
+	}
+	return m.inline(e)
+}
+
+func (m *mdWriter) image(e *etree.Element) string {
+	b, ok := m.d.binary(e.SelectAttrValue("href", ""))
+	if !ok {
+		return mdEscape(e.SelectAttrValue("alt", ""))
+	}
+	return "![" + mdEscape(e.SelectAttrValue("alt", "")) + "](" + dataURI(b) + ")"
+}
+
+// lines renders title or stanza lines joined with hard line breaks
+func (m *mdWriter) lines(e *etree.Element) string {
+	lines := []string{}
+	for _, c := range e.ChildElements() {
+		if l := m.inline(c); l != "" {
+			lines = append(lines, l)
+		}
+	}
+	return strings.Join(lines, "  \n")
+}
+
+// heading renders title as heading of level
+func (m *mdWriter) heading(title *etree.Element, level int) string {
+	if level > 6 {
+		level = 6
+	}
+	lines := []string{}
+	for _, c := range title.ChildElements() {
+		if l := m.inline(c); l != "" {
+			lines = append(lines, l)
+		}
+	}
+	return strings.Repeat("#", level) + " " + strings.Join(lines, ". ")
+}
+
+// quote renders children of e as markdown quote
+func (m *mdWriter) quote(e *etree.Element, level int) string {
+	q := &mdWriter{d: m.d}
+	for _, c := range e.ChildElements() {
+		q.block(c, level)
+	}
+	text := strings.Join(q.blocks, "\n\n")
+	return "> " + strings.ReplaceAll(text, "\n", "\n> ")
+}
+
+// block renders block element e, level is heading level of its titles
+func (m *mdWriter) block(e *etree.Element, level int) {
+	switch e.Tag {
+	case "section", "body":
+		for _, c := range e.ChildElements() {
+			if c.Tag == "section" {
+				m.block(c, level+1)
+				continue
+			}
+			m.block(c, level)
+		}
+	case "title":
+		m.add(m.heading(e, level))
+	case "subtitle":
+		m.add(strings.Repeat("#", minInt(level+1, 6)) + " " + m.inline(e))
+	case "p", "v":
+		m.add(m.inline(e))
+	case "text-author":
+		m.add("*" + m.inline(e) + "*")
+	case "epigraph", "cite", "annotation":
+		m.add(m.quote(e, level+1))
+	case "poem":
+		for _, c := range e.ChildElements() {
+			switch c.Tag {
+			case "title":
+				m.add("**" + strings.ReplaceAll(m.lines(c), "  \n", "**  \n**") + "**")
+			case "stanza":
+				m.add(m.lines(c))
+			default:
+				m.block(c, level)
+			}
+		}
+	case "empty-line":
+		m.add("---")
+	case "image":
+		m.add(m.image(e))
+	case "table":
+		rows := []string{}
+		for i, tr := range e.SelectElements("tr") {
+			cells := []string{}
+			for _, c := range tr.ChildElements() {
+				cells = append(cells, m.inline(c))
+			}
+			rows = append(rows, "| "+strings.Join(cells, " | ")+" |")
+			if i == 0 {
+				rows = append(rows, "|"+strings.Repeat(" --- |", len(cells)))
+			}
+		}
+		m.add(strings.Join(rows, "\n"))
+	default:
+		for _, c := range e.ChildElements() {
+			m.block(c, level)
+		}
+	}
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// WriteMarkdown writes book as markdown. Book title is top level
+// heading, sections are lower level headings, images are embedded
+// as data URIs.
+func (d *fb2) WriteMarkdown(w io.Writer) error {
+	d.Lock()
+	defer d.Unlock()
+	body, bodies := d.exportBodies()
+	m := &mdWriter{d: d}
+	m.block(body, 1)
+	// notes are part of the book under its title
+	for _, b := range bodies {
+		m.block(b, 2)
+	}
+	if _, err := io.WriteString(w, strings.Join(m.blocks, "\n\n")+"\n"); err != nil {
+		return fmt.Errorf("write markdown error: %w", err)
+	}
+	return nil
+}
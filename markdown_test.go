@@ -0,0 +1,58 @@
+package fb2
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReadMarkdown(t *testing.T) {
+	src := "# Book\n\n## One\n\nSome *em* and **strong** with `a*b`.\n\n> Quote\n\n## Two\n\n1. first\n2. second\n\n---\n\nSee [one](http://example.com)."
+	book, err := ReadMarkdown(strings.NewReader(src))
+	if err != nil {
+		t.Fatalf("ReadMarkdown() error = %v", err)
+	}
+	if book.Title() != "Book" {
+		t.Errorf("Title() = %q, want %q", book.Title(), "Book")
+	}
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"One", "<section>\n<title>\n<p>One</p>\n</title>\n" +
+			"<p>Some <emphasis>em</emphasis> and <strong>strong</strong> with <code>a*b</code>.</p>\n" +
+			"<cite>\n<p>Quote</p>\n</cite>\n</section>\n"},
+		{"Two", "<section>\n<title>\n<p>Two</p>\n</title>\n<p>1. first</p>\n<p>2. second</p>\n<empty-line/>\n" +
+			"<p>See <a l:href=\"http://example.com\">one</a>.</p>\n</section>\n"},
+	}
+	sections := book.(*fb2).body.SelectElements("section")
+	if len(sections) != len(tests) {
+		t.Fatalf("ReadMarkdown() sections = %d, want %d", len(sections), len(tests))
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := elementString(t, sections[i]); got != tt.want {
+				t.Errorf("section = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	book, err := ReadFB2(strings.NewReader(splitSrc))
+	if err != nil {
+		t.Fatalf("ReadFB2() error = %v", err)
+	}
+	var sb strings.Builder
+	if err := book.WriteMarkdown(&sb); err != nil {
+		t.Fatalf("WriteMarkdown() error = %v", err)
+	}
+	for _, want := range []string{
+		"# Big Book\n\n> Epigraph\n\n## Part One\n\nShort[\\[1\\]](#n1)\n\n",
+		"See [part one](#p1) here.\n\n![](data:image/png;base64,BBBB)\n\n",
+		"## Notes\n\n### 1\n\nFirst note, see [3](#n3)\n\n",
+	} {
+		if !strings.Contains(sb.String(), want) {
+			t.Errorf("WriteMarkdown() = %q, want to contain %q", sb.String(), want)
+		}
+	}
+}
@@ -0,0 +1,349 @@
+package fb2
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	etree "github.com/rupor-github/fb2converter/etree"
+)
+
+// ErrMergeSource is returned when books can't be merged
+var ErrMergeSource = errors.New("invalid merge source")
+
+// MergeMetadata defines how title-info of merged books is combined
+type MergeMetadata int
+
+const (
+	// MergeFirst takes metadata of the first book
+	MergeFirst MergeMetadata = iota
+	// MergeCombine joins authors, translators, genres, keywords
+	// and sequences of all books
+	MergeCombine
+)
+
+// MergeOptions configures Merge
+type MergeOptions struct {
+	// Title is the omnibus title. If empty, the common sequence name
+	// or joined book titles are used.
+	Title string
+	// Metadata is the title-info combining strategy
+	Metadata MergeMetadata
+}
+
+// bookMerger collects merged book parts
+type bookMerger struct {
+	res *fb2
+	// ids are all ids used in result
+	ids map[string]bool
+	// notes are merged note bodies by body name
+	notes map[string]*etree.Element
+	// noteNum are last note numbers by body name
+	noteNum map[string]int
+}
+
+// Merge combines books into one. Each book becomes top level section
+// with its title, epigraphs, cover and annotation. Binaries and other
+// ids are renamed to avoid collisions, notes are renumbered.
+func Merge(opts MergeOptions, books ...FB2) (FB2, error) {
+	if len(books) == 0 {
+		return nil, fmt.Errorf("merge error: %w", ErrMergeSource)
+	}
+	srcs := make([]*fb2, len(books))
+	for i, b := range books {
+		s, ok := b.(*fb2)
+		if !ok || s == nil {
+			return nil, fmt.Errorf("merge book %d error: %w", i, ErrMergeSource)
+		}
+		srcs[i] = s
+	}
+	m := &bookMerger{
+		res:     NewFB2("").(*fb2),
+		ids:     map[string]bool{},
+		notes:   map[string]*etree.Element{},
+		noteNum: map[string]int{},
+	}
+	infos := make([]TitleInfoType, len(srcs))
+	for i, s := range srcs {
+		infos[i] = m.add(s)
+	}
+	for _, s := range srcs {
+		for _, b := range s.bodies {
+			if name := b.SelectAttrValue("name", ""); m.notes[name] != nil {
+				m.res.bodies = append(m.res.bodies, m.notes[name])
+				delete(m.notes, name)
+			}
+		}
+	}
+	ti := &m.res.data.Description.TitleInfo
+	switch opts.Metadata {
+	case MergeCombine:
+		combineTitleInfo(ti, infos)
+	default:
+		first := infos[0]
+		first.Coverpage = ti.Coverpage
+		*ti = first
+	}
+	ti.BookTitle = opts.Title
+	if ti.BookTitle == "" {
+		ti.BookTitle = mergedTitle(infos)
+	}
+	m.res.data.Description.PublishInfo.BookName = ti.BookTitle
+	return m.res, nil
+}
+
+// mergedTitle returns common sequence name of books or their titles
+func mergedTitle(infos []TitleInfoType) string {
+	name := ""
+	if len(infos[0].Sequence) != 0 {
+		name = infos[0].Sequence[0].Name
+	}
+	titles := []string{}
+	for _, ti := range infos {
+		titles = append(titles, ti.BookTitle)
+		if len(ti.Sequence) == 0 || ti.Sequence[0].Name != name {
+			name = ""
+		}
+	}
+	if name != "" {
+		return name
+	}
+	return strings.Join(titles, "; ")
+}
+
+// combineTitleInfo joins title-info of all books into ti
+func combineTitleInfo(ti *TitleInfoType, infos []TitleInfoType) {
+	addAuthors := func(to []AuthorType, from []AuthorType) []AuthorType {
+		for i := range from {
+			found := false
+			for j := range to {
+				if sameAuthor(&to[j], &from[i]) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				to = append(to, from[i])
+			}
+		}
+		return to
+	}
+	keywords := []string{}
+	seen := map[string]bool{}
+	for _, info := range infos {
+		ti.Author = addAuthors(ti.Author, info.Author)
+		ti.Translator = addAuthors(ti.Translator, info.Translator)
+		for _, g := range info.Genre {
+			if !seen["genre:"+g.Text] {
+				seen["genre:"+g.Text] = true
+				ti.Genre = append(ti.Genre, Genre{Text: g.Text})
+			}
+		}
+		for _, k := range strings.Split(info.Keywords, ",") {
+			k = strings.TrimSpace(k)
+			if k != "" && !seen["keyword:"+strings.ToLower(k)] {
+				seen["keyword:"+strings.ToLower(k)] = true
+				keywords = append(keywords, k)
+			}
+		}
+		for _, s := range info.Sequence {
+			if !seen["sequence:"+s.Name] {
+				seen["sequence:"+s.Name] = true
+				ti.Sequence = append(ti.Sequence, SequenceType{Name: s.Name})
+			}
+		}
+		if ti.Lang == "" {
+			ti.Lang = info.Lang
+		}
+		if ti.SrcLang == "" {
+			ti.SrcLang = info.SrcLang
+		}
+	}
+	ti.Keywords = strings.Join(keywords, ", ")
+}
+
+// elementIDs returns ids of e and its descendants in document order
+func elementIDs(e *etree.Element) []string {
+	ids := []string{}
+	if id := e.SelectAttrValue("id", ""); id != "" {
+		ids = append(ids, id)
+	}
+	for _, c := range e.ChildElements() {
+		ids = append(ids, elementIDs(c)...)
+	}
+	return ids
+}
+
+// renameIDs replaces ids and internal links of e and its descendants
+func renameIDs(e *etree.Element, rename map[string]string) {
+	for i := range e.Attr {
+		a := &e.Attr[i]
+		switch {
+		case a.Key == "id" && a.Space == "":
+			if id, ok := rename[a.Value]; ok {
+				a.Value = id
+			}
+		case a.Key == "href" && strings.HasPrefix(a.Value, "#"):
+			if id, ok := rename[a.Value[1:]]; ok {
+				a.Value = "#" + id
+			}
+		}
+	}
+	for _, c := range e.ChildElements() {
+		renameIDs(c, rename)
+	}
+}
+
+// uniqueBinaryID returns unused binary id keeping file extension
+func uniqueBinaryID(id string, ids map[string]bool) string {
+	ext := path.Ext(id)
+	base := strings.TrimSuffix(id, ext)
+	res := id
+	for n := 2; ids[res]; n++ {
+		res = base + "-" + strconv.Itoa(n) + ext
+	}
+	ids[res] = true
+	return res
+}
+
+// add merges book s into result and returns its title-info
+func (m *bookMerger) add(s *fb2) TitleInfoType {
+	s.Lock()
+	defer s.Unlock()
+	rename := map[string]string{}
+	// notes are numbered in order of merged note bodies
+	type noteNum struct{ old, new string }
+	nums := map[string]noteNum{}
+	for _, b := range s.bodies {
+		name := b.SelectAttrValue("name", "")
+		for _, n := range b.SelectElements("section") {
+			id := n.SelectAttrValue("id", "")
+			if id == "" || name != "notes" {
+				continue
+			}
+			m.noteNum[name]++
+			num := m.noteNum[name]
+			nid := uniqueID("n"+strconv.Itoa(num), m.ids)
+			rename[id] = nid
+			if title := sectionTitle(n); isNumber(strings.Trim(title, "[]{}")) {
+				nums[nid] = noteNum{strings.Trim(title, "[]{}"), strconv.Itoa(num)}
+			}
+		}
+	}
+	ids := elementIDs(s.body)
+	for _, b := range s.bodies {
+		ids = append(ids, elementIDs(b)...)
+	}
+	for _, id := range ids {
+		if _, ok := rename[id]; !ok {
+			rename[id] = uniqueID(id, m.ids)
+		}
+	}
+	for _, b := range s.data.Binary {
+		rename[b.Id] = uniqueBinaryID(b.Id, m.ids)
+		b.Id = rename[b.Id]
+		m.res.data.Binary = append(m.res.data.Binary, b)
+	}
+
+	ti := copyTitleInfo(s.data.Description.TitleInfo)
+	ti.Coverpage = nil
+	cover := ""
+	if cp := s.data.Description.TitleInfo.Coverpage; len(cp) != 0 && cp[0].Image != nil {
+		id := strings.TrimPrefix(cp[0].Image.XlinkHref, "#")
+		if nid, ok := rename[id]; ok {
+			id = nid
+		}
+		cover = id
+		if len(m.res.data.Description.TitleInfo.Coverpage) == 0 {
+			m.res.data.Description.TitleInfo.Coverpage = []Coverpage{{
+				Image: &InlineImageType{XlinkHref: "#" + id, Alt: "Cover"},
+			}}
+		}
+	}
+
+	body := s.body.Copy()
+	renameIDs(body, rename)
+	section := m.res.body.CreateElement("section")
+	section.SetText("\n")
+	section.SetTail("\n")
+	if title := body.SelectElement("title"); title != nil {
+		section.AddChild(title.Copy().SetTail("\n"))
+	} else {
+		section.AddChild(newTitle(ti.BookTitle).SetTail("\n"))
+	}
+	for _, e := range body.SelectElements("epigraph") {
+		section.AddChild(e.Copy().SetTail("\n"))
+	}
+	if cover != "" {
+		img := section.CreateElement("image")
+		img.CreateAttr("l:href", "#"+cover)
+		img.SetTail("\n")
+	}
+	if s.annotation != nil {
+		ann := s.annotation.Copy()
+		renameIDs(ann, rename)
+		section.AddChild(ann.SetTail("\n"))
+	}
+	for _, e := range body.ChildElements() {
+		if !hasTag(e, "title", "epigraph", "image") {
+			section.AddChild(e.Copy().SetTail("\n"))
+		}
+	}
+	renumberNoteLinks(section, func(id string) (string, string, bool) {
+		n, ok := nums[id]
+		return n.old, n.new, ok
+	})
+
+	for _, b := range s.bodies {
+		b = b.Copy()
+		renameIDs(b, rename)
+		name := b.SelectAttrValue("name", "")
+		notes := m.notes[name]
+		if notes == nil {
+			notes = etree.NewElement("body")
+			notes.Attr = append(notes.Attr, b.Attr...)
+			notes.SetText("\n")
+			notes.SetTail("\n")
+			if title := b.SelectElement("title"); title != nil {
+				notes.AddChild(title.Copy().SetTail("\n"))
+			}
+			m.notes[name] = notes
+		}
+		for _, e := range b.ChildElements() {
+			if e.Tag == "title" {
+				continue
+			}
+			if n, ok := nums[e.SelectAttrValue("id", "")]; ok {
+				if title := e.SelectElement("title"); title != nil {
+					e.InsertChild(title, newTitle(n.new).SetTail(title.Tail()))
+					e.RemoveChild(title)
+				}
+			}
+			notes.AddChild(e.SetTail("\n"))
+		}
+	}
+	return ti
+}
+
+// renumberNoteLinks replaces old note numbers in link texts with new ones
+func renumberNoteLinks(e *etree.Element, num func(id string) (string, string, bool)) {
+	for _, a := range e.FindElements(".//a") {
+		href := a.SelectAttrValue("href", "")
+		if !strings.HasPrefix(href, "#") || len(a.ChildElements()) != 0 {
+			continue
+		}
+		old, n, ok := num(href[1:])
+		if !ok {
+			continue
+		}
+		if text := a.Text(); strings.Trim(text, "[]{} ") == old {
+			a.SetText(strings.Replace(text, old, n, 1))
+		}
+	}
+}
+
+func isNumber(s string) bool {
+	return s != "" && allDigits(s)
+}
@@ -0,0 +1,116 @@
+package fb2
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// volumeFB2 returns loaded series volume with note and cover
+func volumeFB2(t *testing.T, n int, author string) FB2 {
+	t.Helper()
+	src := fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<FictionBook xmlns="http://www.gribuser.ru/xml/fictionbook/2.0" xmlns:l="http://www.w3.org/1999/xlink">
+<description>
+<title-info>
+<genre>sf</genre><genre>sf_space</genre>
+<author><first-name>%[2]s</first-name><last-name>Writer</last-name></author>
+<book-title>Volume %[1]d</book-title>
+<annotation><p>About volume %[1]d</p></annotation>
+<keywords>space, Series</keywords>
+<coverpage><l:image l:href="#cover.jpg"/></coverpage>
+<lang>en</lang>
+<sequence name="Saga" number="%[1]d"/>
+</title-info>
+</description>
+<body>
+<section id="ch1"><title><p>Chapter 1</p></title><p>Text<a l:href="#n_1" type="note">[1]</a></p></section>
+</body>
+<body name="notes">
+<title><p>Notes</p></title>
+<section id="n_1"><title><p>1</p></title><p>Note of volume %[1]d</p></section>
+</body>
+<binary id="cover.jpg" content-type="image/jpeg">AAAA</binary>
+</FictionBook>`, n, author)
+	d, err := ReadFB2(strings.NewReader(src))
+	if err != nil {
+		t.Fatalf("ReadFB2() error = %v", err)
+	}
+	return d
+}
+
+func TestMerge(t *testing.T) {
+	v1 := volumeFB2(t, 1, "Ann")
+	v2 := volumeFB2(t, 2, "Bob")
+	d, err := Merge(MergeOptions{Metadata: MergeCombine}, v1, v2)
+	if err != nil {
+		t.Fatalf("Merge() error = %v", err)
+	}
+	if got, want := d.Title(), "Saga"; got != want {
+		t.Errorf("fb2.Title() = %q, want %q", got, want)
+	}
+	authors := []string{}
+	for _, a := range d.Authors(RoleAuthor) {
+		authors = append(authors, a.String())
+	}
+	if want := []string{"Ann Writer", "Bob Writer"}; !reflect.DeepEqual(authors, want) {
+		t.Errorf("fb2.Authors() = %v, want %v", authors, want)
+	}
+	if got, want := d.Genre(), []string{"sf", "sf_space"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("fb2.Genre() = %v, want %v", got, want)
+	}
+	want := []SectionInfo{
+		{Index: 0, Title: "Volume 1", Sections: 1},
+		{Index: 1, Title: "Volume 2", Sections: 1},
+	}
+	if got := d.Sections(); !reflect.DeepEqual(got, want) {
+		t.Errorf("fb2.Sections() = %+v, want %+v", got, want)
+	}
+	out, err := d.WriteToString()
+	if err != nil {
+		t.Fatalf("fb2.WriteToString() error = %v", err)
+	}
+	for _, s := range []string{
+		`<keywords>space, Series</keywords>`,
+		`<sequence name="Saga"/>`,
+		`<image l:href="#cover.jpg" alt="Cover"/>`,
+		`<section>
+<title>
+<p>Volume 2</p>
+</title>
+<image l:href="#cover-2.jpg"/>
+<annotation><p>About volume 2</p></annotation>
+<section id="ch1-2">`,
+		`<a l:href="#n1" type="note">[1]</a>`,
+		`<a l:href="#n2" type="note">[2]</a>`,
+		`<section id="n2"><title>
+<p>2</p>
+</title><p>Note of volume 2</p></section>`,
+		`<binary content-type="image/jpeg" id="cover-2.jpg">AAAA</binary>`,
+	} {
+		if !strings.Contains(out, s) {
+			t.Errorf("fb2.WriteToString() doesn't contain\n%s\n%s", s, out)
+		}
+	}
+	if n := strings.Count(out, `<body name="notes">`); n != 1 {
+		t.Errorf("notes bodies count = %d, want 1", n)
+	}
+}
+
+func TestMergeFirst(t *testing.T) {
+	d, err := Merge(MergeOptions{Title: "Omnibus"}, volumeFB2(t, 1, "Ann"), NewFB2("Other"))
+	if err != nil {
+		t.Fatalf("Merge() error = %v", err)
+	}
+	if got := d.Title(); got != "Omnibus" {
+		t.Errorf("fb2.Title() = %q, want %q", got, "Omnibus")
+	}
+	if got := d.Author(); got != "Ann Writer" {
+		t.Errorf("fb2.Author() = %q, want %q", got, "Ann Writer")
+	}
+	if _, err := Merge(MergeOptions{}); !errors.Is(err, ErrMergeSource) {
+		t.Errorf("Merge() error = %v, want %v", err, ErrMergeSource)
+	}
+}
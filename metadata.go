@@ -0,0 +1,153 @@
+package fb2
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+// ErrNoDescription is returned by ReadMetadata for documents
+// without description
+var ErrNoDescription = errors.New("no description")
+
+// CoverImage is decoded cover image of book
+type CoverImage struct {
+	Id          string
+	ContentType string
+	Data        []byte
+}
+
+// Metadata is book description read without book content
+type Metadata struct {
+	Description FictionBookDescription
+	// Cover is book cover, nil if it wasn't requested
+	// or book has no cover
+	Cover *CoverImage
+}
+
+// ReadMetadata reads book description from FictionBook document
+// or zip archive with it and stops without reading book content.
+// If cover is set, bodies are skipped without parsing up to
+// the cover binary. Archives are read by central directory if r
+// is io.ReaderAt and io.Seeker, like *os.File, and sequentially
+// otherwise.
+func ReadMetadata(r io.Reader, cover bool) (*Metadata, error) {
+	if rs, ok := r.(readSeekerAt); ok {
+		zr, err := zipReaderAt(rs)
+		if err != nil {
+			return nil, fmt.Errorf("read metadata zip error: %w", err)
+		}
+		if zr != nil {
+			// like sequential reading, the first fb2 entry is read
+			var entry *zip.File
+			for _, f := range zr.File {
+				if strings.EqualFold(filepath.Ext(f.Name), ".fb2") {
+					entry = f
+					break
+				}
+			}
+			if entry == nil {
+				return nil, fmt.Errorf("read metadata zip error: %w", ErrNoFB2InZip)
+			}
+			f, err := entry.Open()
+			if err != nil {
+				return nil, fmt.Errorf("read metadata zip error: %w", err)
+			}
+			defer f.Close()
+			return readMetadata(f, cover)
+		}
+	}
+	br := bufio.NewReader(r)
+	if magic, _ := br.Peek(len(zipMagic)); bytes.Equal(magic, zipMagic) {
+		zr, err := zipStream(br)
+		if err != nil {
+			return nil, fmt.Errorf("read metadata zip error: %w", err)
+		}
+		return readMetadata(zr, cover)
+	}
+	return readMetadata(br, cover)
+}
+
+// readSeekerAt is reader with random access
+type readSeekerAt interface {
+	io.ReaderAt
+	io.Seeker
+}
+
+// zipReaderAt returns zip reader of archive starting at current
+// position of r, nil if r isn't zip archive. Position of r is kept.
+func zipReaderAt(r readSeekerAt) (*zip.Reader, error) {
+	start, err := r.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+	end, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := r.Seek(start, io.SeekStart); err != nil {
+		return nil, err
+	}
+	magic := make([]byte, len(zipMagic))
+	if n, _ := r.ReadAt(magic, start); n < len(magic) || !bytes.Equal(magic, zipMagic) {
+		return nil, nil
+	}
+	return zip.NewReader(io.NewSectionReader(r, start, end-start), end-start)
+}
+
+// OpenMetadata reads book description of .fb2 or zipped .fb2 file
+// like ReadMetadata
+func OpenMetadata(srcFilePath string, cover bool) (*Metadata, error) {
+	f, err := OpenFB2Stream(srcFilePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return readMetadata(f, cover)
+}
+
+func readMetadata(r io.Reader, cover bool) (*Metadata, error) {
+	s := NewStreamReader(r)
+	s.skipBodies = true
+	var m *Metadata
+	coverID := ""
+	for {
+		ev, err := s.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read metadata error: %w", err)
+		}
+		switch ev.Type {
+		case EventDescription:
+			m = &Metadata{Description: *ev.Description}
+			if cp := m.Description.TitleInfo.Coverpage; len(cp) != 0 && cp[0].Image != nil {
+				coverID = strings.TrimPrefix(cp[0].Image.XlinkHref, "#")
+			}
+			if !cover || coverID == "" {
+				return m, nil
+			}
+		case EventBinary:
+			if m == nil || ev.Id != coverID {
+				continue
+			}
+			data, err := ioutil.ReadAll(ev.Data)
+			if err != nil {
+				return nil, fmt.Errorf("read metadata cover error: %w", err)
+			}
+			m.Cover = &CoverImage{Id: ev.Id, ContentType: ev.ContentType, Data: data}
+			return m, nil
+		}
+	}
+	if m == nil {
+		return nil, fmt.Errorf("read metadata error: %w", ErrNoDescription)
+	}
+	return m, nil
+}
@@ -0,0 +1,123 @@
+package fb2
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadMetadata(t *testing.T) {
+	var zipped bytes.Buffer
+	if err := ZipFB2(&zipped, "book.fb2", strings.NewReader(splitSrc)); err != nil {
+		t.Fatal(err)
+	}
+	// fb2 entry follows other files
+	var mixed bytes.Buffer
+	zw := zip.NewWriter(&mixed)
+	for _, name := range []string{"readme.txt", "book.fb2"} {
+		f, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Write([]byte(splitSrc))
+	}
+	zw.Close()
+	path := filepath.Join(t.TempDir(), "book.fb2.zip")
+	if err := ioutil.WriteFile(path, zipped.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		read  func(cover bool) (*Metadata, error)
+		cover bool
+	}{
+		{"fb2", func(cover bool) (*Metadata, error) { return ReadMetadata(strings.NewReader(splitSrc), cover) }, false},
+		{"fb2 cover", func(cover bool) (*Metadata, error) { return ReadMetadata(strings.NewReader(splitSrc), cover) }, true},
+		{"zip cover", func(cover bool) (*Metadata, error) { return ReadMetadata(bytes.NewReader(zipped.Bytes()), cover) }, true},
+		{"zip entries", func(cover bool) (*Metadata, error) { return ReadMetadata(bytes.NewReader(mixed.Bytes()), cover) }, true},
+		{"file", func(cover bool) (*Metadata, error) { return OpenMetadata(path, cover) }, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := tt.read(tt.cover)
+			if err != nil {
+				t.Fatalf("ReadMetadata() error = %v", err)
+			}
+			ti := m.Description.TitleInfo
+			if ti.BookTitle != "Big Book" || ti.Lang != "en" || len(ti.Author) != 1 || ti.Author[0].LastName != "Writer" {
+				t.Errorf("ReadMetadata() title-info = %+v", ti)
+			}
+			if !tt.cover {
+				if m.Cover != nil {
+					t.Errorf("ReadMetadata() cover = %+v, want nil", m.Cover)
+				}
+				return
+			}
+			if c := m.Cover; c == nil || c.Id != "cover.jpg" || c.ContentType != "image/jpeg" || !bytes.Equal(c.Data, []byte{0, 0, 0}) {
+				t.Errorf("ReadMetadata() cover = %+v", c)
+			}
+		})
+	}
+
+	if _, err := ReadMetadata(strings.NewReader(`<FictionBook><body/></FictionBook>`), false); !errors.Is(err, ErrNoDescription) {
+		t.Errorf("ReadMetadata() error = %v, want %v", err, ErrNoDescription)
+	}
+	var empty bytes.Buffer
+	zw = zip.NewWriter(&empty)
+	zw.Create("readme.txt")
+	zw.Close()
+	if _, err := ReadMetadata(bytes.NewReader(empty.Bytes()), false); !errors.Is(err, ErrNoFB2InZip) {
+		t.Errorf("ReadMetadata() error = %v, want %v", err, ErrNoFB2InZip)
+	}
+}
+
+func TestReadMetadata_StoredEntries(t *testing.T) {
+	// zip.Writer writes sizes of stored entries to data descriptors
+	archive := func(entries ...string) []byte {
+		var buf bytes.Buffer
+		zw := zip.NewWriter(&buf)
+		for i := 0; i < len(entries); i += 2 {
+			f, err := zw.CreateHeader(&zip.FileHeader{Name: entries[i], Method: zip.Store})
+			if err != nil {
+				t.Fatal(err)
+			}
+			f.Write([]byte(entries[i+1]))
+		}
+		zw.Close()
+		return buf.Bytes()
+	}
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"stored fb2", archive("book.fb2", splitSrc)},
+		{"stored entries", archive("readme.txt", "text with PK\x07\x08 inside", "cover.jpg", "jpeg", "book.fb2", splitSrc)},
+	}
+	for _, tt := range tests {
+		if flags := binary.LittleEndian.Uint16(tt.data[6:]); flags&0x8 == 0 {
+			t.Fatalf("%s: first entry has no data descriptor", tt.name)
+		}
+		readers := map[string]io.Reader{
+			"sequential": struct{ io.Reader }{bytes.NewReader(tt.data)},
+			"random":     bytes.NewReader(tt.data),
+		}
+		for mode, r := range readers {
+			t.Run(tt.name+" "+mode, func(t *testing.T) {
+				m, err := ReadMetadata(r, true)
+				if err != nil {
+					t.Fatalf("ReadMetadata() error = %v", err)
+				}
+				if m.Description.TitleInfo.BookTitle != "Big Book" || m.Cover == nil {
+					t.Errorf("ReadMetadata() = %+v", m)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package fb2
+
+import (
+	etree "github.com/rupor-github/fb2converter/etree"
+)
+
+// PoemBuilder builds poem element
+type PoemBuilder struct {
+	id         string
+	lang       string
+	title      []string
+	epigraphs  []*EpigraphBuilder
+	stanzas    []*etree.Element
+	textAuthor [][]Inline
+	date       *DateType
+}
+
+// StanzaBuilder builds poem stanza
+type StanzaBuilder struct {
+	title    []string
+	subtitle []Inline
+	verses   [][]Inline
+}
+
+// NewPoem returns poem builder
+func NewPoem(title ...string) *PoemBuilder {
+	return &PoemBuilder{title: title}
+}
+
+// SetID sets poem id
+func (p *PoemBuilder) SetID(id string) *PoemBuilder {
+	p.id = id
+	return p
+}
+
+// SetLang sets poem language
+func (p *PoemBuilder) SetLang(lang string) *PoemBuilder {
+	p.lang = lang
+	return p
+}
+
+// SetTitle sets poem title lines
+func (p *PoemBuilder) SetTitle(lines ...string) *PoemBuilder {
+	p.title = lines
+	return p
+}
+
+// AddEpigraph appends poem epigraph
+func (p *PoemBuilder) AddEpigraph(e *EpigraphBuilder) *PoemBuilder {
+	p.epigraphs = append(p.epigraphs, e)
+	return p
+}
+
+// AddSubtitle appends subtitle placed before the next stanza
+func (p *PoemBuilder) AddSubtitle(c ...Inline) *PoemBuilder {
+	p.stanzas = append(p.stanzas, Subtitle(c...).Element())
+	return p
+}
+
+// AddStanza appends stanza
+func (p *PoemBuilder) AddStanza(s *StanzaBuilder) *PoemBuilder {
+	p.stanzas = append(p.stanzas, s.Element())
+	return p
+}
+
+// AddTextAuthor appends text-author line
+func (p *PoemBuilder) AddTextAuthor(c ...Inline) *PoemBuilder {
+	p.textAuthor = append(p.textAuthor, c)
+	return p
+}
+
+// SetDate sets poem date text and optional machine readable value
+func (p *PoemBuilder) SetDate(text, value string) *PoemBuilder {
+	p.date = &DateType{Text: text, Value: value}
+	return p
+}
+
+// Element returns poem element
+func (p *PoemBuilder) Element() *etree.Element {
+	e := etree.NewElement("poem")
+	e.SetText("\n")
+	if p.id != "" {
+		e.CreateAttr("id", p.id)
+	}
+	if p.lang != "" {
+		e.CreateAttr("xml:lang", p.lang)
+	}
+	if len(p.title) != 0 {
+		e.AddChild(newTitle(p.title...).SetTail("\n"))
+	}
+	for _, ep := range p.epigraphs {
+		e.AddChild(ep.Element().SetTail("\n"))
+	}
+	for _, s := range p.stanzas {
+		e.AddChild(s.Copy().SetTail("\n"))
+	}
+	for _, ta := range p.textAuthor {
+		appendBlocks(e, newBlock("text-author", ta...))
+	}
+	if p.date != nil {
+		date := e.CreateElement("date")
+		if p.date.Value != "" {
+			date.CreateAttr("value", p.date.Value)
+		}
+		date.SetText(p.date.Text)
+		date.SetTail("\n")
+	}
+	return e
+}
+
+// NewStanza returns stanza builder. Lines are added as plain verses.
+func NewStanza(lines ...string) *StanzaBuilder {
+	s := &StanzaBuilder{}
+	for _, l := range lines {
+		s.verses = append(s.verses, []Inline{Text(l)})
+	}
+	return s
+}
+
+// SetTitle sets stanza title lines
+func (s *StanzaBuilder) SetTitle(lines ...string) *StanzaBuilder {
+	s.title = lines
+	return s
+}
+
+// SetSubtitle sets stanza subtitle
+func (s *StanzaBuilder) SetSubtitle(c ...Inline) *StanzaBuilder {
+	s.subtitle = c
+	return s
+}
+
+// AddVerse appends verse line with inline content
+func (s *StanzaBuilder) AddVerse(c ...Inline) *StanzaBuilder {
+	s.verses = append(s.verses, c)
+	return s
+}
+
+// Element returns stanza element
+func (s *StanzaBuilder) Element() *etree.Element {
+	e := etree.NewElement("stanza")
+	e.SetText("\n")
+	if len(s.title) != 0 {
+		e.AddChild(newTitle(s.title...).SetTail("\n"))
+	}
+	if s.subtitle != nil {
+		appendBlocks(e, Subtitle(s.subtitle...))
+	}
+	for _, v := range s.verses {
+		appendBlocks(e, newBlock("v", v...))
+	}
+	return e
+}
@@ -0,0 +1,83 @@
+package fb2
+
+import (
+	"strings"
+	"testing"
+
+	etree "github.com/rupor-github/fb2converter/etree"
+)
+
+func elementString(t *testing.T, e *etree.Element) string {
+	t.Helper()
+	doc := etree.NewDocument()
+	doc.SetRoot(e)
+	s, err := doc.WriteToString()
+	if err != nil {
+		t.Fatalf("write element error: %v", err)
+	}
+	return s
+}
+
+func TestPoemBuilder(t *testing.T) {
+	poem := NewPoem("Зимнее утро").
+		SetID("poem1").
+		AddEpigraph(NewEpigraph("Мороз и солнце").AddTextAuthor(Text("А. Пушкин"))).
+		AddStanza(NewStanza().
+			AddVerse(Text("Мороз и солнце; день "), Emphasis(Text("чудесный")), Text("!")).
+			AddVerse(Text("Еще ты дремлешь, друг прелестный —"))).
+		AddSubtitle(Text("* * *")).
+		AddStanza(NewStanza("Вечор, ты помнишь, вьюга злилась").
+			SetTitle("II").
+			SetSubtitle(Strong(Text("Вечер")))).
+		AddTextAuthor(Text("Александр Пушкин")).
+		SetDate("1829", "1829-11-03")
+	want := `<poem id="poem1">
+<title>
+<p>Зимнее утро</p>
+</title>
+<epigraph>
+<p>Мороз и солнце</p>
+<text-author>А. Пушкин</text-author>
+</epigraph>
+<stanza>
+<v>Мороз и солнце; день <emphasis>чудесный</emphasis>!</v>
+<v>Еще ты дремлешь, друг прелестный —</v>
+</stanza>
+<subtitle>* * *</subtitle>
+<stanza>
+<title>
+<p>II</p>
+</title>
+<subtitle><strong>Вечер</strong></subtitle>
+<v>Вечор, ты помнишь, вьюга злилась</v>
+</stanza>
+<text-author>Александр Пушкин</text-author>
+<date value="1829-11-03">1829</date>
+</poem>`
+	if got := elementString(t, poem.Element()); got != want {
+		t.Errorf("PoemBuilder.Element() =\n%s\nwant\n%s", got, want)
+	}
+
+	d := NewFB2("Test1Title")
+	d.AddSectionBlocks("Стихи", P(Text("Вступление")), poem, EmptyLine())
+	d.SetDescription("Test")
+	out, err := d.WriteToString()
+	if err != nil {
+		t.Fatalf("fb2.WriteToString() error = %v", err)
+	}
+	if !strings.Contains(out, "<p>Вступление</p>\n<poem id=\"poem1\">") {
+		t.Errorf("fb2.AddSectionBlocks() poem not found:\n%s", out)
+	}
+	if _, err := ReadFB2(strings.NewReader(out)); err != nil {
+		t.Errorf("ReadFB2() error = %v", err)
+	}
+}
+
+func TestInline(t *testing.T) {
+	p := P(Text("a "), Strong(Text("b "), Emphasis(Text("c"))), Text(" d "),
+		Link("#n1", Sup(Text("1"))), Text(" "), Code(Text("x<y")), InlineImage("img.png"))
+	want := `<p>a <strong>b <emphasis>c</emphasis></strong> d <a l:href="#n1"><sup>1</sup></a> <code>x&lt;y</code><image l:href="#img.png"/></p>`
+	if got := elementString(t, p.Element()); got != want {
+		t.Errorf("P() =\n%s\nwant\n%s", got, want)
+	}
+}
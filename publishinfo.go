@@ -0,0 +1,122 @@
+package fb2
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// minPublishYear is the lower bound for publish-info year
+const minPublishYear = 1450
+
+// ErrPublishYear is returned for malformed or out of range publish year
+var ErrPublishYear = errors.New("invalid publish year")
+
+// PublishInfo returns a copy of book publish-info
+func (d *fb2) PublishInfo() DescriptionPublishInfo {
+	d.Lock()
+	defer d.Unlock()
+	pi := d.data.Description.PublishInfo
+	pi.Sequence = copySequences(pi.Sequence)
+	if pi.Isbn != nil {
+		isbn := *pi.Isbn
+		pi.Isbn = &isbn
+	}
+	return pi
+}
+
+// ISBN returns paper edition ISBN
+func (d *fb2) ISBN() string {
+	d.Lock()
+	defer d.Unlock()
+	if d.data.Description.PublishInfo.Isbn == nil {
+		return ""
+	}
+	return d.data.Description.PublishInfo.Isbn.Text
+}
+
+// SetPublishBookName sets paper edition book name
+func (d *fb2) SetPublishBookName(name string) {
+	d.Lock()
+	defer d.Unlock()
+	d.data.Description.PublishInfo.BookName = name
+}
+
+// SetPublisher sets paper edition publisher
+func (d *fb2) SetPublisher(publisher string) {
+	d.Lock()
+	defer d.Unlock()
+	d.data.Description.PublishInfo.Publisher = publisher
+}
+
+// SetPublishCity sets paper edition city
+func (d *fb2) SetPublishCity(city string) {
+	d.Lock()
+	defer d.Unlock()
+	d.data.Description.PublishInfo.City = city
+}
+
+// SetPublishYear validates and sets paper edition year.
+// Empty year clears the value.
+func (d *fb2) SetPublishYear(year string) error {
+	d.Lock()
+	defer d.Unlock()
+	if year != "" {
+		y, err := strconv.Atoi(year)
+		if err != nil || len(year) != 4 || y < minPublishYear || y > time.Now().Year()+1 {
+			return fmt.Errorf("%w: %q", ErrPublishYear, year)
+		}
+	}
+	d.data.Description.PublishInfo.Year = year
+	return nil
+}
+
+// SetISBN validates isbn and stores it as hyphenated ISBN-13.
+// Empty isbn clears the value.
+func (d *fb2) SetISBN(isbn string) error {
+	d.Lock()
+	defer d.Unlock()
+	if isbn == "" {
+		d.data.Description.PublishInfo.Isbn = nil
+		return nil
+	}
+	norm, err := NormalizeISBN(isbn)
+	if err != nil {
+		return fmt.Errorf("set isbn %q error: %w", isbn, err)
+	}
+	d.data.Description.PublishInfo.Isbn = &TextFieldType{Text: norm}
+	return nil
+}
+
+// PublishSequence returns publisher sequences of paper edition
+func (d *fb2) PublishSequence() []SequenceType {
+	d.Lock()
+	defer d.Unlock()
+	return copySequences(d.data.Description.PublishInfo.Sequence)
+}
+
+// SetPublishSequence replaces publisher sequences of paper edition
+// with a single sequence
+func (d *fb2) SetPublishSequence(name string, number int64) {
+	d.Lock()
+	defer d.Unlock()
+	d.data.Description.PublishInfo.Sequence = []SequenceType{NewSequence(name, number)}
+}
+
+// AddPublishSequence appends publisher sequence of paper edition
+func (d *fb2) AddPublishSequence(seq SequenceType) {
+	d.Lock()
+	defer d.Unlock()
+	d.data.Description.PublishInfo.Sequence = append(d.data.Description.PublishInfo.Sequence, seq)
+}
+
+// RemovePublishSequence removes publisher sequences named name
+// at any nesting level. It reports whether any sequence was removed.
+func (d *fb2) RemovePublishSequence(name string) bool {
+	d.Lock()
+	defer d.Unlock()
+	var ok bool
+	d.data.Description.PublishInfo.Sequence, ok = removeSequence(d.data.Description.PublishInfo.Sequence, name)
+	return ok
+}
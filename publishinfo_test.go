@@ -0,0 +1,47 @@
+package fb2
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_fb2_PublishInfo(t *testing.T) {
+	d := NewFB2("Test1Title")
+	d.SetPublisher("АСТ")
+	d.SetPublishCity("Москва")
+	d.SetPublishSequence("Классика", 3)
+	if err := d.SetPublishYear("2005"); err != nil {
+		t.Errorf("fb2.SetPublishYear() error = %v", err)
+	}
+	for _, y := range []string{"05", "1200", "year", "3000"} {
+		if err := d.SetPublishYear(y); err == nil {
+			t.Errorf("fb2.SetPublishYear(%q) expected error", y)
+		}
+	}
+	if err := d.SetISBN("5-17-013452-5"); err != nil {
+		t.Errorf("fb2.SetISBN() error = %v", err)
+	}
+	if err := d.SetISBN("5-17-013452-1"); err == nil {
+		t.Errorf("fb2.SetISBN() expected checksum error")
+	}
+	if got := d.ISBN(); got != "978-5-17-013452-6" {
+		t.Errorf("fb2.ISBN() = %v", got)
+	}
+	d.SetDescription("Test")
+	out, err := d.WriteToString()
+	if err != nil {
+		t.Fatalf("fb2.WriteToString() error = %v", err)
+	}
+	r, err := ReadFB2(strings.NewReader(out))
+	if err != nil {
+		t.Fatalf("ReadFB2() error = %v", err)
+	}
+	pi := r.PublishInfo()
+	if pi.Publisher != "АСТ" || pi.City != "Москва" || pi.Year != "2005" ||
+		pi.BookName != "Test1Title" || len(pi.Sequence) != 1 || pi.Sequence[0].Name != "Классика" {
+		t.Errorf("fb2.PublishInfo() = %+v", pi)
+	}
+	if got := r.ISBN(); got != "978-5-17-013452-6" {
+		t.Errorf("fb2.ISBN() = %v", got)
+	}
+}
@@ -0,0 +1,144 @@
+package fb2
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	etree "github.com/rupor-github/fb2converter/etree"
+)
+
+// ReadFB2 parses FictionBook document from r. Documents in UTF-8
+// and windows-1251 are supported.
+func ReadFB2(r io.Reader) (FB2, error) {
+	doc := etree.NewDocument()
+	doc.ReadSettings.CharsetReader = charsetReader
+	if _, err := doc.ReadFrom(r); err != nil {
+		return nil, fmt.Errorf("read fb2 error: %w", err)
+	}
+	return fromDocument(doc)
+}
+
+// OpenFB2 reads FictionBook document from .fb2 or zipped .fb2 file
+func OpenFB2(srcFilePath string) (FB2, error) {
+	f, err := OpenFB2Stream(srcFilePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadFB2(f)
+}
+
+func fromDocument(doc *etree.Document) (*fb2, error) {
+	root := doc.SelectElement("FictionBook")
+	if root == nil {
+		return nil, errors.New("invalid fb2: no FictionBook root element")
+	}
+	v := &fb2{}
+	desc := root.SelectElement("description")
+	if desc == nil {
+		return nil, errors.New("invalid fb2: no description element")
+	}
+	els, err := readDescription(desc, &v.data.Description)
+	if err != nil {
+		return nil, err
+	}
+	v.annotation, v.srcAnnotation, v.history = els.annotation, els.srcAnnotation, els.history
+	// mixed content is kept as elements, decoded copies would be written twice
+	v.data.Description.TitleInfo.Annotation = AnnotationType{}
+	if v.data.Description.SrcTitleInfo != nil {
+		v.data.Description.SrcTitleInfo.Annotation = AnnotationType{}
+	}
+	v.data.Description.DocumentInfo.History = AnnotationType{}
+	for _, s := range root.SelectElements("stylesheet") {
+		s = s.Copy()
+		s.Space = ""
+		v.stylesheets = append(v.stylesheets, s)
+	}
+	for _, b := range root.SelectElements("body") {
+		if v.body == nil {
+			v.body = b.Copy()
+			continue
+		}
+		v.bodies = append(v.bodies, b.Copy())
+	}
+	if v.body == nil {
+		return nil, errors.New("invalid fb2: no body element")
+	}
+	for _, b := range root.SelectElements("binary") {
+		v.data.Binary = append(v.data.Binary, FictionBookBinary{
+			ContentType: b.SelectAttrValue("content-type", ""),
+			Id:          b.SelectAttrValue("id", ""),
+			Text:        strings.TrimSpace(b.Text()),
+		})
+	}
+	return v, nil
+}
+
+// descElements are description elements with mixed content.
+// encoding/xml can't keep order of text and markup in them,
+// so they are kept as element trees.
+type descElements struct {
+	annotation    *etree.Element
+	srcAnnotation *etree.Element
+	history       *etree.Element
+}
+
+// readDescription decodes description element into d and returns
+// its elements with mixed content
+func readDescription(desc *etree.Element, d *FictionBookDescription) (descElements, error) {
+	if err := unmarshalElement(desc, d); err != nil {
+		return descElements{}, fmt.Errorf("read description error: %w", err)
+	}
+	fixCoverpage(desc.SelectElement("title-info"), &d.TitleInfo)
+	if d.SrcTitleInfo != nil {
+		fixCoverpage(desc.SelectElement("src-title-info"), d.SrcTitleInfo)
+	}
+	return descElements{
+		annotation:    mixedElement(desc, "./title-info/annotation"),
+		srcAnnotation: mixedElement(desc, "./src-title-info/annotation"),
+		history:       mixedElement(desc, "./document-info/history"),
+	}, nil
+}
+
+// mixedElement returns copy of element found by path in desc,
+// nil if it's missing or empty
+func mixedElement(desc *etree.Element, path string) *etree.Element {
+	e := desc.FindElement(path)
+	if e == nil || len(e.ChildElements()) == 0 {
+		return nil
+	}
+	e = e.Copy()
+	e.Space = ""
+	return e
+}
+
+// unmarshalElement decodes etree element into scheme struct
+func unmarshalElement(e *etree.Element, v interface{}) error {
+	doc := etree.NewDocument()
+	doc.SetRoot(e.Copy())
+	b, err := doc.WriteToBytes()
+	if err != nil {
+		return err
+	}
+	return xml.Unmarshal(b, v)
+}
+
+// fixCoverpage restores coverpage image links. encoding/xml can't match
+// namespaced href attribute, so it is read from the element tree.
+func fixCoverpage(ti *etree.Element, info *TitleInfoType) {
+	if ti == nil {
+		return
+	}
+	info.Coverpage = nil
+	for _, img := range ti.FindElements("./coverpage/image") {
+		info.Coverpage = append(info.Coverpage, Coverpage{
+			Image: &InlineImageType{
+				XlinkHref: img.SelectAttrValue("href", ""),
+				Alt:       img.SelectAttrValue("alt", ""),
+			},
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package fb2
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOpenFB2(t *testing.T) {
+	tests := []struct {
+		name      string
+		path      string
+		wantTitle string
+		wantBin   int
+		wantErr   bool
+	}{
+		{
+			name:      "Test1 positive",
+			path:      "./testdata/test1.fb2",
+			wantTitle: "dsa",
+			wantBin:   2,
+			wantErr:   false,
+		},
+		{
+			name:    "Test2 negative no file",
+			path:    "./testdata/none.fb2",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := OpenFB2(tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("OpenFB2() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got := d.Title(); got != tt.wantTitle {
+				t.Errorf("OpenFB2() title = %v, want %v", got, tt.wantTitle)
+			}
+			if got := len(d.Data().Binary); got != tt.wantBin {
+				t.Errorf("OpenFB2() binaries = %v, want %v", got, tt.wantBin)
+			}
+			cp := d.Data().Description.TitleInfo.Coverpage
+			if len(cp) != 1 || cp[0].Image.XlinkHref != "#_cover.jpg" {
+				t.Errorf("OpenFB2() coverpage = %+v", cp)
+			}
+			if _, err := d.WriteToString(); err != nil {
+				t.Errorf("OpenFB2() write error = %v", err)
+			}
+		})
+	}
+}
+
+func TestReadFB2_Negative(t *testing.T) {
+	for _, src := range []string{
+		`<html/>`,
+		`<FictionBook><body/></FictionBook>`,
+		`<FictionBook><description/></FictionBook>`,
+		`<FictionBook`,
+	} {
+		if _, err := ReadFB2(strings.NewReader(src)); err == nil {
+			t.Errorf("ReadFB2(%q) expected error", src)
+		}
+	}
+}
+
+func TestReadFB2_Charset(t *testing.T) {
+	src := "<?xml version=\"1.0\" encoding=\"windows-1251\"?>\n<FictionBook><description><title-info>" +
+		"<book-title>\xca\xed\xe8\xe3\xe0</book-title></title-info></description><body><section><p>x</p></section></body></FictionBook>"
+	d, err := ReadFB2(strings.NewReader(src))
+	if err != nil {
+		t.Fatalf("ReadFB2() error = %v", err)
+	}
+	if got := d.Title(); got != "Книга" {
+		t.Errorf("ReadFB2() title = %q, want %q", got, "Книга")
+	}
+}
+
+func TestReadFB2_MixedDescription(t *testing.T) {
+	src := `<?xml version="1.0" encoding="UTF-8"?>
+<FictionBook xmlns="http://www.gribuser.ru/xml/fictionbook/2.0" xmlns:l="http://www.w3.org/1999/xlink">
+<stylesheet type="text/css">p { margin: 0 }</stylesheet>
+<description>
+<title-info><genre>prose</genre><book-title>Book</book-title>` +
+		`<annotation><p>About <emphasis>this</emphasis> book.</p></annotation><lang>en</lang></title-info>
+<src-title-info><genre>prose</genre><book-title>Buch</book-title>` +
+		`<annotation><p>Über <strong>das</strong> Buch.</p></annotation><lang>de</lang></src-title-info>
+<document-info><author><nickname>me</nickname></author><id>1</id><version>1.0</version>` +
+		`<history><p>v1.0 <strong>created</strong> by me</p></history></document-info>
+</description>
+<body><section><p>Text</p></section></body>
+</FictionBook>`
+	d, err := ReadFB2(strings.NewReader(src))
+	if err != nil {
+		t.Fatalf("ReadFB2() error = %v", err)
+	}
+	d.AddRevision(Revision{Description: "edited", Date: time.Date(2022, 1, 2, 0, 0, 0, 0, time.UTC)})
+	out, err := d.WriteToString()
+	if err != nil {
+		t.Fatalf("fb2.WriteToString() error = %v", err)
+	}
+	for _, want := range []string{
+		`<stylesheet type="text/css">p { margin: 0 }</stylesheet>`,
+		`<p>About <emphasis>this</emphasis> book.</p>`,
+		`<p>Über <strong>das</strong> Buch.</p>`,
+		`<p>v1.0 <strong>created</strong> by me</p>`,
+		`<p>v1.1 — 2022-01-02 — edited</p>`,
+	} {
+		if strings.Count(out, want) != 1 {
+			t.Errorf("fb2.WriteToString() must have single %s:\n%s", want, out)
+		}
+	}
+	for tag, want := range map[string]int{"<stylesheet": 1, "<annotation": 2, "<history": 1} {
+		if n := strings.Count(out, tag); n != want {
+			t.Errorf("fb2.WriteToString() has %d %s elements, want %d:\n%s", n, tag, want, out)
+		}
+	}
+	if i, j := strings.Index(out, "<stylesheet"), strings.Index(out, "<description"); i < 0 || j < i {
+		t.Errorf("stylesheet must precede description:\n%s", out)
+	}
+	r, err := ReadFB2(strings.NewReader(out))
+	if err != nil {
+		t.Fatalf("ReadFB2() error = %v", err)
+	}
+	want := []string{"v1.0 created by me", "v1.1 — 2022-01-02 — edited"}
+	if got := r.History(); !reflect.DeepEqual(got, want) {
+		t.Errorf("fb2.History() = %v, want %v", got, want)
+	}
+}
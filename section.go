@@ -0,0 +1,286 @@
+package fb2
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	etree "github.com/rupor-github/fb2converter/etree"
+)
+
+var (
+	// ErrSectionNotFound is returned when section index or id doesn't exist
+	ErrSectionNotFound = errors.New("section not found")
+	// ErrDuplicateID is returned when element id is already used in book
+	ErrDuplicateID = errors.New("duplicate id")
+)
+
+// SectionInfo describes top level section of the main body
+type SectionInfo struct {
+	Index int
+	ID    string
+	Title string
+	// Sections is the number of nested sections
+	Sections int
+}
+
+// sectionHead are section children kept when section content is replaced
+var sectionHead = []string{"title", "epigraph", "image", "annotation"}
+
+// sectionTitle returns section title as single line
+func sectionTitle(section *etree.Element) string {
+	title := section.SelectElement("title")
+	if title == nil {
+		return ""
+	}
+	return strings.ReplaceAll(plainText(title), "\n", " ")
+}
+
+// sections returns top level sections of the main body
+func (d *fb2) sections() []*etree.Element {
+	return d.body.SelectElements("section")
+}
+
+func (d *fb2) section(i int) (*etree.Element, error) {
+	s := d.sections()
+	if i < 0 || i >= len(s) {
+		return nil, fmt.Errorf("section %d error: %w", i, ErrSectionNotFound)
+	}
+	return s[i], nil
+}
+
+// collectIDs adds ids of e and its descendants to ids
+func collectIDs(e *etree.Element, ids map[string]bool) {
+	if id := e.SelectAttrValue("id", ""); id != "" {
+		ids[id] = true
+	}
+	for _, c := range e.ChildElements() {
+		collectIDs(c, ids)
+	}
+}
+
+// ids returns ids used in all book bodies
+func (d *fb2) ids() map[string]bool {
+	ids := map[string]bool{}
+	collectIDs(d.body, ids)
+	for _, b := range d.bodies {
+		collectIDs(b, ids)
+	}
+	return ids
+}
+
+// checkIDs returns error if elements contain ids already used in book
+// or repeated among elements. Ids of skip subtree are ignored.
+func (d *fb2) checkIDs(skip *etree.Element, elems ...*etree.Element) error {
+	return checkNewIDs(d.ids(), skip, elems...)
+}
+
+// checkTreeIDs is checkIDs for element tree without book, e.g. for
+// section found in Body(). Ids of the whole tree of parent are used.
+func checkTreeIDs(parent, skip *etree.Element, elems ...*etree.Element) error {
+	for parent.Parent() != nil {
+		parent = parent.Parent()
+	}
+	ids := map[string]bool{}
+	collectIDs(parent, ids)
+	return checkNewIDs(ids, skip, elems...)
+}
+
+// checkNewIDs returns error if elements contain ids from ids
+// or repeated among elements. Ids of skip subtree are ignored.
+func checkNewIDs(ids map[string]bool, skip *etree.Element, elems ...*etree.Element) error {
+	used := map[string]bool{}
+	if skip != nil {
+		collectIDs(skip, used)
+	}
+	for id := range used {
+		delete(ids, id)
+	}
+	for _, e := range elems {
+		add := map[string]bool{}
+		collectIDs(e, add)
+		for id := range add {
+			if ids[id] {
+				return fmt.Errorf("id %q error: %w", id, ErrDuplicateID)
+			}
+			ids[id] = true
+		}
+	}
+	return nil
+}
+
+// Sections lists top level sections of the main body
+func (d *fb2) Sections() []SectionInfo {
+	d.Lock()
+	defer d.Unlock()
+	res := []SectionInfo{}
+	for i, s := range d.sections() {
+		res = append(res, SectionInfo{
+			Index:    i,
+			ID:       s.SelectAttrValue("id", ""),
+			Title:    sectionTitle(s),
+			Sections: len(s.SelectElements("section")),
+		})
+	}
+	return res
+}
+
+// Section returns top level section by index. Changes to returned
+// element are applied to the book.
+func (d *fb2) Section(i int) (*etree.Element, error) {
+	d.Lock()
+	defer d.Unlock()
+	return d.section(i)
+}
+
+// SectionByID returns section with id at any nesting level
+func (d *fb2) SectionByID(id string) (*etree.Element, error) {
+	d.Lock()
+	defer d.Unlock()
+	for _, s := range d.body.FindElements("//section") {
+		if s.SelectAttrValue("id", "") == id {
+			return s, nil
+		}
+	}
+	return nil, fmt.Errorf("section %q error: %w", id, ErrSectionNotFound)
+}
+
+// InsertSection inserts section at top level position i.
+// i equal to number of sections appends it.
+func (d *fb2) InsertSection(i int, sectionTitle string, blocks ...Block) error {
+	d.Lock()
+	defer d.Unlock()
+	s := d.sections()
+	if i < 0 || i > len(s) {
+		return fmt.Errorf("insert section %d error: %w", i, ErrSectionNotFound)
+	}
+	section := etree.NewElement("section")
+	section.SetText("\n")
+	section.AddChild(newTitle(sectionTitle).SetTail("\n"))
+	if err := AppendBlocks(section, blocks...); err != nil {
+		return fmt.Errorf("insert section error: %w", err)
+	}
+	if err := d.checkIDs(nil, section); err != nil {
+		return fmt.Errorf("insert section error: %w", err)
+	}
+	var before etree.Token
+	if i < len(s) {
+		before = s[i]
+	}
+	d.body.InsertChild(before, section.SetTail("\n"))
+	d.assignSectionID(section)
+	return nil
+}
+
+// MoveSection moves top level section from position from to position to
+func (d *fb2) MoveSection(from, to int) error {
+	d.Lock()
+	defer d.Unlock()
+	s := d.sections()
+	if from < 0 || from >= len(s) || to < 0 || to >= len(s) {
+		return fmt.Errorf("move section %d to %d error: %w", from, to, ErrSectionNotFound)
+	}
+	if from == to {
+		return nil
+	}
+	section := s[from]
+	d.body.RemoveChild(section)
+	var before etree.Token
+	if to < from {
+		before = s[to]
+	} else if to+1 < len(s) {
+		before = s[to+1]
+	}
+	d.body.InsertChild(before, section)
+	return nil
+}
+
+// ReplaceSectionContent replaces top level section content with blocks.
+// Section title, epigraphs, image and annotation are kept.
+func (d *fb2) ReplaceSectionContent(i int, blocks ...Block) error {
+	d.Lock()
+	defer d.Unlock()
+	section, err := d.section(i)
+	if err != nil {
+		return err
+	}
+	elems := make([]*etree.Element, 0, len(blocks))
+	for _, b := range blocks {
+		e := b.Element()
+		if err := checkTables(e); err != nil {
+			return fmt.Errorf("replace section error: %w", err)
+		}
+		elems = append(elems, e)
+	}
+	body := etree.NewElement("section")
+	for _, c := range section.ChildElements() {
+		if !hasTag(c, sectionHead...) {
+			body.AddChild(c.Copy())
+		}
+	}
+	if err := d.checkIDs(body, elems...); err != nil {
+		return fmt.Errorf("replace section error: %w", err)
+	}
+	for _, c := range section.ChildElements() {
+		if !hasTag(c, sectionHead...) {
+			section.RemoveChild(c)
+		}
+	}
+	if len(section.ChildElements()) == 0 {
+		section.Child = nil
+	}
+	appendElements(section, elems...)
+	return nil
+}
+
+// SetSectionTitle replaces top level section title
+func (d *fb2) SetSectionTitle(i int, lines ...string) error {
+	d.Lock()
+	defer d.Unlock()
+	section, err := d.section(i)
+	if err != nil {
+		return err
+	}
+	title := newTitle(lines...).SetTail("\n")
+	if old := section.SelectElement("title"); old != nil {
+		section.InsertChild(old, title)
+		section.RemoveChild(old)
+		return nil
+	}
+	insertAfterHead(section, title)
+	return nil
+}
+
+// SetSectionID sets top level section id. Empty id removes it.
+func (d *fb2) SetSectionID(i int, id string) error {
+	d.Lock()
+	defer d.Unlock()
+	section, err := d.section(i)
+	if err != nil {
+		return err
+	}
+	if section.SelectAttrValue("id", "") == id {
+		return nil
+	}
+	if id == "" {
+		section.RemoveAttr("id")
+		return nil
+	}
+	if d.ids()[id] {
+		return fmt.Errorf("set section id error: %w", ErrDuplicateID)
+	}
+	section.CreateAttr("id", id)
+	return nil
+}
+
+// RemoveSection deletes top level section
+func (d *fb2) RemoveSection(i int) error {
+	d.Lock()
+	defer d.Unlock()
+	section, err := d.section(i)
+	if err != nil {
+		return err
+	}
+	d.body.RemoveChild(section)
+	return nil
+}
@@ -0,0 +1,149 @@
+package fb2
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func sectionTitles(d FB2) []string {
+	res := []string{}
+	for _, s := range d.Sections() {
+		res = append(res, s.Title)
+	}
+	return res
+}
+
+func Test_fb2_SectionEditing(t *testing.T) {
+	d := NewFB2("Test")
+	d.AddSectionBlocks("One", P(Text("1")))
+	d.AddSectionBlocks("Two", P(Text("2")))
+	if err := d.InsertSection(0, "Zero", P(Text("0"))); err != nil {
+		t.Fatalf("fb2.InsertSection() error = %v", err)
+	}
+	if err := d.InsertSection(3, "Three"); err != nil {
+		t.Fatalf("fb2.InsertSection() error = %v", err)
+	}
+	if err := d.InsertSection(5, "Five"); !errors.Is(err, ErrSectionNotFound) {
+		t.Errorf("fb2.InsertSection() error = %v, want %v", err, ErrSectionNotFound)
+	}
+	if got, want := sectionTitles(d), []string{"Zero", "One", "Two", "Three"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("fb2.Sections() = %v, want %v", got, want)
+	}
+
+	if err := d.MoveSection(0, 2); err != nil {
+		t.Fatalf("fb2.MoveSection() error = %v", err)
+	}
+	if err := d.MoveSection(3, 0); err != nil {
+		t.Fatalf("fb2.MoveSection() error = %v", err)
+	}
+	if got, want := sectionTitles(d), []string{"Three", "One", "Two", "Zero"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("fb2.Sections() after move = %v, want %v", got, want)
+	}
+
+	if err := d.SetSectionID(1, "one"); err != nil {
+		t.Fatalf("fb2.SetSectionID() error = %v", err)
+	}
+	if err := d.SetSectionID(2, "one"); !errors.Is(err, ErrDuplicateID) {
+		t.Errorf("fb2.SetSectionID() error = %v, want %v", err, ErrDuplicateID)
+	}
+	if err := d.SetSectionTitle(1, "First", "part"); err != nil {
+		t.Fatalf("fb2.SetSectionTitle() error = %v", err)
+	}
+	s, err := d.SectionByID("one")
+	if err != nil {
+		t.Fatalf("fb2.SectionByID() error = %v", err)
+	}
+	if got := sectionTitle(s); got != "First part" {
+		t.Errorf("section title = %q, want %q", got, "First part")
+	}
+	if _, err := d.SectionByID("none"); !errors.Is(err, ErrSectionNotFound) {
+		t.Errorf("fb2.SectionByID() error = %v, want %v", err, ErrSectionNotFound)
+	}
+
+	if err := d.AddSectionBlocks("Dup", NewPoem("").SetID("one")); !errors.Is(err, ErrDuplicateID) {
+		t.Errorf("fb2.AddSectionBlocks() error = %v, want %v", err, ErrDuplicateID)
+	}
+	if err := d.AddSection(`<p id="one">dup</p>`, "Dup"); !errors.Is(err, ErrDuplicateID) {
+		t.Errorf("fb2.AddSection() error = %v, want %v", err, ErrDuplicateID)
+	}
+	if got := len(d.Sections()); got != 4 {
+		t.Errorf("fb2.Sections() after duplicate ids = %d sections, want 4", got)
+	}
+	if err := d.ReplaceSectionContent(1, NewPoem("").SetID("one")); !errors.Is(err, ErrDuplicateID) {
+		t.Errorf("fb2.ReplaceSectionContent() error = %v, want %v", err, ErrDuplicateID)
+	}
+	if err := d.ReplaceSectionContent(1, P(Text("new")), EmptyLine()); err != nil {
+		t.Fatalf("fb2.ReplaceSectionContent() error = %v", err)
+	}
+	want := `<section id="one">
+<title>
+<p>First</p>
+<p>part</p>
+</title>
+<p>new</p>
+<empty-line/>
+</section>`
+	if got := elementString(t, s.Copy().SetTail("")); got != want {
+		t.Errorf("replaced section =\n%s\nwant\n%s", got, want)
+	}
+
+	if err := d.RemoveSection(0); err != nil {
+		t.Fatalf("fb2.RemoveSection() error = %v", err)
+	}
+	if err := d.RemoveSection(3); !errors.Is(err, ErrSectionNotFound) {
+		t.Errorf("fb2.RemoveSection() error = %v, want %v", err, ErrSectionNotFound)
+	}
+	got := d.Sections()
+	wantInfo := []SectionInfo{
+		{Index: 0, ID: "one", Title: "First part"},
+		{Index: 1, Title: "Two"},
+		{Index: 2, Title: "Zero"},
+	}
+	if !reflect.DeepEqual(got, wantInfo) {
+		t.Errorf("fb2.Sections() = %+v, want %+v", got, wantInfo)
+	}
+}
+
+func Test_fb2_SectionsLoaded(t *testing.T) {
+	src := `<?xml version="1.0" encoding="UTF-8"?>
+<FictionBook xmlns="http://www.gribuser.ru/xml/fictionbook/2.0" xmlns:l="http://www.w3.org/1999/xlink">
+  <description><title-info><book-title>Loaded</book-title></title-info></description>
+  <body>
+    <section id="s1">
+      <title><p>Part 1</p></title>
+      <section id="s1.1"><title><p>Chapter 1</p></title><p>Text</p></section>
+      <section id="s1.2"><title><p>Chapter 2</p></title><p>Text</p></section>
+    </section>
+    <section><title><p>Part 2</p></title><p>Text</p></section>
+  </body>
+</FictionBook>`
+	d, err := ReadFB2(strings.NewReader(src))
+	if err != nil {
+		t.Fatalf("ReadFB2() error = %v", err)
+	}
+	want := []SectionInfo{
+		{Index: 0, ID: "s1", Title: "Part 1", Sections: 2},
+		{Index: 1, Title: "Part 2"},
+	}
+	if got := d.Sections(); !reflect.DeepEqual(got, want) {
+		t.Errorf("fb2.Sections() = %+v, want %+v", got, want)
+	}
+	s, err := d.SectionByID("s1.2")
+	if err != nil {
+		t.Fatalf("fb2.SectionByID() error = %v", err)
+	}
+	if got := sectionTitle(s); got != "Chapter 2" {
+		t.Errorf("section title = %q, want %q", got, "Chapter 2")
+	}
+	if err := d.SetSectionID(1, "s1.1"); !errors.Is(err, ErrDuplicateID) {
+		t.Errorf("fb2.SetSectionID() error = %v, want %v", err, ErrDuplicateID)
+	}
+	if err := d.MoveSection(1, 0); err != nil {
+		t.Fatalf("fb2.MoveSection() error = %v", err)
+	}
+	if got := d.Sections()[1].ID; got != "s1" {
+		t.Errorf("moved section id = %q, want %q", got, "s1")
+	}
+}
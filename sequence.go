@@ -0,0 +1,82 @@
+package fb2
+
+import "fmt"
+
+// NewSequence returns sequence with name and number.
+// Zero or negative number means the sequence has no number.
+func NewSequence(name string, number int64) SequenceType {
+	seq := SequenceType{Name: name}
+	if number > 0 {
+		seq.Number = fmt.Sprintf("%v", number)
+	}
+	return seq
+}
+
+// AddSequence appends title-info sequence. Nested sequences
+// (a series within a universe) are set in seq.Sequence.
+func (d *fb2) AddSequence(seq SequenceType) {
+	d.Lock()
+	defer d.Unlock()
+	d.data.Description.TitleInfo.Sequence = append(d.data.Description.TitleInfo.Sequence, seq)
+}
+
+// RemoveSequence removes title-info sequences named name
+// at any nesting level. It reports whether any sequence was removed.
+func (d *fb2) RemoveSequence(name string) bool {
+	d.Lock()
+	defer d.Unlock()
+	var ok bool
+	d.data.Description.TitleInfo.Sequence, ok = removeSequence(d.data.Description.TitleInfo.Sequence, name)
+	return ok
+}
+
+// SetSequenceNumber sets number of the first title-info sequence
+// named name, nested sequences are searched too. Zero or negative
+// number removes the number. It reports whether sequence was found.
+func (d *fb2) SetSequenceNumber(name string, number int64) bool {
+	d.Lock()
+	defer d.Unlock()
+	return setSequenceNumber(d.data.Description.TitleInfo.Sequence, name, NewSequence(name, number).Number)
+}
+
+func setSequenceNumber(seqs []SequenceType, name, number string) bool {
+	for i := range seqs {
+		if seqs[i].Name == name {
+			seqs[i].Number = number
+			return true
+		}
+		if setSequenceNumber(seqs[i].Sequence, name, number) {
+			return true
+		}
+	}
+	return false
+}
+
+func removeSequence(seqs []SequenceType, name string) ([]SequenceType, bool) {
+	removed := false
+	res := []SequenceType{}
+	for _, s := range seqs {
+		if s.Name == name {
+			removed = true
+			continue
+		}
+		var ok bool
+		s.Sequence, ok = removeSequence(s.Sequence, name)
+		removed = removed || ok
+		res = append(res, s)
+	}
+	return res, removed
+}
+
+// copySequences returns deep copy of seqs
+func copySequences(seqs []SequenceType) []SequenceType {
+	if seqs == nil {
+		return nil
+	}
+	res := make([]SequenceType, len(seqs))
+	for i, s := range seqs {
+		s.Sequence = copySequences(s.Sequence)
+		res[i] = s
+	}
+	return res
+}
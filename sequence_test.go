@@ -0,0 +1,68 @@
+package fb2
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_fb2_Sequence(t *testing.T) {
+	d, err := OpenFB2("./testdata/test1.fb2")
+	if err != nil {
+		t.Fatalf("OpenFB2() error = %v", err)
+	}
+	if got := d.Sequence(); len(got) != 1 || got[0].String() != "Sdfa: 9" {
+		t.Errorf("fb2.Sequence() = %+v", got)
+	}
+	n := NewFB2("Test1Title")
+	n.SetDescription("Test")
+	n.SetSequence("Discworld", 0)
+	n.AddSequence(SequenceType{
+		Name:     "Universe",
+		Sequence: []SequenceType{NewSequence("Series", 2)},
+	})
+	n.AddPublishSequence(NewSequence("Fantasy Masterworks", 14))
+	out, err := n.WriteToString()
+	if err != nil {
+		t.Fatalf("fb2.WriteToString() error = %v", err)
+	}
+	if !strings.Contains(out, `<sequence name="Discworld"></sequence>`) &&
+		!strings.Contains(out, `<sequence name="Discworld"/>`) {
+		t.Errorf("fb2.WriteToString() sequence without number not found:\n%s", out)
+	}
+	r, err := ReadFB2(strings.NewReader(out))
+	if err != nil {
+		t.Fatalf("ReadFB2() error = %v", err)
+	}
+	want := []SequenceType{
+		{Name: "Discworld"},
+		{Name: "Universe", Sequence: []SequenceType{{Name: "Series", Number: "2"}}},
+	}
+	if got := r.Sequence(); !reflect.DeepEqual(got, want) {
+		t.Errorf("fb2.Sequence() = %+v, want %+v", got, want)
+	}
+	if got := r.PublishSequence(); len(got) != 1 || got[0].String() != "Fantasy Masterworks: 14" {
+		t.Errorf("fb2.PublishSequence() = %+v", got)
+	}
+	if !r.SetSequenceNumber("Series", 5) || r.Sequence()[1].Sequence[0].Number != "5" {
+		t.Errorf("fb2.SetSequenceNumber() nested number not set: %+v", r.Sequence())
+	}
+	if !r.SetSequenceNumber("Discworld", 3) || !r.SetSequenceNumber("Discworld", 0) || r.Sequence()[0].Number != "" {
+		t.Errorf("fb2.SetSequenceNumber() number not removed: %+v", r.Sequence())
+	}
+	if r.SetSequenceNumber("Missing", 1) {
+		t.Errorf("fb2.SetSequenceNumber() set number of missing sequence")
+	}
+	if !r.RemoveSequence("Series") {
+		t.Errorf("fb2.RemoveSequence() nested sequence not removed")
+	}
+	if r.RemoveSequence("Series") {
+		t.Errorf("fb2.RemoveSequence() removed missing sequence")
+	}
+	if got := r.Sequence(); len(got) != 2 || len(got[1].Sequence) != 0 {
+		t.Errorf("fb2.Sequence() = %+v", got)
+	}
+	if !r.RemovePublishSequence("Fantasy Masterworks") || len(r.PublishSequence()) != 0 {
+		t.Errorf("fb2.RemovePublishSequence() failed")
+	}
+}
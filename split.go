@@ -0,0 +1,328 @@
+package fb2
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gofrs/uuid"
+	etree "github.com/rupor-github/fb2converter/etree"
+)
+
+var (
+	// ErrSplitSource is returned when book can't be split
+	ErrSplitSource = errors.New("invalid split source")
+	// ErrSplitRange is returned when split range is out of sections
+	ErrSplitRange = errors.New("invalid split range")
+)
+
+// SplitMode defines how book is split into parts
+type SplitMode int
+
+const (
+	// SplitSections makes a part of each top level section
+	SplitSections SplitMode = iota
+	// SplitSize groups consecutive top level sections into parts
+	// with text not larger than MaxSize
+	SplitSize
+	// SplitRanges makes a part of each section range
+	SplitRanges
+)
+
+// SectionRange is a range of top level sections from From
+// up to but not including To
+type SectionRange struct {
+	From, To int
+}
+
+// SplitOptions configures Split
+type SplitOptions struct {
+	Mode SplitMode
+	// MaxSize is part text size limit in bytes for SplitSize, it must
+	// be positive. Section larger than MaxSize makes a part itself.
+	MaxSize int
+	// Ranges are section ranges for SplitRanges
+	Ranges []SectionRange
+	// Sequence is the parts sequence name, book title if empty
+	Sequence string
+}
+
+// copyTitleInfo returns copy of ti not sharing slices
+func copyTitleInfo(ti TitleInfoType) TitleInfoType {
+	ti.Author = append([]AuthorType{}, ti.Author...)
+	ti.Translator = append([]AuthorType{}, ti.Translator...)
+	ti.Genre = append([]Genre{}, ti.Genre...)
+	ti.Coverpage = append([]Coverpage{}, ti.Coverpage...)
+	ti.Sequence = copySequences(ti.Sequence)
+	return ti
+}
+
+// copyDescription returns copy of description not sharing slices
+func copyDescription(desc FictionBookDescription) FictionBookDescription {
+	desc.TitleInfo = copyTitleInfo(desc.TitleInfo)
+	if desc.SrcTitleInfo != nil {
+		src := copyTitleInfo(*desc.SrcTitleInfo)
+		desc.SrcTitleInfo = &src
+	}
+	di := &desc.DocumentInfo
+	di.Author = append([]AuthorType{}, di.Author...)
+	di.Publisher = append([]AuthorType{}, di.Publisher...)
+	di.SrcUrl = append([]string(nil), di.SrcUrl...)
+	desc.PublishInfo.Sequence = copySequences(desc.PublishInfo.Sequence)
+	if isbn := desc.PublishInfo.Isbn; isbn != nil {
+		v := *isbn
+		desc.PublishInfo.Isbn = &v
+	}
+	desc.CustomInfo = append([]DescriptionCustomInfo(nil), desc.CustomInfo...)
+	desc.Output = append([]ShareInstructionType(nil), desc.Output...)
+	return desc
+}
+
+// Split splits book into parts by top level sections of the main body.
+// Each part gets referenced binaries and notes only and is numbered
+// in the parts sequence added to book sequences.
+func Split(book FB2, opts SplitOptions) ([]FB2, error) {
+	s, ok := book.(*fb2)
+	if !ok || s == nil {
+		return nil, fmt.Errorf("split error: %w", ErrSplitSource)
+	}
+	s.Lock()
+	defer s.Unlock()
+	sections := s.sections()
+	if len(sections) == 0 {
+		return nil, fmt.Errorf("split error: %w: no top level sections", ErrSplitSource)
+	}
+	groups := [][]*etree.Element{}
+	switch opts.Mode {
+	case SplitSize:
+		if opts.MaxSize <= 0 {
+			return nil, fmt.Errorf("split size %d error: %w", opts.MaxSize, ErrSplitRange)
+		}
+		size := 0
+		for _, sec := range sections {
+			l := len(innerText(sec))
+			if len(groups) == 0 || size+l > opts.MaxSize {
+				groups = append(groups, nil)
+				size = 0
+			}
+			groups[len(groups)-1] = append(groups[len(groups)-1], sec)
+			size += l
+		}
+	case SplitRanges:
+		for _, r := range opts.Ranges {
+			if r.From < 0 || r.From >= r.To || r.To > len(sections) {
+				return nil, fmt.Errorf("split range %d-%d error: %w", r.From, r.To, ErrSplitRange)
+			}
+			groups = append(groups, sections[r.From:r.To])
+		}
+	default:
+		for _, sec := range sections {
+			groups = append(groups, []*etree.Element{sec})
+		}
+	}
+	seq := opts.Sequence
+	if seq == "" {
+		seq = s.data.Description.TitleInfo.BookTitle
+	}
+	parts := make([]FB2, 0, len(groups))
+	for i, g := range groups {
+		parts = append(parts, s.part(g, i+1, seq))
+	}
+	return parts, nil
+}
+
+// partSequences returns book sequences with parts sequence added.
+// Sequence with the same name as parts sequence is replaced.
+func partSequences(seqs []SequenceType, part SequenceType) []SequenceType {
+	for i := range seqs {
+		if seqs[i].Name == part.Name {
+			seqs[i] = part
+			return seqs
+		}
+	}
+	return append(seqs, part)
+}
+
+// part returns book with copies of sections numbered n in sequence seq
+func (d *fb2) part(sections []*etree.Element, n int, seq string) *fb2 {
+	p := &fb2{
+		data:          FictionBookScheme{stylesheet: d.data.stylesheet},
+		sectionIDMode: d.sectionIDMode,
+	}
+	p.data.Description = copyDescription(d.data.Description)
+	ti := &p.data.Description.TitleInfo
+	ti.BookTitle = d.partTitle(sections, n)
+	ti.Sequence = partSequences(ti.Sequence, NewSequence(seq, int64(n)))
+	p.data.Description.PublishInfo.BookName = ti.BookTitle
+	p.data.Description.DocumentInfo.Id = uuid.Must(uuid.NewV4()).String()
+	bt := DefaultBodyTitle
+	if d.bodyTitle != nil {
+		bt = *d.bodyTitle
+	}
+	bt.Title = true
+	p.bodyTitle = &bt
+	if d.annotation != nil {
+		p.annotation = d.annotation.Copy()
+	}
+	if d.srcAnnotation != nil {
+		p.srcAnnotation = d.srcAnnotation.Copy()
+	}
+	if d.history != nil {
+		p.history = d.history.Copy()
+	}
+	for _, s := range d.stylesheets {
+		p.stylesheets = append(p.stylesheets, s.Copy())
+	}
+
+	p.body = etree.NewElement("body")
+	p.body.Attr = append(p.body.Attr, d.body.Attr...)
+	p.body.SetText("\n")
+	p.body.SetTail("\n")
+	for _, e := range d.body.ChildElements() {
+		if e.Tag == "section" {
+			break
+		}
+		if n == 1 && e.Tag == "epigraph" {
+			p.body.AddChild(e.Copy().SetTail("\n"))
+		}
+	}
+	for _, sec := range sections {
+		p.body.AddChild(sec.Copy().SetTail("\n"))
+	}
+
+	// notes may refer to other notes, so they are collected
+	// until no new link targets appear
+	refs := map[string]bool{}
+	linkTargets(p.body, refs)
+	if p.annotation != nil {
+		linkTargets(p.annotation, refs)
+	}
+	notes := make([][]*etree.Element, len(d.bodies))
+	for added := true; added; {
+		added = false
+		for i, b := range d.bodies {
+			for _, sec := range b.SelectElements("section") {
+				if id := sec.SelectAttrValue("id", ""); refs[id] && !containsElement(notes[i], sec) {
+					notes[i] = append(notes[i], sec)
+					linkTargets(sec, refs)
+					added = true
+				}
+			}
+		}
+	}
+	for i, b := range d.bodies {
+		if len(notes[i]) == 0 {
+			continue
+		}
+		nb := etree.NewElement("body")
+		nb.Attr = append(nb.Attr, b.Attr...)
+		nb.SetText("\n")
+		nb.SetTail("\n")
+		if title := b.SelectElement("title"); title != nil {
+			nb.AddChild(title.Copy().SetTail("\n"))
+		}
+		// notes keep source order
+		for _, sec := range b.SelectElements("section") {
+			if containsElement(notes[i], sec) {
+				nb.AddChild(sec.Copy().SetTail("\n"))
+			}
+		}
+		p.bodies = append(p.bodies, nb)
+	}
+
+	for _, c := range ti.Coverpage {
+		if c.Image != nil {
+			refs[strings.TrimPrefix(c.Image.XlinkHref, "#")] = true
+		}
+	}
+	for _, b := range d.data.Binary {
+		if refs[b.Id] {
+			p.data.Binary = append(p.data.Binary, b)
+		}
+	}
+	ids := p.ids()
+	unlinkMissing(p.body, ids)
+	for _, b := range p.bodies {
+		unlinkMissing(b, ids)
+	}
+	return p
+}
+
+// partTitle returns title of part made of sections. Single section part
+// is named after the section.
+func (d *fb2) partTitle(sections []*etree.Element, n int) string {
+	if len(sections) == 1 {
+		if t := sectionTitle(sections[0]); t != "" {
+			return t
+		}
+	}
+	return d.data.Description.TitleInfo.BookTitle + " " + strconv.Itoa(n)
+}
+
+// linkTargets adds ids referenced by links and images of e to refs
+func linkTargets(e *etree.Element, refs map[string]bool) {
+	if href := e.SelectAttrValue("href", ""); strings.HasPrefix(href, "#") {
+		refs[href[1:]] = true
+	}
+	for _, c := range e.ChildElements() {
+		linkTargets(c, refs)
+	}
+}
+
+func containsElement(list []*etree.Element, e *etree.Element) bool {
+	for _, v := range list {
+		if v == e {
+			return true
+		}
+	}
+	return false
+}
+
+// unlinkMissing replaces internal links to missing ids with link text
+func unlinkMissing(e *etree.Element, ids map[string]bool) {
+	for _, a := range e.FindElements(".//a") {
+		if href := a.SelectAttrValue("href", ""); strings.HasPrefix(href, "#") && !ids[href[1:]] {
+			unwrapElement(a)
+		}
+	}
+}
+
+// unwrapElement replaces e with its content keeping mixed text
+func unwrapElement(e *etree.Element) {
+	p := e.Parent()
+	for _, t := range append([]etree.Token{}, e.Child...) {
+		switch c := t.(type) {
+		case *etree.CharData:
+			textBefore(p, e, c.Data)
+		case *etree.Element:
+			p.InsertChild(e, c)
+		}
+	}
+	textBefore(p, e, e.Tail())
+	p.RemoveChild(e)
+}
+
+// textBefore adds text to p right before its child t
+func textBefore(p *etree.Element, t etree.Token, s string) {
+	if s == "" {
+		return
+	}
+	for i, c := range p.Child {
+		if c != t {
+			continue
+		}
+		if i > 0 {
+			switch prev := p.Child[i-1].(type) {
+			case *etree.Element:
+				prev.SetTail(prev.Tail() + s)
+				return
+			case *etree.CharData:
+				prev.Data += s
+				return
+			}
+		}
+		p.InsertChild(t, etree.NewCharData(s))
+		return
+	}
+}
@@ -0,0 +1,150 @@
+package fb2
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const splitSrc = `<?xml version="1.0" encoding="UTF-8"?>
+<FictionBook xmlns="http://www.gribuser.ru/xml/fictionbook/2.0" xmlns:l="http://www.w3.org/1999/xlink">
+<description>
+<title-info>
+<genre>prose</genre>
+<author><first-name>Ann</first-name><last-name>Writer</last-name></author>
+<book-title>Big Book</book-title>
+<coverpage><image l:href="#cover.jpg"/></coverpage>
+<lang>en</lang>
+</title-info>
+</description>
+<body>
+<title><p>Big Book</p></title>
+<epigraph><p>Epigraph</p></epigraph>
+<section id="p1"><title><p>Part One</p></title><p>Short<a l:href="#n1" type="note">[1]</a></p></section>
+<section id="p2"><title><p>Part Two</p></title><p>See <a l:href="#p1">part one</a> here.</p><image l:href="#map.png"/></section>
+<section id="p3"><title><p>Part Three</p></title><p>A much longer text of the third part<a l:href="#n2" type="note">[2]</a></p></section>
+</body>
+<body name="notes">
+<title><p>Notes</p></title>
+<section id="n1"><title><p>1</p></title><p>First note, see <a l:href="#n3">3</a></p></section>
+<section id="n2"><title><p>2</p></title><p>Second note</p></section>
+<section id="n3"><title><p>3</p></title><p>Third note</p></section>
+</body>
+<binary id="cover.jpg" content-type="image/jpeg">AAAA</binary>
+<binary id="map.png" content-type="image/png">BBBB</binary>
+</FictionBook>`
+
+func TestSplit(t *testing.T) {
+	book, err := ReadFB2(strings.NewReader(splitSrc))
+	if err != nil {
+		t.Fatalf("ReadFB2() error = %v", err)
+	}
+	tests := []struct {
+		name   string
+		opts   SplitOptions
+		titles []string
+	}{
+		{"sections", SplitOptions{}, []string{"Part One", "Part Two", "Part Three"}},
+		{"size", SplitOptions{Mode: SplitSize, MaxSize: 45}, []string{"Big Book 1", "Part Three"}},
+		{"ranges", SplitOptions{Mode: SplitRanges, Ranges: []SectionRange{{0, 1}, {1, 3}}, Sequence: "Big"}, []string{"Part One", "Big Book 2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parts, err := Split(book, tt.opts)
+			if err != nil {
+				t.Fatalf("Split() error = %v", err)
+			}
+			titles := []string{}
+			for i, p := range parts {
+				titles = append(titles, p.Title())
+				seq := p.Sequence()
+				wantSeq := tt.opts.Sequence
+				if wantSeq == "" {
+					wantSeq = "Big Book"
+				}
+				if len(seq) != 1 || seq[0].Name != wantSeq || seq[0].Number != NewSequence("", int64(i+1)).Number {
+					t.Errorf("part %d sequence = %v", i, seq)
+				}
+				if _, err := p.WriteToString(); err != nil {
+					t.Errorf("part %d WriteToString() error = %v", i, err)
+				}
+			}
+			if !reflect.DeepEqual(titles, tt.titles) {
+				t.Errorf("part titles = %v, want %v", titles, tt.titles)
+			}
+		})
+	}
+	if _, err := Split(book, SplitOptions{Mode: SplitRanges, Ranges: []SectionRange{{2, 4}}}); !errors.Is(err, ErrSplitRange) {
+		t.Errorf("Split() error = %v, want %v", err, ErrSplitRange)
+	}
+	if _, err := Split(book, SplitOptions{Mode: SplitSize}); !errors.Is(err, ErrSplitRange) {
+		t.Errorf("Split() error = %v, want %v", err, ErrSplitRange)
+	}
+	if _, err := Split(NewFB2("Empty"), SplitOptions{}); !errors.Is(err, ErrSplitSource) {
+		t.Errorf("Split() error = %v, want %v", err, ErrSplitSource)
+	}
+}
+
+func TestSplitSequences(t *testing.T) {
+	book, err := ReadFB2(strings.NewReader(splitSrc))
+	if err != nil {
+		t.Fatalf("ReadFB2() error = %v", err)
+	}
+	series := NewSequence("Series", 3)
+	series.Sequence = []SequenceType{NewSequence("Subseries", 1)}
+	book.AddSequence(series)
+	parts, err := Split(book, SplitOptions{Mode: SplitRanges, Ranges: []SectionRange{{0, 1}, {1, 3}}})
+	if err != nil {
+		t.Fatalf("Split() error = %v", err)
+	}
+	want := []SequenceType{series, NewSequence("Big Book", 2)}
+	if got := parts[1].Sequence(); !reflect.DeepEqual(got, want) {
+		t.Errorf("part sequences = %+v, want %+v", got, want)
+	}
+	if got := book.Sequence(); !reflect.DeepEqual(got, []SequenceType{series}) {
+		t.Errorf("book sequences = %+v, want %+v", got, []SequenceType{series})
+	}
+}
+
+func TestSplitReferences(t *testing.T) {
+	book, err := ReadFB2(strings.NewReader(splitSrc))
+	if err != nil {
+		t.Fatalf("ReadFB2() error = %v", err)
+	}
+	parts, err := Split(book, SplitOptions{})
+	if err != nil {
+		t.Fatalf("Split() error = %v", err)
+	}
+	outs := []string{}
+	for _, p := range parts {
+		out, err := p.WriteToString()
+		if err != nil {
+			t.Fatalf("fb2.WriteToString() error = %v", err)
+		}
+		outs = append(outs, out)
+	}
+	tests := []struct {
+		part   int
+		has    []string
+		hasNot []string
+	}{
+		{0, []string{`<epigraph><p>Epigraph</p></epigraph>`, `id="n1"`, `id="n3"`, `id="cover.jpg"`},
+			[]string{`id="n2"`, `id="map.png"`}},
+		{1, []string{`<p>See part one here.</p>`, `id="map.png"`, `id="cover.jpg"`},
+			[]string{`<body name="notes">`, `Epigraph`}},
+		{2, []string{`id="n2"`}, []string{`id="n1"`, `id="n3"`, `id="map.png"`}},
+	}
+	for _, tt := range tests {
+		for _, s := range tt.has {
+			if !strings.Contains(outs[tt.part], s) {
+				t.Errorf("part %d doesn't contain %s:\n%s", tt.part, s, outs[tt.part])
+			}
+		}
+		for _, s := range tt.hasNot {
+			if strings.Contains(outs[tt.part], s) {
+				t.Errorf("part %d contains %s:\n%s", tt.part, s, outs[tt.part])
+			}
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package fb2
+
+import "fmt"
+
+// srcTitleInfo returns src-title-info, creating it if needed
+func (d *fb2) srcTitleInfo() *TitleInfoType {
+	if d.data.Description.SrcTitleInfo == nil {
+		d.data.Description.SrcTitleInfo = &TitleInfoType{
+			Author: []AuthorType{},
+			Genre:  []Genre{},
+		}
+	}
+	return d.data.Description.SrcTitleInfo
+}
+
+// SrcTitle returns original book title
+func (d *fb2) SrcTitle() string {
+	d.Lock()
+	defer d.Unlock()
+	if d.data.Description.SrcTitleInfo == nil {
+		return ""
+	}
+	return d.data.Description.SrcTitleInfo.BookTitle
+}
+
+// SrcAuthors returns original book authors
+func (d *fb2) SrcAuthors() []AuthorType {
+	d.Lock()
+	defer d.Unlock()
+	if d.data.Description.SrcTitleInfo == nil {
+		return nil
+	}
+	return append([]AuthorType{}, d.data.Description.SrcTitleInfo.Author...)
+}
+
+// SrcLang returns source language of translated book
+func (d *fb2) SrcLang() string {
+	d.Lock()
+	defer d.Unlock()
+	if d.data.Description.SrcTitleInfo != nil &&
+		d.data.Description.SrcTitleInfo.Lang != "" {
+		return d.data.Description.SrcTitleInfo.Lang
+	}
+	return d.data.Description.TitleInfo.SrcLang
+}
+
+// SrcGenre returns original book genres
+func (d *fb2) SrcGenre() []string {
+	d.Lock()
+	defer d.Unlock()
+	if d.data.Description.SrcTitleInfo == nil {
+		return nil
+	}
+	return genreCodes(d.data.Description.SrcTitleInfo.Genre)
+}
+
+// SrcSequence returns original book sequence
+func (d *fb2) SrcSequence() []SequenceType {
+	d.Lock()
+	defer d.Unlock()
+	if d.data.Description.SrcTitleInfo == nil {
+		return nil
+	}
+	return copySequences(d.data.Description.SrcTitleInfo.Sequence)
+}
+
+// SetSrcTitle sets original book title
+func (d *fb2) SetSrcTitle(title string) {
+	d.Lock()
+	defer d.Unlock()
+	d.srcTitleInfo().BookTitle = title
+}
+
+// AddSrcAuthor appends original book author
+func (d *fb2) AddSrcAuthor(author AuthorType) {
+	d.Lock()
+	defer d.Unlock()
+	info := d.srcTitleInfo()
+	info.Author = append(info.Author, author)
+}
+
+// SetSrcLang sets source language both in title-info/src-lang
+// and in src-title-info/lang
+func (d *fb2) SetSrcLang(lang string) {
+	d.Lock()
+	defer d.Unlock()
+	d.data.Description.TitleInfo.SrcLang = lang
+	d.srcTitleInfo().Lang = lang
+}
+
+// SetSrcGenre validates and sets original book genres
+func (d *fb2) SetSrcGenre(g []string) error {
+	d.Lock()
+	defer d.Unlock()
+	if err := validateGenres(g); err != nil {
+		return fmt.Errorf("set src genre error: %w", err)
+	}
+	d.srcTitleInfo().Genre = newGenres(g)
+	return nil
+}
+
+// SetSrcSequence sets original book sequence
+func (d *fb2) SetSrcSequence(name string, number int64) {
+	d.Lock()
+	defer d.Unlock()
+	d.srcTitleInfo().Sequence = []SequenceType{NewSequence(name, number)}
+}
@@ -0,0 +1,53 @@
+package fb2
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_fb2_SrcTitleInfo(t *testing.T) {
+	d := NewFB2("Война и мир")
+	if d.SrcTitle() != "" || d.SrcAuthors() != nil || d.SrcSequence() != nil {
+		t.Fatalf("fb2.SrcTitleInfo must be empty for new book")
+	}
+	d.SetLang("en")
+	d.SetSrcTitle("Война и мир")
+	d.AddSrcAuthor(AuthorType{FirstName: "Лев", LastName: "Толстой"})
+	d.SetSrcLang("ru")
+	d.SetSrcGenre([]string{"prose_rus_classic"})
+	d.SetSrcSequence("Собрание", 5)
+	d.SetDescription("Test")
+
+	out, err := d.WriteToString()
+	if err != nil {
+		t.Fatalf("fb2.WriteToString() error = %v", err)
+	}
+	if !strings.Contains(out, "<src-title-info>") || !strings.Contains(out, "<src-lang>ru</src-lang>") {
+		t.Fatalf("fb2.WriteToString() no src-title-info in output:\n%s", out)
+	}
+
+	r, err := ReadFB2(strings.NewReader(out))
+	if err != nil {
+		t.Fatalf("ReadFB2() error = %v", err)
+	}
+	if got := r.SrcTitle(); got != "Война и мир" {
+		t.Errorf("fb2.SrcTitle() = %v", got)
+	}
+	if got := r.SrcAuthors(); len(got) != 1 || got[0].LastName != "Толстой" {
+		t.Errorf("fb2.SrcAuthors() = %v", got)
+	}
+	r.SrcAuthors()[0].LastName = "Changed"
+	if got := r.SrcAuthors(); got[0].LastName != "Толстой" {
+		t.Errorf("fb2.SrcAuthors() must return copy, got %v", got)
+	}
+	if got := r.SrcLang(); got != "ru" {
+		t.Errorf("fb2.SrcLang() = %v", got)
+	}
+	if got := r.SrcGenre(); !reflect.DeepEqual(got, []string{"prose_rus_classic"}) {
+		t.Errorf("fb2.SrcGenre() = %v", got)
+	}
+	if got := r.SrcSequence(); len(got) != 1 || got[0].String() != "Собрание: 5" {
+		t.Errorf("fb2.SrcSequence() = %v", got)
+	}
+}
@@ -0,0 +1,586 @@
+package fb2
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	etree "github.com/rupor-github/fb2converter/etree"
+)
+
+// ErrMalformedXML is returned by StreamReader for broken markup
+var ErrMalformedXML = errors.New("malformed xml")
+
+// EventType is kind of StreamReader event
+type EventType int
+
+const (
+	// EventDescription is emitted for parsed description
+	EventDescription EventType = iota + 1
+	// EventSectionStart is emitted at section start with its title
+	EventSectionStart
+	// EventSectionEnd is emitted at section end
+	EventSectionEnd
+	// EventParagraph is emitted for p, v, subtitle and text-author
+	// elements and for titles of bodies, poems and stanzas
+	EventParagraph
+	// EventBinary is emitted at binary start with reader of its content
+	EventBinary
+)
+
+// Event is StreamReader event
+type Event struct {
+	Type EventType
+	// Description is book description of EventDescription
+	Description *FictionBookDescription
+	// Body is name of body containing section or paragraph,
+	// empty for main body
+	Body string
+	// Depth is nesting depth of section, 1 for top level sections.
+	// Depth of paragraph is depth of its section.
+	Depth int
+	// Id is id of section or binary
+	Id string
+	// Title is section title with lines joined by ". "
+	Title string
+	// Tag is paragraph element tag
+	Tag string
+	// Text is paragraph text, lines of titles and stanzas
+	// are separated by newlines
+	Text string
+	// ContentType is content type of binary
+	ContentType string
+	// Data reads decoded binary content. It's valid until next
+	// call of Next, unread content is skipped.
+	Data io.Reader
+}
+
+// xmlToken is markup token of StreamReader
+type xmlToken struct {
+	// end is set for end tag, text is set for character data
+	end, text   bool
+	selfClosing bool
+	tag         string
+	space       string
+	attr        []etree.Attr
+	data        string
+}
+
+func (t *xmlToken) attrValue(key string) string {
+	for _, a := range t.attr {
+		if a.Key == key {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// StreamReader reads FB2 document as stream of events without
+// building element tree. Binaries are decoded while read, so books
+// of any size are read in constant memory.
+type StreamReader struct {
+	r   *bufio.Reader
+	err error
+	// pushed is token returned again by next call of token
+	pushed *xmlToken
+	// section is section start waiting for its title
+	section *Event
+	// binary is content reader of current binary
+	binary *binaryReader
+	body   string
+	depth  int
+	// skipBodies skips bodies without parsing
+	skipBodies bool
+}
+
+// xmlEncodingRe matches encoding of XML declaration
+var xmlEncodingRe = regexp.MustCompile(`^<\?xml[^>]*encoding=["']([^"']+)["']`)
+
+// NewStreamReader returns streaming reader of FB2 document from r.
+// Documents in UTF-8 and windows-1251 are supported.
+func NewStreamReader(r io.Reader) *StreamReader {
+	br := bufio.NewReader(r)
+	if bom, _ := br.Peek(3); bytes.Equal(bom, []byte("\xef\xbb\xbf")) {
+		br.Discard(3)
+	}
+	s := &StreamReader{r: br}
+	head, _ := br.Peek(256)
+	if m := xmlEncodingRe.FindSubmatch(head); m != nil {
+		cr, err := charsetReader(string(m[1]), br)
+		if err != nil {
+			s.err = fmt.Errorf("stream error: %w", err)
+			return s
+		}
+		if cr != io.Reader(br) {
+			s.r = bufio.NewReader(cr)
+		}
+	}
+	return s
+}
+
+// readUntil reads input until delim and returns it without delim
+func (s *StreamReader) readUntil(delim string) (string, error) {
+	var sb strings.Builder
+	for {
+		line, err := s.r.ReadString(delim[len(delim)-1])
+		sb.WriteString(line)
+		if err != nil {
+			return "", fmt.Errorf("%w: unexpected end of input", ErrMalformedXML)
+		}
+		if strings.HasSuffix(sb.String(), delim) {
+			return strings.TrimSuffix(sb.String(), delim), nil
+		}
+	}
+}
+
+// discardUntil skips input up to delim starting with <
+func (s *StreamReader) discardUntil(delim string) error {
+	matched := 0
+	for matched < len(delim) {
+		c, err := s.r.ReadByte()
+		if err != nil {
+			return fmt.Errorf("%w: unexpected end of input", ErrMalformedXML)
+		}
+		switch {
+		case c == delim[matched]:
+			matched++
+		case c == '<':
+			matched = 1
+		default:
+			matched = 0
+		}
+	}
+	return nil
+}
+
+// readTag reads tag content up to closing > outside of quotes
+func (s *StreamReader) readTag() (string, error) {
+	var sb strings.Builder
+	quote := byte(0)
+	for {
+		c, err := s.r.ReadByte()
+		if err != nil {
+			return "", fmt.Errorf("%w: unexpected end of input", ErrMalformedXML)
+		}
+		switch {
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote == 0 && (c == '"' || c == '\''):
+			quote = c
+		case quote == 0 && c == '>':
+			return sb.String(), nil
+		}
+		sb.WriteByte(c)
+	}
+}
+
+// splitQName splits qualified name into namespace prefix and local name
+func splitQName(name string) (string, string) {
+	if i := strings.IndexByte(name, ':'); i >= 0 {
+		return name[:i], name[i+1:]
+	}
+	return "", name
+}
+
+// parseTag parses start tag content into token
+func parseTag(tag string) (*xmlToken, error) {
+	t := &xmlToken{}
+	if strings.HasSuffix(tag, "/") {
+		t.selfClosing = true
+		tag = tag[:len(tag)-1]
+	}
+	i := strings.IndexAny(tag, " \t\r\n")
+	if i < 0 {
+		i = len(tag)
+	}
+	t.space, t.tag = splitQName(tag[:i])
+	if t.tag == "" {
+		return nil, fmt.Errorf("%w: empty tag name", ErrMalformedXML)
+	}
+	rest := tag[i:]
+	for {
+		rest = strings.TrimLeft(rest, " \t\r\n")
+		if rest == "" {
+			return t, nil
+		}
+		eq := strings.IndexByte(rest, '=')
+		if eq < 0 {
+			return nil, fmt.Errorf("%w: attribute without value in <%s>", ErrMalformedXML, t.tag)
+		}
+		name := strings.TrimSpace(rest[:eq])
+		rest = strings.TrimLeft(rest[eq+1:], " \t\r\n")
+		if rest == "" || (rest[0] != '"' && rest[0] != '\'') {
+			return nil, fmt.Errorf("%w: unquoted attribute in <%s>", ErrMalformedXML, t.tag)
+		}
+		end := strings.IndexByte(rest[1:], rest[0])
+		if end < 0 {
+			return nil, fmt.Errorf("%w: unterminated attribute in <%s>", ErrMalformedXML, t.tag)
+		}
+		space, key := splitQName(name)
+		value, err := unescapeXML(rest[1 : end+1])
+		if err != nil {
+			return nil, err
+		}
+		t.attr = append(t.attr, etree.Attr{Space: space, Key: key, Value: value})
+		rest = rest[end+2:]
+	}
+}
+
+// xmlEntities are predefined XML entities
+var xmlEntities = map[string]string{"lt": "<", "gt": ">", "amp": "&", "apos": "'", "quot": "\""}
+
+// unescapeXML replaces predefined XML entities and character
+// references in s
+func unescapeXML(s string) (string, error) {
+	i := strings.IndexByte(s, '&')
+	if i < 0 {
+		return s, nil
+	}
+	var sb strings.Builder
+	for i >= 0 {
+		sb.WriteString(s[:i])
+		s = s[i+1:]
+		end := strings.IndexByte(s, ';')
+		if end < 0 {
+			return "", fmt.Errorf("%w: unterminated entity", ErrMalformedXML)
+		}
+		name := s[:end]
+		if v, ok := xmlEntities[name]; ok {
+			sb.WriteString(v)
+		} else if r, ok := charRef(name); ok {
+			sb.WriteRune(r)
+		} else {
+			return "", fmt.Errorf("%w: unknown entity &%s;", ErrMalformedXML, name)
+		}
+		s = s[end+1:]
+		i = strings.IndexByte(s, '&')
+	}
+	sb.WriteString(s)
+	return sb.String(), nil
+}
+
+// charRef decodes character reference name like #38 or #x26
+func charRef(name string) (rune, bool) {
+	if !strings.HasPrefix(name, "#") || len(name) < 2 {
+		return 0, false
+	}
+	base, digits := 10, name[1:]
+	if digits[0] == 'x' {
+		base, digits = 16, digits[1:]
+	}
+	n, err := strconv.ParseUint(digits, base, 32)
+	if err != nil || !utf8.ValidRune(rune(n)) {
+		return 0, false
+	}
+	return rune(n), true
+}
+
+// token returns next markup token, skipping declarations and comments
+func (s *StreamReader) token() (*xmlToken, error) {
+	if t := s.pushed; t != nil {
+		s.pushed = nil
+		return t, nil
+	}
+	for {
+		c, err := s.r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		if c != '<' {
+			s.r.UnreadByte()
+			text, err := s.r.ReadString('<')
+			if err == nil {
+				s.r.UnreadByte()
+				text = text[:len(text)-1]
+			}
+			data, err := unescapeXML(text)
+			if err != nil {
+				return nil, err
+			}
+			return &xmlToken{text: true, data: data}, nil
+		}
+		next, err := s.r.Peek(1)
+		if err != nil {
+			return nil, fmt.Errorf("%w: unexpected end of input", ErrMalformedXML)
+		}
+		switch next[0] {
+		case '?':
+			if _, err := s.readUntil("?>"); err != nil {
+				return nil, err
+			}
+		case '!':
+			if p, _ := s.r.Peek(8); string(p) == "![CDATA[" {
+				s.r.Discard(8)
+				text, err := s.readUntil("]]>")
+				if err != nil {
+					return nil, err
+				}
+				return &xmlToken{text: true, data: text}, nil
+			}
+			if p, _ := s.r.Peek(3); string(p) == "!--" {
+				if _, err := s.readUntil("-->"); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			if _, err := s.readTag(); err != nil {
+				return nil, err
+			}
+		case '/':
+			s.r.Discard(1)
+			tag, err := s.readTag()
+			if err != nil {
+				return nil, err
+			}
+			t := &xmlToken{end: true}
+			t.space, t.tag = splitQName(strings.TrimSpace(tag))
+			return t, nil
+		default:
+			tag, err := s.readTag()
+			if err != nil {
+				return nil, err
+			}
+			return parseTag(tag)
+		}
+	}
+}
+
+// readElement reads element started by start into element tree
+func (s *StreamReader) readElement(start *xmlToken) (*etree.Element, error) {
+	root := etree.NewElement(start.tag)
+	root.Space = start.space
+	root.Attr = start.attr
+	if start.selfClosing {
+		return root, nil
+	}
+	cur := root
+	for {
+		t, err := s.token()
+		if err != nil {
+			return nil, endOfInput(err)
+		}
+		switch {
+		case t.text:
+			cur.CreateCharData(t.data)
+		case t.end:
+			if t.tag != cur.Tag || t.space != cur.Space {
+				return nil, mismatchedEnd(cur.Space, cur.Tag, t)
+			}
+			if cur == root {
+				return root, nil
+			}
+			cur = cur.Parent()
+		default:
+			e := cur.CreateElement(t.tag)
+			e.Space = t.space
+			e.Attr = t.attr
+			if !t.selfClosing {
+				cur = e
+			}
+		}
+	}
+}
+
+// readText reads text of element started by start. Paragraphs
+// of titles and stanzas are separated by newlines.
+func (s *StreamReader) readText(start *xmlToken) (string, error) {
+	if start.selfClosing {
+		return "", nil
+	}
+	var sb strings.Builder
+	open := []*xmlToken{start}
+	for len(open) > 0 {
+		t, err := s.token()
+		if err != nil {
+			return "", endOfInput(err)
+		}
+		switch {
+		case t.text:
+			sb.WriteString(t.data)
+		case t.end:
+			if last := open[len(open)-1]; t.tag != last.tag || t.space != last.space {
+				return "", mismatchedEnd(last.space, last.tag, t)
+			}
+			open = open[:len(open)-1]
+		case !t.selfClosing:
+			open = append(open, t)
+			fallthrough
+		default:
+			if t.tag == "p" || t.tag == "v" || t.tag == "empty-line" {
+				sb.WriteString("\n")
+			}
+		}
+	}
+	lines := []string{}
+	for _, l := range strings.Split(sb.String(), "\n") {
+		if l = strings.Join(strings.Fields(l), " "); l != "" {
+			lines = append(lines, l)
+		}
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// mismatchedEnd returns error of end tag t closing other element
+func mismatchedEnd(space, tag string, t *xmlToken) error {
+	if space != "" {
+		tag = space + ":" + tag
+	}
+	end := t.tag
+	if t.space != "" {
+		end = t.space + ":" + end
+	}
+	return fmt.Errorf("%w: <%s> closed by </%s>", ErrMalformedXML, tag, end)
+}
+
+// endOfInput converts EOF inside element into malformed XML error
+func endOfInput(err error) error {
+	if err == io.EOF {
+		return fmt.Errorf("%w: unexpected end of input", ErrMalformedXML)
+	}
+	return err
+}
+
+// binaryReader reads base64 content of binary element up to its
+// end tag, skipping whitespace
+type binaryReader struct {
+	r    *bufio.Reader
+	done bool
+}
+
+func (b *binaryReader) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) && !b.done {
+		c, err := b.r.ReadByte()
+		if err != nil {
+			if n == 0 {
+				return 0, endOfInput(err)
+			}
+			break
+		}
+		switch c {
+		case '<':
+			b.r.UnreadByte()
+			b.done = true
+		case ' ', '\t', '\r', '\n':
+		default:
+			p[n] = c
+			n++
+		}
+	}
+	if n == 0 && b.done {
+		return 0, io.EOF
+	}
+	return n, nil
+}
+
+// Next returns next event. It returns io.EOF at the end of document.
+func (s *StreamReader) Next() (*Event, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	ev, err := s.next()
+	if err != nil && err != io.EOF {
+		err = fmt.Errorf("stream error: %w", err)
+	}
+	if err != nil {
+		s.err = err
+	}
+	return ev, err
+}
+
+func (s *StreamReader) next() (*Event, error) {
+	if s.binary != nil {
+		if _, err := io.Copy(ioutil.Discard, s.binary); err != nil {
+			return nil, err
+		}
+		s.binary = nil
+	}
+	for {
+		t, err := s.token()
+		if err != nil {
+			if s.section != nil && err == io.EOF {
+				err = endOfInput(err)
+			}
+			return nil, err
+		}
+		if t.text {
+			continue
+		}
+		if ev := s.section; ev != nil {
+			s.section = nil
+			if !t.end && t.tag == "title" {
+				title, err := s.readText(t)
+				if err != nil {
+					return nil, err
+				}
+				ev.Title = strings.ReplaceAll(title, "\n", ". ")
+				return ev, nil
+			}
+			s.pushed = t
+			return ev, nil
+		}
+		if t.end {
+			switch t.tag {
+			case "section":
+				s.depth--
+				return &Event{Type: EventSectionEnd, Body: s.body, Depth: s.depth + 1}, nil
+			case "body":
+				s.body = ""
+			}
+			continue
+		}
+		switch t.tag {
+		case "description":
+			desc, err := s.readElement(t)
+			if err != nil {
+				return nil, err
+			}
+			ev := &Event{Type: EventDescription, Description: &FictionBookDescription{}}
+			if _, err := readDescription(desc, ev.Description); err != nil {
+				return nil, err
+			}
+			return ev, nil
+		case "body":
+			if s.skipBodies && !t.selfClosing {
+				end := "</body>"
+				if t.space != "" {
+					end = "</" + t.space + ":body>"
+				}
+				if err := s.discardUntil(end); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			s.body = t.attrValue("name")
+		case "section":
+			s.depth++
+			s.section = &Event{Type: EventSectionStart, Body: s.body, Depth: s.depth, Id: t.attrValue("id")}
+			if t.selfClosing {
+				s.pushed = &xmlToken{end: true, tag: "section"}
+			}
+		case "p", "v", "subtitle", "text-author", "title":
+			text, err := s.readText(t)
+			if err != nil {
+				return nil, err
+			}
+			return &Event{Type: EventParagraph, Body: s.body, Depth: s.depth, Tag: t.tag, Text: text}, nil
+		case "binary":
+			ev := &Event{Type: EventBinary, Id: t.attrValue("id"), ContentType: t.attrValue("content-type")}
+			if t.selfClosing {
+				ev.Data = strings.NewReader("")
+				return ev, nil
+			}
+			s.binary = &binaryReader{r: s.r}
+			ev.Data = base64.NewDecoder(base64.StdEncoding, s.binary)
+			return ev, nil
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package fb2
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// eventString returns short description of event for comparison
+func eventString(t *testing.T, ev *Event) string {
+	t.Helper()
+	switch ev.Type {
+	case EventDescription:
+		return "description " + ev.Description.TitleInfo.BookTitle
+	case EventSectionStart:
+		return fmt.Sprintf("start %s %d %s %q", ev.Body, ev.Depth, ev.Id, ev.Title)
+	case EventSectionEnd:
+		return fmt.Sprintf("end %s %d", ev.Body, ev.Depth)
+	case EventParagraph:
+		return fmt.Sprintf("%s %d %q", ev.Tag, ev.Depth, ev.Text)
+	case EventBinary:
+		data, err := ioutil.ReadAll(ev.Data)
+		if err != nil {
+			t.Fatalf("binary %s read error = %v", ev.Id, err)
+		}
+		return fmt.Sprintf("binary %s %s %d", ev.Id, ev.ContentType, len(data))
+	}
+	return "unknown"
+}
+
+func TestStreamReader(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want []string
+	}{
+		{"book", splitSrc, []string{
+			"description Big Book",
+			`title 0 "Big Book"`, `p 0 "Epigraph"`,
+			`start  1 p1 "Part One"`, `p 1 "Short[1]"`, "end  1",
+			`start  1 p2 "Part Two"`, `p 1 "See part one here."`, "end  1",
+			`start  1 p3 "Part Three"`, `p 1 "A much longer text of the third part[2]"`, "end  1",
+			`title 0 "Notes"`,
+			`start notes 1 n1 "1"`, `p 1 "First note, see 3"`, "end notes 1",
+			`start notes 1 n2 "2"`, `p 1 "Second note"`, "end notes 1",
+			`start notes 1 n3 "3"`, `p 1 "Third note"`, "end notes 1",
+			"binary cover.jpg image/jpeg 3", "binary map.png image/png 3",
+		}},
+		{"nested", `<FictionBook><body><!-- comment --><section><title><p>A</p><p>B &amp; C</p></title>
+<section id="s"><poem><stanza><v>one</v><v>two</v></stanza></poem><p><![CDATA[x < y]]></p></section><section/></section></body>
+<binary id="b" content-type="text/plain">
+ aGVs
+ bG8=
+</binary></FictionBook>`, []string{
+			`start  1  "A. B & C"`, `start  2 s ""`, `v 2 "one"`, `v 2 "two"`, `p 2 "x < y"`, "end  2",
+			`start  2  ""`, "end  2", "end  1", "binary b text/plain 5",
+		}},
+		{"entities", `<FictionBook><body name="a&amp;b"><section><p>&lt;&#65;&#x42;&quot;&apos;&gt; &amp;nbsp;</p></section></body></FictionBook>`,
+			[]string{`start a&b 1  ""`, `p 1 "<AB\"'> &nbsp;"`, "end a&b 1"}},
+		{"windows-1251", "<?xml version=\"1.0\" encoding=\"windows-1251\"?>\n<FictionBook><body><section><p>\xcf\xf0\xe8\xe2\xe5\xf2</p></section></body></FictionBook>",
+			[]string{`start  1  ""`, `p 1 "Привет"`, "end  1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewStreamReader(strings.NewReader(tt.src))
+			got := []string{}
+			for {
+				ev, err := s.Next()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatalf("Next() error = %v", err)
+				}
+				got = append(got, eventString(t, ev))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("events = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStreamReaderBinary(t *testing.T) {
+	// binary of 3 MB is read in small chunks, unread binary is skipped
+	data := bytes.Repeat([]byte("AAAA"), 1<<20)
+	src := io.MultiReader(
+		strings.NewReader(`<FictionBook><binary id="big" content-type="image/png">`),
+		bytes.NewReader(data),
+		strings.NewReader(`</binary><binary id="skipped" content-type="image/png">`),
+		bytes.NewReader(data),
+		strings.NewReader(`</binary><body><section><p>end</p></section></body></FictionBook>`),
+	)
+	s := NewStreamReader(src)
+	ev, err := s.Next()
+	if err != nil || ev.Type != EventBinary {
+		t.Fatalf("Next() = %v, %v", ev, err)
+	}
+	n, err := io.Copy(ioutil.Discard, ev.Data)
+	if err != nil || n != 3<<20 {
+		t.Errorf("binary read = %d, %v, want %d", n, err, 3<<20)
+	}
+	types := []EventType{}
+	for {
+		ev, err := s.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Next() error = %v", err)
+		}
+		types = append(types, ev.Type)
+	}
+	if want := []EventType{EventBinary, EventSectionStart, EventParagraph, EventSectionEnd}; !reflect.DeepEqual(types, want) {
+		t.Errorf("events = %v, want %v", types, want)
+	}
+}
+
+func TestStreamReaderErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+	}{
+		{"unterminated tag", `<FictionBook><body`},
+		{"unterminated paragraph", `<FictionBook><body><section><p>text`},
+		{"unquoted attribute", `<FictionBook><body name=notes>`},
+		{"mismatched end tag", `<FictionBook><body><section><p>a <strong>b</p></strong></section></body></FictionBook>`},
+		{"mismatched description", `<FictionBook><description><title-info></description></title-info></FictionBook>`},
+		{"html entity", `<FictionBook><body><section><p>a&nbsp;b</p></section></body></FictionBook>`},
+		{"bad character reference", `<FictionBook><body><section><p>&#xD800;</p></section></body></FictionBook>`},
+		{"unterminated entity", `<FictionBook><body name="a&b"></body></FictionBook>`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewStreamReader(strings.NewReader(tt.src))
+			var err error
+			for err == nil {
+				_, err = s.Next()
+			}
+			if !errors.Is(err, ErrMalformedXML) {
+				t.Errorf("Next() error = %v, want %v", err, ErrMalformedXML)
+			}
+		})
+	}
+	s := NewStreamReader(strings.NewReader(`<?xml version="1.0" encoding="koi8-r"?><FictionBook/>`))
+	if _, err := s.Next(); err == nil || errors.Is(err, io.EOF) {
+		t.Errorf("Next() error = %v, want unsupported charset", err)
+	}
+}
@@ -0,0 +1,263 @@
+package fb2
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	etree "github.com/rupor-github/fb2converter/etree"
+)
+
+// ErrTableSpan is returned when cell spans don't fit table grid
+var ErrTableSpan = errors.New("invalid table span")
+
+// TableCell is a table cell content and layout
+type TableCell struct {
+	Content []Inline
+	Header  bool
+	Colspan int
+	Rowspan int
+	Align   AlignType
+	Valign  VAlignType
+}
+
+// Cell returns data cell
+func Cell(c ...Inline) TableCell {
+	return TableCell{Content: c}
+}
+
+// HeaderCell returns header (th) cell
+func HeaderCell(c ...Inline) TableCell {
+	return TableCell{Content: c, Header: true}
+}
+
+// WithSpan returns cell spanning colspan columns and rowspan rows
+func (c TableCell) WithSpan(colspan, rowspan int) TableCell {
+	c.Colspan, c.Rowspan = colspan, rowspan
+	return c
+}
+
+// WithAlign returns cell with horizontal and vertical alignment.
+// Empty values are omitted.
+func (c TableCell) WithAlign(align AlignType, valign VAlignType) TableCell {
+	c.Align, c.Valign = align, valign
+	return c
+}
+
+func (c TableCell) spans() (int, int) {
+	cs, rs := c.Colspan, c.Rowspan
+	if cs < 1 {
+		cs = 1
+	}
+	if rs < 1 {
+		rs = 1
+	}
+	return cs, rs
+}
+
+// TableBuilder builds table element
+type TableBuilder struct {
+	id    string
+	style string
+	rows  [][]TableCell
+}
+
+// NewTable returns table builder
+func NewTable() *TableBuilder {
+	return &TableBuilder{}
+}
+
+// TableFromRecords returns table with a row for each record.
+// If header is true the first record is a header row.
+// Short records are padded with empty cells.
+func TableFromRecords(records [][]string, header bool) *TableBuilder {
+	t := NewTable()
+	width := 0
+	for _, r := range records {
+		if len(r) > width {
+			width = len(r)
+		}
+	}
+	for i, r := range records {
+		row := make([]TableCell, width)
+		for j := range row {
+			if j < len(r) {
+				row[j].Content = []Inline{Text(r[j])}
+			}
+			row[j].Header = header && i == 0
+		}
+		t.AddRow(row...)
+	}
+	return t
+}
+
+// TableFromCSV reads CSV records from r and returns table
+func TableFromCSV(r io.Reader, header bool) (*TableBuilder, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	records, err := cr.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("read csv table error: %w", err)
+	}
+	return TableFromRecords(records, header), nil
+}
+
+// SetID sets table id
+func (t *TableBuilder) SetID(id string) *TableBuilder {
+	t.id = id
+	return t
+}
+
+// SetStyle sets table style
+func (t *TableBuilder) SetStyle(style string) *TableBuilder {
+	t.style = style
+	return t
+}
+
+// AddHeaderRow appends row of plain text header cells
+func (t *TableBuilder) AddHeaderRow(cells ...string) *TableBuilder {
+	row := make([]TableCell, 0, len(cells))
+	for _, c := range cells {
+		row = append(row, HeaderCell(Text(c)))
+	}
+	return t.AddRow(row...)
+}
+
+// AddRow appends row
+func (t *TableBuilder) AddRow(cells ...TableCell) *TableBuilder {
+	t.rows = append(t.rows, cells)
+	return t
+}
+
+// Validate checks that cell spans fill rectangular grid
+// without overlaps and don't exceed table rows
+func (t *TableBuilder) Validate() error {
+	rows := make([][][2]int, 0, len(t.rows))
+	for _, row := range t.rows {
+		spans := make([][2]int, 0, len(row))
+		for _, c := range row {
+			cs, rs := c.spans()
+			spans = append(spans, [2]int{cs, rs})
+		}
+		rows = append(rows, spans)
+	}
+	return checkSpans(rows)
+}
+
+// checkSpans checks that colspan and rowspan pairs of row cells
+// fill rectangular grid
+func checkSpans(rows [][][2]int) error {
+	occupied := map[[2]int]bool{}
+	width := -1
+	for r, row := range rows {
+		col := 0
+		for _, c := range row {
+			for occupied[[2]int{r, col}] {
+				col++
+			}
+			cs, rs := c[0], c[1]
+			if r+rs > len(rows) {
+				return fmt.Errorf("%w: row %d column %d rowspan %d exceeds table", ErrTableSpan, r, col, rs)
+			}
+			for i := r; i < r+rs; i++ {
+				for j := col; j < col+cs; j++ {
+					if occupied[[2]int{i, j}] {
+						return fmt.Errorf("%w: row %d column %d overlaps other cell", ErrTableSpan, i, j)
+					}
+					occupied[[2]int{i, j}] = true
+				}
+			}
+			col += cs
+		}
+		w := 0
+		for occupied[[2]int{r, w}] {
+			w++
+		}
+		for k := range occupied {
+			if k[0] == r && k[1] >= w {
+				return fmt.Errorf("%w: row %d has gap at column %d", ErrTableSpan, r, w)
+			}
+		}
+		if width >= 0 && w != width {
+			return fmt.Errorf("%w: row %d has %d columns, want %d", ErrTableSpan, r, w, width)
+		}
+		width = w
+	}
+	return nil
+}
+
+// spanAttr returns colspan or rowspan attribute value, 1 if it's
+// missing or invalid
+func spanAttr(e *etree.Element, name string) int {
+	n, err := strconv.Atoi(e.SelectAttrValue(name, ""))
+	if err != nil || n < 1 {
+		return 1
+	}
+	return n
+}
+
+// checkTables validates cell spans of tables in e and its descendants
+func checkTables(e *etree.Element) error {
+	if e.Tag == "table" {
+		rows := [][][2]int{}
+		for _, tr := range e.SelectElements("tr") {
+			spans := [][2]int{}
+			for _, c := range tr.ChildElements() {
+				if c.Tag == "td" || c.Tag == "th" {
+					spans = append(spans, [2]int{spanAttr(c, "colspan"), spanAttr(c, "rowspan")})
+				}
+			}
+			rows = append(rows, spans)
+		}
+		if err := checkSpans(rows); err != nil {
+			return err
+		}
+	}
+	for _, c := range e.ChildElements() {
+		if err := checkTables(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Element returns table element
+func (t *TableBuilder) Element() *etree.Element {
+	e := etree.NewElement("table")
+	e.SetText("\n")
+	if t.id != "" {
+		e.CreateAttr("id", t.id)
+	}
+	if t.style != "" {
+		e.CreateAttr("style", t.style)
+	}
+	for _, row := range t.rows {
+		tr := e.CreateElement("tr")
+		tr.SetText("\n")
+		for _, c := range row {
+			tag := "td"
+			if c.Header {
+				tag = "th"
+			}
+			td := tr.CreateElement(tag)
+			if c.Colspan > 1 {
+				td.CreateAttr("colspan", strconv.Itoa(c.Colspan))
+			}
+			if c.Rowspan > 1 {
+				td.CreateAttr("rowspan", strconv.Itoa(c.Rowspan))
+			}
+			if c.Align != "" {
+				td.CreateAttr("align", string(c.Align))
+			}
+			if c.Valign != "" {
+				td.CreateAttr("valign", string(c.Valign))
+			}
+			appendInline(td, c.Content...)
+			td.SetTail("\n")
+		}
+		tr.SetTail("\n")
+	}
+	return e
+}
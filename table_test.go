@@ -0,0 +1,95 @@
+package fb2
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	etree "github.com/rupor-github/fb2converter/etree"
+)
+
+func TestTableBuilder_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		table   *TableBuilder
+		wantErr error
+	}{
+		{
+			name: "Spans fit grid",
+			table: NewTable().
+				AddHeaderRow("Year", "Q1", "Q2").
+				AddRow(Cell(Text("2020")).WithSpan(1, 2), Cell(Text("1")), Cell(Text("2"))).
+				AddRow(Cell(Text("3, 4")).WithSpan(2, 1)),
+		},
+		{
+			name: "Rowspan exceeds table",
+			table: NewTable().
+				AddRow(Cell(Text("a")).WithSpan(1, 3), Cell(Text("b"))).
+				AddRow(Cell(Text("c"))),
+			wantErr: ErrTableSpan,
+		},
+		{
+			name: "Row widths differ",
+			table: NewTable().
+				AddRow(Cell(Text("a")), Cell(Text("b"))).
+				AddRow(Cell(Text("c")).WithSpan(3, 1)),
+			wantErr: ErrTableSpan,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.table.Validate(); !errors.Is(err, tt.wantErr) {
+				t.Errorf("TableBuilder.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			section := etree.NewElement("section")
+			cite := NewCite().AddBlock(tt.table)
+			if err := AppendBlocks(section, P(Text("p")), cite); !errors.Is(err, tt.wantErr) {
+				t.Errorf("AppendBlocks() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil && len(section.Child) != 0 {
+				t.Errorf("AppendBlocks() appended blocks of invalid table")
+			}
+			book := NewFB2("Tables")
+			if err := book.AddSectionBlocks("Table", tt.table); !errors.Is(err, tt.wantErr) {
+				t.Errorf("AddSectionBlocks() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTableBuilder_Element(t *testing.T) {
+	table := NewTable().SetID("t1").
+		AddHeaderRow("Name", "Value").
+		AddRow(Cell(Strong(Text("pi"))).WithAlign(AlignTypeLeft, VAlignTypeTop),
+			Cell(Text("3.14")).WithAlign(AlignTypeRight, ""))
+	want := `<table id="t1">
+<tr>
+<th>Name</th>
+<th>Value</th>
+</tr>
+<tr>
+<td align="left" valign="top"><strong>pi</strong></td>
+<td align="right">3.14</td>
+</tr>
+</table>`
+	if got := elementString(t, table.Element()); got != want {
+		t.Errorf("TableBuilder.Element() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestTableFromCSV(t *testing.T) {
+	table, err := TableFromCSV(strings.NewReader("city,population\nMoscow,12.6\nKazan\n"), true)
+	if err != nil {
+		t.Fatalf("TableFromCSV() error = %v", err)
+	}
+	if err := table.Validate(); err != nil {
+		t.Errorf("TableBuilder.Validate() error = %v", err)
+	}
+	got := elementString(t, table.Element())
+	if !strings.Contains(got, "<th>city</th>") || !strings.Contains(got, "<td>Kazan</td>\n<td/>") {
+		t.Errorf("TableFromCSV() =\n%s", got)
+	}
+	if _, err := TableFromCSV(strings.NewReader("a,\"b\n"), false); err == nil {
+		t.Errorf("TableFromCSV() expected error")
+	}
+}
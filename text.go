@@ -0,0 +1,169 @@
+package fb2
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	etree "github.com/rupor-github/fb2converter/etree"
+)
+
+var (
+	// textChapterRe matches chapter headings of plain text books
+	textChapterRe = regexp.MustCompile(`(?i)^(?:(?:глава|часть|книга|розділ|частина|chapter|part|book|kapitel|teil)\s+(?:\d+|[ivxlcdm]+)\b.*|пролог|эпилог|епілог|prologue|epilogue|prolog|epilog)$`)
+	// textNumberRe matches headings made of chapter number only
+	textNumberRe = regexp.MustCompile(`^(?:\d+|[IVXLCDM]+)\.?$`)
+	// textSeparatorRe matches scene separators like * * *
+	textSeparatorRe = regexp.MustCompile(`^(?:\*\s*){3,}$`)
+)
+
+// maxHeadingLen is the longest plain text line taken as chapter heading
+const maxHeadingLen = 80
+
+// isTextHeading reports whether plain text line is chapter heading
+func isTextHeading(line string) bool {
+	if utf8.RuneCountInString(line) > maxHeadingLen {
+		return false
+	}
+	return textChapterRe.MatchString(line) || textNumberRe.MatchString(line)
+}
+
+// textParagraphs splits plain text into paragraphs. Text with blank
+// lines between paragraphs is split on them, otherwise each line
+// is a paragraph. Chapter headings are always separate paragraphs.
+func textParagraphs(text string) []string {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	blank, filled := 0, 0
+	for _, l := range lines {
+		if strings.TrimSpace(l) == "" {
+			blank++
+		} else {
+			filled++
+		}
+	}
+	byBlank := blank*4 >= filled
+	res := []string{}
+	cur := []string{}
+	flush := func() {
+		if len(cur) != 0 {
+			res = append(res, strings.Join(cur, " "))
+			cur = nil
+		}
+	}
+	for _, l := range lines {
+		l = strings.Join(strings.Fields(l), " ")
+		switch {
+		case l == "":
+			flush()
+		case isTextHeading(l) || textSeparatorRe.MatchString(l):
+			flush()
+			res = append(res, l)
+		default:
+			cur = append(cur, l)
+			if !byBlank {
+				flush()
+			}
+		}
+	}
+	flush()
+	return res
+}
+
+// ReadText converts plain text book into FB2. Chapters are detected
+// by headings like "Chapter 1" or "Глава 1". Text is expected in UTF-8,
+// invalid UTF-8 is decoded as windows-1251.
+func ReadText(r io.Reader) (FB2, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("read text error: %w", err)
+	}
+	d := NewFB2("").(*fb2)
+	var section *etree.Element
+	for _, p := range textParagraphs(decodeText(b)) {
+		heading := isTextHeading(p)
+		if section == nil || heading {
+			section = newBlockChild(d.body, "section")
+			section.SetText("\n")
+			if heading {
+				section.AddChild(newTitle(p).SetTail("\n"))
+				continue
+			}
+		}
+		if textSeparatorRe.MatchString(p) {
+			newBlockChild(section, "subtitle").SetText("* * *")
+			continue
+		}
+		newBlockChild(section, "p").SetText(p)
+	}
+	return d, nil
+}
+
+// OpenText converts plain text book file into FB2 like ReadText
+func OpenText(srcFilePath string) (FB2, error) {
+	f, err := os.Open(srcFilePath)
+	if err != nil {
+		return nil, fmt.Errorf("open text error: %w", err)
+	}
+	defer f.Close()
+	return ReadText(f)
+}
+
+// textWriter renders FB2 elements as plain text blocks
+type textWriter struct {
+	blocks []string
+}
+
+func (t *textWriter) add(s string) {
+	if s = strings.TrimSpace(s); s != "" {
+		t.blocks = append(t.blocks, s)
+	}
+}
+
+func (t *textWriter) block(e *etree.Element) {
+	switch e.Tag {
+	case "p", "subtitle", "text-author", "date":
+		t.add(innerText(e))
+	case "title", "stanza":
+		t.add(plainText(e))
+	case "image":
+		if alt := e.SelectAttrValue("alt", ""); alt != "" {
+			t.add("[" + alt + "]")
+		}
+	case "table":
+		rows := []string{}
+		for _, tr := range e.SelectElements("tr") {
+			cells := []string{}
+			for _, c := range tr.ChildElements() {
+				cells = append(cells, strings.TrimSpace(innerText(c)))
+			}
+			rows = append(rows, strings.Join(cells, "\t"))
+		}
+		t.add(strings.Join(rows, "\n"))
+	case "empty-line":
+	default:
+		for _, c := range e.ChildElements() {
+			t.block(c)
+		}
+	}
+}
+
+// WriteText writes book as plain text with paragraphs separated
+// by blank lines. Notes are written after the main text.
+func (d *fb2) WriteText(w io.Writer) error {
+	d.Lock()
+	defer d.Unlock()
+	body, bodies := d.exportBodies()
+	t := &textWriter{}
+	t.block(body)
+	for _, b := range bodies {
+		t.block(b)
+	}
+	if _, err := io.WriteString(w, strings.Join(t.blocks, "\n\n")+"\n"); err != nil {
+		return fmt.Errorf("write text error: %w", err)
+	}
+	return nil
+}
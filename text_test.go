@@ -0,0 +1,58 @@
+package fb2
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadText(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want [][]string
+	}{
+		{
+			"lines",
+			"Глава 1\nПервая строка.\nВторая строка.\n* * *\nТретья.\nГлава 2\nКонец.",
+			[][]string{{"Глава 1", "Первая строка.", "Вторая строка.", "* * *", "Третья."}, {"Глава 2", "Конец."}},
+		},
+		{
+			"blank lines",
+			"Foreword\n\nChapter 1\n\nFirst\nparagraph.\n\nSecond.\n\nII\n\nLast.",
+			[][]string{{"Foreword"}, {"Chapter 1", "First paragraph.", "Second."}, {"II", "Last."}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			book, err := ReadText(strings.NewReader(tt.src))
+			if err != nil {
+				t.Fatalf("ReadText() error = %v", err)
+			}
+			got := [][]string{}
+			for _, s := range book.(*fb2).body.SelectElements("section") {
+				got = append(got, strings.Split(plainText(s), "\n"))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ReadText() sections = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteText(t *testing.T) {
+	book, err := ReadFB2(strings.NewReader(splitSrc))
+	if err != nil {
+		t.Fatalf("ReadFB2() error = %v", err)
+	}
+	var sb strings.Builder
+	if err := book.WriteText(&sb); err != nil {
+		t.Fatalf("WriteText() error = %v", err)
+	}
+	want := "Big Book\n\nEpigraph\n\nPart One\n\nShort[1]\n\nPart Two\n\nSee part one here.\n\n" +
+		"Part Three\n\nA much longer text of the third part[2]\n\n" +
+		"Notes\n\n1\n\nFirst note, see 3\n\n2\n\nSecond note\n\n3\n\nThird note\n"
+	if sb.String() != want {
+		t.Errorf("WriteText() = %q, want %q", sb.String(), want)
+	}
+}
@@ -0,0 +1,105 @@
+package fb2
+
+import (
+	"strings"
+
+	etree "github.com/rupor-github/fb2converter/etree"
+)
+
+// TOCPosition is the place of generated table of contents in body
+type TOCPosition int
+
+const (
+	// TOCStart places table of contents after body title and epigraphs
+	TOCStart TOCPosition = iota
+	// TOCEnd places table of contents after the last section
+	TOCEnd
+)
+
+// tocIndent indents nested table of contents entries with
+// non-breaking spaces, readers collapse regular ones
+const tocIndent = "\u00a0\u00a0\u00a0\u00a0"
+
+// TOC configures table of contents section generated
+// each time the book is written
+type TOC struct {
+	// Title is the heading text, "Contents" if empty
+	Title string
+	// Position is the place of table of contents in body
+	Position TOCPosition
+	// MaxDepth limits nesting of listed sections, 0 lists all
+	MaxDepth int
+	// ID is the table of contents section id, "toc" if empty
+	ID string
+}
+
+// SetTOC sets table of contents template. Nil template disables
+// table of contents generation.
+func (d *fb2) SetTOC(t *TOC) {
+	d.Lock()
+	defer d.Unlock()
+	if t == nil {
+		d.toc = nil
+		return
+	}
+	toc := *t
+	if toc.Title == "" {
+		toc.Title = "Contents"
+	}
+	if toc.ID == "" {
+		toc.ID = "toc"
+	}
+	d.toc = &toc
+}
+
+// tocEntries appends links to titled sections of parent to toc.
+// Sections without ids get generated ones.
+func (d *fb2) tocEntries(toc, parent *etree.Element, depth int, ids map[string]bool) {
+	if d.toc.MaxDepth > 0 && depth >= d.toc.MaxDepth {
+		return
+	}
+	mode := d.sectionIDMode
+	if mode == SectionIDNone {
+		mode = SectionIDTitle
+	}
+	for _, s := range parent.SelectElements("section") {
+		if title := sectionTitle(s); title != "" {
+			id := s.SelectAttrValue("id", "")
+			if id == "" {
+				id = newID(s, mode, ids)
+				s.CreateAttr("id", id)
+			}
+			c := []Inline{InternalLink(id, Text(title))}
+			if depth > 0 {
+				c = append([]Inline{Text(strings.Repeat(tocIndent, depth))}, c...)
+			}
+			appendBlocks(toc, P(c...))
+		}
+		d.tocEntries(toc, s, depth+1, ids)
+	}
+}
+
+// writeTOC replaces table of contents section of body with
+// generated one. Section with the same id as table of contents
+// is treated as previously generated one.
+func (d *fb2) writeTOC(body *etree.Element, ids map[string]bool) {
+	if d.toc == nil {
+		return
+	}
+	for _, s := range body.SelectElements("section") {
+		if s.SelectAttrValue("id", "") == d.toc.ID {
+			body.RemoveChild(s)
+		}
+	}
+	ids[d.toc.ID] = true
+	toc := etree.NewElement("section")
+	toc.CreateAttr("id", d.toc.ID)
+	toc.SetText("\n")
+	toc.AddChild(newTitle(d.toc.Title).SetTail("\n"))
+	d.tocEntries(toc, body, 0, ids)
+	if d.toc.Position == TOCEnd {
+		body.AddChild(toc.SetTail("\n"))
+		return
+	}
+	insertAfterHead(body, toc, "image", "title", "epigraph")
+}
@@ -0,0 +1,116 @@
+package fb2
+
+import (
+	"strings"
+	"testing"
+
+	etree "github.com/rupor-github/fb2converter/etree"
+)
+
+func Test_fb2_TOC(t *testing.T) {
+	newBook := func() FB2 {
+		d := NewFB2("Книга")
+		d.AddSectionBlocks("Часть 1", P(Text("1")))
+		s, _ := d.Section(0)
+		AppendBlocks(s, &blockElement{e: newSection("Глава 1", "ch1", newSection("Подглава", ""))})
+		d.AddSectionBlocks("Часть 2", P(Text("2")))
+		return d
+	}
+	tests := []struct {
+		name string
+		toc  *TOC
+		want string
+	}{
+		{
+			name: "start",
+			toc:  &TOC{},
+			want: `<body>
+<title>
+<p>Книга</p>
+</title>
+<section id="toc">
+<title>
+<p>Contents</p>
+</title>
+<p><a l:href="#chast-1">Часть 1</a></p>
+<p>` + tocIndent + `<a l:href="#ch1">Глава 1</a></p>
+<p>` + tocIndent + tocIndent + `<a l:href="#podglava">Подглава</a></p>
+<p><a l:href="#chast-2">Часть 2</a></p>
+</section>
+<section id="chast-1">`,
+		},
+		{
+			name: "end with depth",
+			toc:  &TOC{Title: "Оглавление", Position: TOCEnd, MaxDepth: 1, ID: "contents"},
+			want: `<section id="contents">
+<title>
+<p>Оглавление</p>
+</title>
+<p><a l:href="#chast-1">Часть 1</a></p>
+<p><a l:href="#chast-2">Часть 2</a></p>
+</section>
+</body>`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := newBook()
+			d.SetTOC(tt.toc)
+			for i := 0; i < 2; i++ {
+				out, err := d.WriteToString()
+				if err != nil {
+					t.Fatalf("fb2.WriteToString() error = %v", err)
+				}
+				if !strings.Contains(out, tt.want) {
+					t.Fatalf("fb2.WriteToString() doesn't contain\n%s\n%s", tt.want, out)
+				}
+				if n := strings.Count(out, "#chast-2"); n != 1 {
+					t.Errorf("table of contents generated %d times", n)
+				}
+			}
+			if got := sectionIDs(d); got[0] != "" {
+				t.Errorf("book sections changed by table of contents: %v", got)
+			}
+		})
+	}
+}
+
+func Test_fb2_TOCReload(t *testing.T) {
+	d := NewFB2("Книга")
+	d.AddSectionBlocks("Часть 1", P(Text("1")))
+	d.SetTOC(&TOC{})
+	out, err := d.WriteToString()
+	if err != nil {
+		t.Fatalf("fb2.WriteToString() error = %v", err)
+	}
+	r, err := ReadFB2(strings.NewReader(out))
+	if err != nil {
+		t.Fatalf("ReadFB2() error = %v", err)
+	}
+	r.AddSectionBlocks("Часть 2", P(Text("2")))
+	r.SetTOC(&TOC{})
+	out, err = r.WriteToString()
+	if err != nil {
+		t.Fatalf("fb2.WriteToString() error = %v", err)
+	}
+	if n := strings.Count(out, `<section id="toc">`); n != 1 {
+		t.Errorf("table of contents count = %d, want 1", n)
+	}
+	if !strings.Contains(out, `<p><a l:href="#chast-2">Часть 2</a></p>`) {
+		t.Errorf("table of contents is not regenerated:\n%s", out)
+	}
+}
+
+// newSection returns section element with title, id and subsections
+func newSection(title, id string, sub ...*etree.Element) *etree.Element {
+	s := etree.NewElement("section")
+	if id != "" {
+		s.CreateAttr("id", id)
+	}
+	s.SetText("\n")
+	s.AddChild(newTitle(title).SetTail("\n"))
+	for _, e := range sub {
+		s.AddChild(e.SetTail("\n"))
+	}
+	return s
+}
@@ -0,0 +1,66 @@
+package fb2
+
+import (
+	"strings"
+	"unicode"
+)
+
+// translitTable maps cyrillic letters to latin
+var translitTable = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "e",
+	'ж': "zh", 'з': "z", 'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m",
+	'н': "n", 'о': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u",
+	'ф': "f", 'х': "kh", 'ц': "ts", 'ч': "ch", 'ш': "sh", 'щ': "shch",
+	'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "yu", 'я': "ya",
+	'є': "ye", 'і': "i", 'ї': "yi", 'ґ': "g", 'ў': "u",
+}
+
+// transliterate replaces cyrillic letters with latin ones
+func transliterate(s string) string {
+	var sb strings.Builder
+	for _, r := range s {
+		lr := unicode.ToLower(r)
+		t, ok := translitTable[lr]
+		if !ok {
+			sb.WriteRune(r)
+			continue
+		}
+		if lr != r && t != "" {
+			t = strings.ToUpper(t[:1]) + t[1:]
+		}
+		sb.WriteString(t)
+	}
+	return sb.String()
+}
+
+// maxSlugLen limits length of ids generated from titles
+const maxSlugLen = 48
+
+// slugID returns id made of transliterated lowercase words of s
+// joined with hyphens. Ids can't start with a digit.
+func slugID(s string) string {
+	var sb strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(transliterate(s)) {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			if dash && sb.Len() > 0 {
+				sb.WriteByte('-')
+			}
+			dash = false
+			sb.WriteRune(r)
+			continue
+		}
+		dash = true
+	}
+	id := sb.String()
+	if len(id) > maxSlugLen {
+		id = id[:maxSlugLen]
+		if i := strings.LastIndexByte(id, '-'); i > 0 {
+			id = id[:i]
+		}
+	}
+	if id != "" && unicode.IsDigit(rune(id[0])) {
+		id = "s-" + id
+	}
+	return id
+}
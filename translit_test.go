@@ -0,0 +1,25 @@
+package fb2
+
+import "testing"
+
+func Test_slugID(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"Глава первая", "glava-pervaya"},
+		{"Щука и Ёж — «Сказка»", "shchuka-i-ezh-skazka"},
+		{"Їжак і Ґудзик", "yizhak-i-gudzik"},
+		{"Chapter 1. The Beginning", "chapter-1-the-beginning"},
+		{"1984", "s-1984"},
+		{"!!!", ""},
+		{"Очень длинное название главы, которое не помещается в идентификатор", "ochen-dlinnoe-nazvanie-glavy-kotoroe-ne"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			if got := slugID(tt.in); got != tt.want {
+				t.Errorf("slugID() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,314 @@
+package fb2
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	etree "github.com/rupor-github/fb2converter/etree"
+)
+
+// TypoRule is a set of typography normalization rules
+type TypoRule uint
+
+const (
+	// TypoSpaces collapses repeated spaces and removes spaces
+	// before punctuation and after opening brackets
+	TypoSpaces TypoRule = 1 << iota
+	// TypoEllipsis replaces three dots with ellipsis
+	TypoEllipsis
+	// TypoDashes replaces spaced hyphens with dashes and dialogue
+	// hyphens at paragraph start with em-dash
+	TypoDashes
+	// TypoQuotes replaces straight quotes with language quotes
+	// and apostrophes
+	TypoQuotes
+	// TypoNbsp binds short words to the next word and adds French
+	// spaces before punctuation with non-breaking spaces
+	TypoNbsp
+	// TypoAll enables all rules
+	TypoAll = TypoSpaces | TypoEllipsis | TypoDashes | TypoQuotes | TypoNbsp
+)
+
+const nbsp = '\u00a0'
+
+// typoLang are language typography conventions
+type typoLang struct {
+	// quotes are open and close quotes of outer and inner level
+	quotes [2][2]string
+	// dash replaces hyphen surrounded by spaces
+	dash string
+	// dialogue replaces hyphen starting paragraph, empty keeps it
+	dialogue string
+	// french adds non-breaking spaces before ; : ! ? and inside « »
+	french bool
+	// short are prepositions and conjunctions bound to the next word
+	short map[string]bool
+}
+
+func wordSet(words string) map[string]bool {
+	res := map[string]bool{}
+	for _, w := range strings.Fields(words) {
+		res[w] = true
+	}
+	return res
+}
+
+var typoLangs = map[string]typoLang{
+	"ru": {
+		quotes:   [2][2]string{{"«", "»"}, {"„", "“"}},
+		dash:     "\u00a0— ",
+		dialogue: "—\u00a0",
+		short:    wordSet("а в во до за и из к ко на над не ни но о об обо от по под при про с со у"),
+	},
+	"en": {
+		quotes: [2][2]string{{"“", "”"}, {"‘", "’"}},
+		dash:   "—",
+		short:  wordSet("a an and at by for in of on or the to"),
+	},
+	"de": {
+		quotes: [2][2]string{{"„", "“"}, {"‚", "‘"}},
+		dash:   "\u00a0– ",
+		short:  wordSet("am an auf aus bei das der die ein im in mit um und von vom zu zum zur"),
+	},
+	"fr": {
+		quotes:   [2][2]string{{"«\u00a0", "\u00a0»"}, {"“", "”"}},
+		dash:     "\u00a0— ",
+		dialogue: "—\u00a0",
+		french:   true,
+		short:    wordSet("à au aux de des du en et la le les ou par un une"),
+	},
+}
+
+func init() {
+	uk := typoLangs["ru"]
+	uk.short = wordSet("а в до з за і й із на над не ні по під при про та у що")
+	typoLangs["uk"] = uk
+	be := typoLangs["ru"]
+	be.short = wordSet("а ад в да з за і на над не ні па пад пры праз у ў")
+	typoLangs["be"] = be
+}
+
+var (
+	typoSpacesRe      = regexp.MustCompile(`[ \t\r\n]+`)
+	typoBeforePunctRe = regexp.MustCompile(` +([,.;:!?…)\]])`)
+	typoAfterOpenRe   = regexp.MustCompile(`([(\[«„]) +`)
+	typoAfterOpenFrRe = regexp.MustCompile(`([(\[„]) +`)
+	typoEllipsisRe    = regexp.MustCompile(`\.{3,}`)
+	typoDashRe        = regexp.MustCompile(`[ \x{00a0}]+(?:--?|–|—)[ \x{00a0}]+`)
+	typoDialogueRe    = regexp.MustCompile(`^(?:--?|–|—)[ \x{00a0}]+`)
+	typoFrenchRe      = regexp.MustCompile(`[ \x{00a0}]?([;:!?]+)(\s|$)`)
+	typoFrenchOpenRe  = regexp.MustCompile(`«[ \x{00a0}]*`)
+	typoFrenchCloseRe = regexp.MustCompile(`[ \x{00a0}]*»`)
+)
+
+// typoState keeps normalization context between text pieces
+// of one paragraph
+type typoState struct {
+	lang  typoLang
+	known bool
+	rules TypoRule
+	// prev is the last rune of previous text piece
+	prev rune
+	// depth is the current quotes nesting level
+	depth int
+}
+
+func newTypoState(lang string, rules TypoRule) *typoState {
+	l, ok := typoLangs[baseLang(lang)]
+	return &typoState{lang: l, known: ok, rules: rules}
+}
+
+// NormalizeText applies typography rules of language lang to s.
+// Quotes, dashes and non-breaking spaces are only changed for
+// ru, uk, be, en, de and fr.
+func NormalizeText(s, lang string, rules TypoRule) string {
+	s = newTypoState(lang, rules).text(s)
+	if rules&TypoSpaces != 0 {
+		s = strings.TrimRight(s, " ")
+	}
+	return s
+}
+
+// text normalizes next text piece
+func (st *typoState) text(s string) string {
+	if s == "" {
+		return s
+	}
+	if st.rules&TypoSpaces != 0 {
+		s = typoSpacesRe.ReplaceAllString(s, " ")
+		if st.prev == 0 || st.prev == ' ' {
+			s = strings.TrimLeft(s, " ")
+		}
+		s = typoBeforePunctRe.ReplaceAllString(s, "$1")
+		if st.lang.french {
+			// spaces inside guillemets are kept for non-breaking ones
+			s = typoAfterOpenFrRe.ReplaceAllString(s, "$1")
+		} else {
+			s = typoAfterOpenRe.ReplaceAllString(s, "$1")
+		}
+	}
+	if st.rules&TypoEllipsis != 0 {
+		s = typoEllipsisRe.ReplaceAllString(s, "…")
+	}
+	if st.known && st.rules&TypoDashes != 0 {
+		if st.prev == 0 && st.lang.dialogue != "" {
+			s = typoDialogueRe.ReplaceAllString(s, st.lang.dialogue)
+		}
+		s = typoDashRe.ReplaceAllString(s, st.lang.dash)
+	}
+	if st.known && st.rules&TypoQuotes != 0 {
+		s = st.quotes(s)
+	}
+	if st.known && st.rules&TypoNbsp != 0 {
+		s = st.shortWords(s)
+		if st.lang.french {
+			s = typoFrenchRe.ReplaceAllString(s, "\u00a0$1$2")
+			s = typoFrenchOpenRe.ReplaceAllString(s, "«\u00a0")
+			s = typoFrenchCloseRe.ReplaceAllString(s, "\u00a0»")
+		}
+	}
+	st.skip(s)
+	return s
+}
+
+// skip keeps context of text piece that isn't normalized
+func (st *typoState) skip(s string) {
+	if r := []rune(s); len(r) != 0 {
+		st.prev = r[len(r)-1]
+	}
+}
+
+// openingContext reports whether quote after rune r opens quotation
+func openingContext(r rune) bool {
+	return r == 0 || unicode.IsSpace(r) || r == nbsp || strings.ContainsRune("([{«„“‚‘—–-/", r)
+}
+
+func (st *typoState) quotes(s string) string {
+	var sb strings.Builder
+	prev := st.prev
+	rs := []rune(s)
+	for i, r := range rs {
+		var next rune
+		if i+1 < len(rs) {
+			next = rs[i+1]
+		}
+		switch r {
+		case '"':
+			level := st.depth
+			if openingContext(prev) && !(next == 0 || unicode.IsSpace(next)) || st.depth == 0 {
+				if level > 1 {
+					level = 1
+				}
+				sb.WriteString(st.lang.quotes[level][0])
+				st.depth++
+			} else {
+				st.depth--
+				level = st.depth
+				if level > 1 {
+					level = 1
+				}
+				sb.WriteString(st.lang.quotes[level][1])
+			}
+		case '\'':
+			if unicode.IsLetter(prev) || unicode.IsDigit(prev) {
+				sb.WriteRune('’')
+			} else if openingContext(prev) && st.lang.quotes[1][0] == "‘" {
+				sb.WriteRune('‘')
+			} else {
+				sb.WriteRune(r)
+			}
+		default:
+			sb.WriteRune(r)
+		}
+		prev = r
+	}
+	return sb.String()
+}
+
+// shortWords replaces space after short prepositions and conjunctions
+// with non-breaking space
+func (st *typoState) shortWords(s string) string {
+	rs := []rune(s)
+	start := 0
+	if unicode.IsLetter(st.prev) {
+		// word started in previous piece
+		start = -1
+	}
+	for i, r := range rs {
+		switch {
+		case unicode.IsLetter(r):
+		case r == ' ' && start >= 0 && st.lang.short[strings.ToLower(string(rs[start:i]))]:
+			rs[i] = nbsp
+			start = i + 1
+		case unicode.IsSpace(r) || r == nbsp || strings.ContainsRune("([«„“‚‘", r):
+			start = i + 1
+		default:
+			start = -1
+		}
+	}
+	return string(rs)
+}
+
+// textPiece is text of inline content. Code pieces are not normalized.
+type textPiece struct {
+	s    *string
+	code bool
+}
+
+// textPieces returns inline content text pieces of e in order.
+// Text inside skip elements is marked as code.
+func textPieces(e *etree.Element, code bool, skip ...string) []textPiece {
+	res := []textPiece{}
+	for _, t := range e.Child {
+		switch c := t.(type) {
+		case *etree.CharData:
+			res = append(res, textPiece{&c.Data, code})
+		case *etree.Element:
+			res = append(res, textPieces(c, code || hasTag(c, skip...), skip...)...)
+			res = append(res, textPiece{&c.TailData, code})
+		}
+	}
+	return res
+}
+
+// normalizeElement applies typography rules to paragraphs of e.
+// Element lang attribute overrides lang.
+func normalizeElement(e *etree.Element, lang string, rules TypoRule) {
+	if l := e.SelectAttrValue("lang", ""); l != "" {
+		lang = l
+	}
+	if hasTag(e, inlineTags...) {
+		st := newTypoState(lang, rules)
+		pieces := textPieces(e, false, "code")
+		for _, p := range pieces {
+			if p.code {
+				st.skip(*p.s)
+				continue
+			}
+			*p.s = st.text(*p.s)
+		}
+		if n := len(pieces); rules&TypoSpaces != 0 && n != 0 && !pieces[n-1].code {
+			*pieces[n-1].s = strings.TrimRight(*pieces[n-1].s, " ")
+		}
+		return
+	}
+	for _, c := range e.ChildElements() {
+		normalizeElement(c, lang, rules)
+	}
+}
+
+// NormalizeTypography applies typography rules to book text and
+// annotation according to book language
+func (d *fb2) NormalizeTypography(rules TypoRule) {
+	d.Lock()
+	defer d.Unlock()
+	lang := d.data.Description.TitleInfo.Lang
+	normalizeElement(d.body, lang, rules)
+	for _, b := range d.bodies {
+		normalizeElement(b, lang, rules)
+	}
+	if d.annotation != nil {
+		normalizeElement(d.annotation, lang, rules)
+	}
+}
@@ -0,0 +1,68 @@
+package fb2
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNormalizeText(t *testing.T) {
+	tests := []struct {
+		name  string
+		lang  string
+		rules TypoRule
+		in    string
+		want  string
+	}{
+		{"ru quotes", "ru", TypoQuotes, `Он сказал "привет "друг"" и ушёл`, `Он сказал «привет „друг“» и ушёл`},
+		{"ru dialogue", "ru-RU", TypoDashes, `- Да - сказал он`, "—\u00a0Да\u00a0— сказал он"},
+		{"ru nbsp", "ru", TypoNbsp, `Он пошёл в лес и на реку`, "Он пошёл в\u00a0лес и\u00a0на\u00a0реку"},
+		{"en quotes", "en", TypoQuotes, `She said "it's 'fine'"`, `She said “it’s ‘fine’”`},
+		{"en dash", "en", TypoDashes, `word -- word`, `word—word`},
+		{"de", "de", TypoQuotes | TypoDashes, `Er sagt "ja" - oder`, "Er sagt „ja“\u00a0– oder"},
+		{"fr", "fr", TypoQuotes | TypoNbsp, `Il dit "oui" : vraiment?`, "Il dit «\u00a0oui\u00a0»\u00a0: vraiment\u00a0?"},
+		{"fr guillemets", "fr", TypoAll, "« Bonjour » et «salut»", "«\u00a0Bonjour\u00a0» et\u00a0«\u00a0salut\u00a0»"},
+		{"fr guillemets spaces only", "fr", TypoSpaces, "«  Bonjour  »", "« Bonjour »"},
+		{"spaces", "ru", TypoSpaces | TypoEllipsis | TypoNbsp, "  Текст  ( в скобках ) , и ....  ", "Текст (в\u00a0скобках), и…"},
+		{"unknown lang", "xx", TypoAll, `a "b" - c...`, `a "b" - c…`},
+		{"disabled", "ru", 0, `"a" - b...`, `"a" - b...`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NormalizeText(tt.in, tt.lang, tt.rules)
+			if got != tt.want {
+				t.Errorf("NormalizeText() = %q, want %q", got, tt.want)
+			}
+			if again := NormalizeText(got, tt.lang, tt.rules); again != got {
+				t.Errorf("NormalizeText() is not idempotent: %q", again)
+			}
+		})
+	}
+}
+
+func Test_fb2_NormalizeTypography(t *testing.T) {
+	d := NewFB2("Test")
+	d.SetLang("ru")
+	d.AddSectionBlocks("Глава",
+		P(Text(`- "Привет`), Emphasis(Text(`, мир"`)), Text(` ... `)),
+		P(Code(Text(`x := "a" - b`)), Text(` и "код"`)),
+	)
+	if err := d.AddSection(`<p xml:lang="en">He said "hi"</p>`, "Eng"); err != nil {
+		t.Fatalf("fb2.AddSection() error = %v", err)
+	}
+	d.SetAnnotation(`Книга о "жизни"`)
+	d.NormalizeTypography(TypoAll)
+	out, err := d.WriteToString()
+	if err != nil {
+		t.Fatalf("fb2.WriteToString() error = %v", err)
+	}
+	for _, s := range []string{
+		"<p>—\u00a0«Привет<emphasis>, мир»</emphasis>…</p>",
+		"<p><code>x := &quot;a&quot; - b</code> и\u00a0«код»</p>",
+		`<p xml:lang="en">He said “hi”</p>`,
+		"<p>Книга о\u00a0«жизни»</p>",
+	} {
+		if !strings.Contains(out, s) {
+			t.Errorf("fb2.WriteToString() doesn't contain %q:\n%s", s, out)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package fb2
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	etree "github.com/rupor-github/fb2converter/etree"
+)
+
+var (
+	// ErrMissingField is returned by Validate for empty required elements
+	ErrMissingField = errors.New("missing required field")
+	// ErrBrokenImage is returned by Validate for images referring
+	// to missing binaries
+	ErrBrokenImage = errors.New("broken image link")
+	// ErrInvalidBinary is returned by Validate for binaries
+	// with malformed base64 content
+	ErrInvalidBinary = errors.New("invalid binary")
+)
+
+// validateAuthors checks that authors have last name or nickname
+func validateAuthors(where string, authors []AuthorType) []error {
+	if len(authors) == 0 {
+		return []error{fmt.Errorf("%s author error: %w", where, ErrMissingField)}
+	}
+	errs := []error{}
+	for i, a := range authors {
+		if strings.TrimSpace(a.LastName) == "" && strings.TrimSpace(a.Nickname) == "" {
+			errs = append(errs, fmt.Errorf("%s author %d last-name error: %w", where, i, ErrMissingField))
+		}
+	}
+	return errs
+}
+
+// validateTitleInfo checks required title-info fields and genres
+func validateTitleInfo(where string, ti *TitleInfoType) []error {
+	errs := []error{}
+	if len(ti.Genre) == 0 {
+		errs = append(errs, fmt.Errorf("%s genre error: %w", where, ErrMissingField))
+	}
+	for _, g := range ti.Genre {
+		if err := ValidateGenre(g.Text); err != nil {
+			errs = append(errs, fmt.Errorf("%s genre error: %w", where, err))
+		}
+	}
+	errs = append(errs, validateAuthors(where, ti.Author)...)
+	if strings.TrimSpace(ti.BookTitle) == "" {
+		errs = append(errs, fmt.Errorf("%s book-title error: %w", where, ErrMissingField))
+	}
+	if strings.TrimSpace(ti.Lang) == "" {
+		errs = append(errs, fmt.Errorf("%s lang error: %w", where, ErrMissingField))
+	}
+	return errs
+}
+
+// countIDs adds number of uses of each id of e and its descendants to ids
+func countIDs(e *etree.Element, ids map[string]int) {
+	if id := e.SelectAttrValue("id", ""); id != "" {
+		ids[id]++
+	}
+	for _, c := range e.ChildElements() {
+		countIDs(c, ids)
+	}
+}
+
+// validateLinks checks links and images of e against ids and binaries
+func validateLinks(e *etree.Element, ids map[string]int, binaries map[string]bool) []error {
+	errs := []error{}
+	for _, a := range e.FindElements(".//a") {
+		if href := a.SelectAttrValue("href", ""); strings.HasPrefix(href, "#") && ids[href[1:]] == 0 {
+			errs = append(errs, fmt.Errorf("link %q error: %w", href, ErrBrokenLink))
+		}
+	}
+	for _, img := range e.FindElements(".//image") {
+		if href := img.SelectAttrValue("href", ""); strings.HasPrefix(href, "#") && !binaries[href[1:]] {
+			errs = append(errs, fmt.Errorf("image %q error: %w", href, ErrBrokenImage))
+		}
+	}
+	return errs
+}
+
+// Validate checks book for problems making it invalid FB2: missing
+// required description fields, unknown genres, malformed ISBN, duplicate
+// ids, broken links and images and malformed binaries. It returns all
+// found problems, nil for valid book.
+func (d *fb2) Validate() []error {
+	d.Lock()
+	defer d.Unlock()
+	var errs []error
+	desc := &d.data.Description
+	errs = append(errs, validateTitleInfo("title-info", &desc.TitleInfo)...)
+	if desc.SrcTitleInfo != nil {
+		errs = append(errs, validateTitleInfo("src-title-info", desc.SrcTitleInfo)...)
+	}
+	di := &desc.DocumentInfo
+	errs = append(errs, validateAuthors("document-info", di.Author)...)
+	if strings.TrimSpace(di.Date.Text) == "" && di.Date.Value == "" {
+		errs = append(errs, fmt.Errorf("document-info date error: %w", ErrMissingField))
+	}
+	if strings.TrimSpace(di.Id) == "" {
+		errs = append(errs, fmt.Errorf("document-info id error: %w", ErrMissingField))
+	}
+	if strings.TrimSpace(di.Version) == "" {
+		errs = append(errs, fmt.Errorf("document-info version error: %w", ErrMissingField))
+	}
+	if isbn := desc.PublishInfo.Isbn; isbn != nil && strings.TrimSpace(isbn.Text) != "" {
+		if err := ValidateISBN(isbn.Text); err != nil {
+			errs = append(errs, fmt.Errorf("publish-info isbn error: %w", err))
+		}
+	}
+
+	if len(d.sections()) == 0 {
+		errs = append(errs, fmt.Errorf("body section error: %w", ErrMissingField))
+	}
+	ids := map[string]int{}
+	countIDs(d.body, ids)
+	for _, b := range d.bodies {
+		countIDs(b, ids)
+	}
+	binaries := map[string]bool{}
+	for _, b := range d.data.Binary {
+		if b.Id == "" {
+			errs = append(errs, fmt.Errorf("binary id error: %w", ErrMissingField))
+			continue
+		}
+		ids[b.Id]++
+		binaries[b.Id] = true
+		if _, err := base64.StdEncoding.DecodeString(stripSpaces(b.Text)); err != nil {
+			errs = append(errs, fmt.Errorf("binary %q error: %w", b.Id, ErrInvalidBinary))
+		}
+	}
+	dups := []string{}
+	for id, n := range ids {
+		if n > 1 {
+			dups = append(dups, id)
+		}
+	}
+	sort.Strings(dups)
+	for _, id := range dups {
+		errs = append(errs, fmt.Errorf("id %q error: %w", id, ErrDuplicateID))
+	}
+
+	for _, c := range desc.TitleInfo.Coverpage {
+		if c.Image != nil && !binaries[strings.TrimPrefix(c.Image.XlinkHref, "#")] {
+			errs = append(errs, fmt.Errorf("coverpage image %q error: %w", c.Image.XlinkHref, ErrBrokenImage))
+		}
+	}
+	errs = append(errs, validateLinks(d.body, ids, binaries)...)
+	for _, b := range d.bodies {
+		errs = append(errs, validateLinks(b, ids, binaries)...)
+	}
+	if d.annotation != nil {
+		errs = append(errs, validateLinks(d.annotation, ids, binaries)...)
+	}
+	return errs
+}
+
+// stripSpaces removes whitespace from base64 text
+func stripSpaces(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == ' ' || r == '\t' || r == '\r' || r == '\n' {
+			return -1
+		}
+		return r
+	}, s)
+}
@@ -0,0 +1,68 @@
+package fb2
+
+import (
+	"errors"
+	"testing"
+)
+
+// validBook returns book passing Validate
+func validBook(t *testing.T) FB2 {
+	t.Helper()
+	d := NewFB2("Test")
+	d.SetLang("en")
+	d.AddAuthor(RoleAuthor, AuthorType{FirstName: "John", LastName: "Doe"})
+	d.AddAuthor(RoleDocumentAuthor, AuthorType{Nickname: "editor"})
+	if err := d.SetGenre([]string{"sf"}); err != nil {
+		t.Fatalf("fb2.SetGenre() error = %v", err)
+	}
+	if err := d.AddSection(`<p id="p1">Text <a l:href="#p1">link</a></p>`, "Chapter"); err != nil {
+		t.Fatalf("fb2.AddSection() error = %v", err)
+	}
+	return d
+}
+
+func Test_fb2_Validate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(d FB2)
+		want   []error
+	}{
+		{"valid", func(d FB2) {}, nil},
+		{"missing fields", func(d FB2) {
+			d.SetTitle("")
+			d.SetLang("")
+			d.RemoveAuthor(RoleAuthor, AuthorType{FirstName: "John", LastName: "Doe"})
+		}, []error{ErrMissingField, ErrMissingField, ErrMissingField}},
+		{"unknown genre", func(d FB2) {
+			d.Data().Description.TitleInfo.Genre = []Genre{{Text: "golang"}}
+		}, []error{ErrUnknownGenre}},
+		{"isbn", func(d FB2) {
+			d.Data().Description.PublishInfo.Isbn = &TextFieldType{Text: "978-0-306-40615-6"}
+		}, []error{ErrISBNChecksum}},
+		{"broken links", func(d FB2) {
+			d.Body().FindElement("//p").CreateElement("a").CreateAttr("l:href", "#missing")
+			d.Body().FindElement("//section").CreateElement("image").CreateAttr("l:href", "#img.jpg")
+		}, []error{ErrBrokenLink, ErrBrokenImage}},
+		{"duplicate id", func(d FB2) {
+			d.Body().FindElement("//section").CreateAttr("id", "p1")
+		}, []error{ErrDuplicateID}},
+		{"binary", func(d FB2) {
+			d.Data().Binary = append(d.Data().Binary, FictionBookBinary{Id: "img.jpg", Text: "@@@"})
+		}, []error{ErrInvalidBinary}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := validBook(t)
+			tt.modify(d)
+			got := d.Validate()
+			if len(got) != len(tt.want) {
+				t.Fatalf("fb2.Validate() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if !errors.Is(got[i], tt.want[i]) {
+					t.Errorf("fb2.Validate()[%d] = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,378 @@
+package fb2
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ErrNoFB2InZip is returned when zip archive contains no fb2 file
+var ErrNoFB2InZip = errors.New("no fb2 file in zip")
+
+// zipMagic starts every zip archive
+var zipMagic = []byte("PK\x03\x04")
+
+// zipEntry returns fb2 file of zip archive. Archive with single file
+// is treated as fb2 whatever its name is.
+func zipEntry(zr *zip.Reader) (*zip.File, error) {
+	for _, f := range zr.File {
+		if strings.EqualFold(filepath.Ext(f.Name), ".fb2") {
+			return f, nil
+		}
+	}
+	if len(zr.File) == 1 {
+		return zr.File[0], nil
+	}
+	return nil, ErrNoFB2InZip
+}
+
+// zipReadCloser closes zip entry and archive file
+type zipReadCloser struct {
+	io.ReadCloser
+	f *os.File
+}
+
+func (z *zipReadCloser) Close() error {
+	err := z.ReadCloser.Close()
+	if ferr := z.f.Close(); err == nil {
+		err = ferr
+	}
+	return err
+}
+
+// OpenFB2Stream opens FictionBook XML of .fb2 or zipped .fb2 file.
+// Zip archives are detected by content, not by file extension.
+func OpenFB2Stream(srcFilePath string) (io.ReadCloser, error) {
+	f, err := os.Open(srcFilePath)
+	if err != nil {
+		return nil, fmt.Errorf("open fb2 error: %w", err)
+	}
+	magic := make([]byte, len(zipMagic))
+	n, _ := io.ReadFull(f, magic)
+	if !bytes.Equal(magic[:n], zipMagic) {
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			f.Close()
+			return nil, fmt.Errorf("open fb2 error: %w", err)
+		}
+		return f, nil
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("open fb2 zip error: %w", err)
+	}
+	zr, err := zip.NewReader(f, info.Size())
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("open fb2 zip error: %w", err)
+	}
+	entry, err := zipEntry(zr)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("open fb2 zip error: %w", err)
+	}
+	r, err := entry.Open()
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("open fb2 zip error: %w", err)
+	}
+	return &zipReadCloser{ReadCloser: r, f: f}, nil
+}
+
+// zipDataDescriptor starts data descriptor following entry data
+var zipDataDescriptor = []byte("PK\x07\x08")
+
+// zipMax marks sizes stored in zip64 extra field
+const zipMax = 0xffffffff
+
+// zipHeader is local file header of zip entry
+type zipHeader struct {
+	name   string
+	flags  uint16
+	method uint16
+	// size is compressed size, valid without data descriptor
+	size  int64
+	zip64 bool
+}
+
+// readZipHeader reads local file header. It returns ErrNoFB2InZip
+// when central directory is reached.
+func readZipHeader(r *bufio.Reader) (*zipHeader, error) {
+	var b [30]byte
+	if _, err := io.ReadFull(r, b[:]); err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(b[:4], zipMagic) {
+		return nil, ErrNoFB2InZip
+	}
+	h := &zipHeader{
+		flags:  binary.LittleEndian.Uint16(b[6:]),
+		method: binary.LittleEndian.Uint16(b[8:]),
+		size:   int64(binary.LittleEndian.Uint32(b[18:])),
+	}
+	usize := binary.LittleEndian.Uint32(b[22:])
+	name := make([]byte, binary.LittleEndian.Uint16(b[26:]))
+	if _, err := io.ReadFull(r, name); err != nil {
+		return nil, err
+	}
+	h.name = string(name)
+	extra := make([]byte, binary.LittleEndian.Uint16(b[28:]))
+	if _, err := io.ReadFull(r, extra); err != nil {
+		return nil, err
+	}
+	for len(extra) >= 4 {
+		id := binary.LittleEndian.Uint16(extra)
+		n := int(binary.LittleEndian.Uint16(extra[2:]))
+		if n > len(extra)-4 {
+			break
+		}
+		field := extra[4 : 4+n]
+		extra = extra[4+n:]
+		if id != 0x0001 {
+			continue
+		}
+		h.zip64 = true
+		// zip64 field has sizes whose header values are zipMax,
+		// uncompressed size first
+		if usize == zipMax && len(field) >= 8 {
+			field = field[8:]
+		}
+		if h.size == zipMax && len(field) >= 8 {
+			h.size = int64(binary.LittleEndian.Uint64(field))
+		}
+	}
+	return h, nil
+}
+
+// descriptor reports whether entry sizes follow entry data
+func (h *zipHeader) descriptor() bool {
+	return h.flags&0x8 != 0
+}
+
+// countReader counts bytes read by decompressor
+type countReader struct {
+	r *bufio.Reader
+	n int64
+}
+
+func (c *countReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func (c *countReader) ReadByte() (byte, error) {
+	b, err := c.r.ReadByte()
+	if err == nil {
+		c.n++
+	}
+	return b, err
+}
+
+// skipDescriptor skips data descriptor of entry with compressed
+// and uncompressed sizes
+func skipDescriptor(r *bufio.Reader, zip64 bool, size, usize int64) error {
+	n := 12
+	if zip64 || size >= zipMax || usize >= zipMax {
+		n = 20
+	}
+	if sig, _ := r.Peek(4); bytes.Equal(sig, zipDataDescriptor) {
+		n += 4
+	}
+	_, err := r.Discard(n)
+	return err
+}
+
+// storedReader reads stored entry data followed by data descriptor.
+// Entry ends at descriptor signature with matching crc and size.
+type storedReader struct {
+	r    *bufio.Reader
+	crc  hash.Hash32
+	n    int64
+	done bool
+}
+
+func newStoredReader(r *bufio.Reader) *storedReader {
+	return &storedReader{r: r, crc: crc32.NewIEEE()}
+}
+
+// atDescriptor checks for descriptor of data read so far and skips it
+func (s *storedReader) atDescriptor() bool {
+	d, _ := s.r.Peek(24)
+	if len(d) < 16 || !bytes.Equal(d[:4], zipDataDescriptor) ||
+		binary.LittleEndian.Uint32(d[4:]) != s.crc.Sum32() {
+		return false
+	}
+	if binary.LittleEndian.Uint32(d[8:]) == uint32(s.n) && binary.LittleEndian.Uint32(d[12:]) == uint32(s.n) &&
+		s.n < zipMax {
+		s.r.Discard(16)
+		return true
+	}
+	if len(d) == 24 && binary.LittleEndian.Uint64(d[8:]) == uint64(s.n) && binary.LittleEndian.Uint64(d[16:]) == uint64(s.n) {
+		s.r.Discard(24)
+		return true
+	}
+	return false
+}
+
+func (s *storedReader) Read(p []byte) (int, error) {
+	if s.done {
+		return 0, io.EOF
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if _, err := s.r.Peek(len(zipDataDescriptor)); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return 0, err
+	}
+	buf, _ := s.r.Peek(s.r.Buffered())
+	// data before possible signature, last bytes may start one
+	safe := len(buf) - len(zipDataDescriptor) + 1
+	if i := bytes.Index(buf, zipDataDescriptor); i >= 0 {
+		safe = i
+	}
+	if safe == 0 {
+		if s.atDescriptor() {
+			s.done = true
+			return 0, io.EOF
+		}
+		safe = 1
+	}
+	if safe > len(p) {
+		safe = len(p)
+	}
+	n, err := s.r.Read(p[:safe])
+	s.crc.Write(p[:n])
+	s.n += int64(n)
+	return n, err
+}
+
+// skipZipEntry skips data of zip entry. Entries with data descriptor
+// are skipped by decompressing or scanning them.
+func skipZipEntry(r *bufio.Reader, h *zipHeader) error {
+	if !h.descriptor() {
+		_, err := io.CopyN(ioutil.Discard, r, h.size)
+		return err
+	}
+	switch h.method {
+	case zip.Store:
+		_, err := io.Copy(ioutil.Discard, newStoredReader(r))
+		return err
+	case zip.Deflate:
+		cr := &countReader{r: r}
+		usize, err := io.Copy(ioutil.Discard, flate.NewReader(cr))
+		if err != nil {
+			return err
+		}
+		return skipDescriptor(r, h.zip64, cr.n, usize)
+	}
+	return zip.ErrAlgorithm
+}
+
+// zipStream returns content of the first fb2 entry of zip archive
+// read sequentially from r, so archive is read without seeking.
+// Stored entries with data descriptor need descriptor signature.
+func zipStream(r *bufio.Reader) (io.Reader, error) {
+	for {
+		h, err := readZipHeader(r)
+		if err != nil {
+			return nil, err
+		}
+		if !strings.EqualFold(filepath.Ext(h.name), ".fb2") {
+			if err := skipZipEntry(r, h); err != nil {
+				return nil, fmt.Errorf("zip entry %s error: %w", h.name, err)
+			}
+			continue
+		}
+		switch {
+		case h.method == zip.Deflate:
+			return flate.NewReader(r), nil
+		case h.method == zip.Store && h.descriptor():
+			return newStoredReader(r), nil
+		case h.method == zip.Store:
+			return io.LimitReader(r, h.size), nil
+		}
+		return nil, zip.ErrAlgorithm
+	}
+}
+
+// ReadFB2Zip parses FictionBook document from zip archive
+func ReadFB2Zip(r io.ReaderAt, size int64) (FB2, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("read fb2 zip error: %w", err)
+	}
+	entry, err := zipEntry(zr)
+	if err != nil {
+		return nil, fmt.Errorf("read fb2 zip error: %w", err)
+	}
+	f, err := entry.Open()
+	if err != nil {
+		return nil, fmt.Errorf("read fb2 zip error: %w", err)
+	}
+	defer f.Close()
+	return ReadFB2(f)
+}
+
+// ZipFB2 writes archive with single entry name containing FictionBook
+// XML read from r, compressed with best compression
+func ZipFB2(w io.Writer, name string, r io.Reader) error {
+	zw := zip.NewWriter(w)
+	zw.RegisterCompressor(zip.Deflate, func(out io.Writer) (io.WriteCloser, error) {
+		return flate.NewWriter(out, flate.BestCompression)
+	})
+	f, err := zw.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("zip fb2 error: %w", err)
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		return fmt.Errorf("zip fb2 error: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("zip fb2 error: %w", err)
+	}
+	return nil
+}
+
+// zipEntryName returns fb2 entry name for archive path like book.fb2.zip
+func zipEntryName(zipPath string) string {
+	name := strings.TrimSuffix(filepath.Base(zipPath), filepath.Ext(zipPath))
+	if !strings.EqualFold(filepath.Ext(name), ".fb2") {
+		name += ".fb2"
+	}
+	return name
+}
+
+// WriteZip writes book as zip archive with single entry name
+func (d *fb2) WriteZip(w io.Writer, name string) error {
+	d.Lock()
+	defer d.Unlock()
+	return d.writeZip(w, name)
+}
+
+func (d *fb2) writeZip(w io.Writer, name string) error {
+	book, err := d.writeToString()
+	if err != nil {
+		return fmt.Errorf("write zip error: %w", err)
+	}
+	return ZipFB2(w, name, strings.NewReader(book))
+}
@@ -0,0 +1,73 @@
+package fb2
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestZip(t *testing.T) {
+	d := NewFB2("Zipped")
+	d.AddSectionBlocks("Chapter", P(Text("Text")))
+	dir := t.TempDir()
+	path := filepath.Join(dir, "book.fb2.zip")
+	if err := d.WriteToFile(path); err != nil {
+		t.Fatalf("fb2.WriteToFile() error = %v", err)
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(b, zipMagic) {
+		t.Fatalf("fb2.WriteToFile() didn't write zip")
+	}
+	got, err := OpenFB2(path)
+	if err != nil {
+		t.Fatalf("OpenFB2() error = %v", err)
+	}
+	if got.Title() != "Zipped" {
+		t.Errorf("OpenFB2() title = %q, want %q", got.Title(), "Zipped")
+	}
+	got, err = ReadFB2Zip(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		t.Fatalf("ReadFB2Zip() error = %v", err)
+	}
+	if got.Title() != "Zipped" {
+		t.Errorf("ReadFB2Zip() title = %q, want %q", got.Title(), "Zipped")
+	}
+
+	// plain file is read as is
+	r, err := OpenFB2Stream("testdata/test1.fb2")
+	if err != nil {
+		t.Fatalf("OpenFB2Stream() error = %v", err)
+	}
+	plain, _ := ioutil.ReadAll(r)
+	r.Close()
+	src, _ := ioutil.ReadFile("testdata/test1.fb2")
+	if !bytes.Equal(plain, src) {
+		t.Errorf("OpenFB2Stream() changed plain file")
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range []string{"a.txt", "b.txt"} {
+		if _, err := zw.Create(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	zw.Close()
+	noFB2 := filepath.Join(dir, "nofb2.zip")
+	if err := ioutil.WriteFile(noFB2, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenFB2(noFB2); !errors.Is(err, ErrNoFB2InZip) {
+		t.Errorf("OpenFB2() error = %v, want %v", err, ErrNoFB2InZip)
+	}
+	if _, err := OpenFB2(filepath.Join(dir, "missing.fb2")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("OpenFB2() error = %v, want %v", err, os.ErrNotExist)
+	}
+}